@@ -10,6 +10,8 @@ import (
 
 	awsUtils "github.com/ps-resources/gh-glx-migrator/internal/aws"
 	"github.com/ps-resources/gh-glx-migrator/internal/clients"
+	"github.com/ps-resources/gh-glx-migrator/internal/config"
+	"github.com/ps-resources/gh-glx-migrator/internal/failure"
 	ghlog "github.com/ps-resources/gh-glx-migrator/pkg/logger"
 
 	"github.com/spf13/cobra"
@@ -28,7 +30,7 @@ gh glx generate-aws-presigned-url --bucket my-bucket --duration 30m`,
 		RunE: generatePresignedURL,
 	}
 
-	cmd.Flags().String("bucket", os.Getenv("AWS_BUCKET"), "S3 bucket name")
+	cmd.Flags().String("bucket", "", "S3 bucket name (default AWS_BUCKET or the profile's storage.aws.bucket)")
 	cmd.Flags().String("blob-name", "", "Name to use for blob in AWS (defaults to local file name)")
 	cmd.Flags().Duration("duration", 30*time.Minute, "URL validity duration (default 30 minutes)")
 
@@ -50,7 +52,7 @@ AWS credentials must be configured via environment variables.`,
 
 	cmd.Flags().String("blob-name", "", "Name to use for blob in AWS (defaults to local file name)")
 	cmd.Flags().String("archive-file-path", "", "Path to migration archive file")
-	cmd.Flags().String("bucket", os.Getenv("AWS_BUCKET"), "S3 bucket name")
+	cmd.Flags().String("bucket", "", "S3 bucket name (default AWS_BUCKET or the profile's storage.aws.bucket)")
 
 	errFile := cmd.MarkFlagRequired("archive-file-path")
 	if errFile != nil {
@@ -61,19 +63,14 @@ AWS credentials must be configured via environment variables.`,
 	return cmd
 }
 func generatePresignedURL(cmd *cobra.Command, args []string) error {
-	// Verify required AWS environment variables on startup
-	if err := verifyAws(); err != nil {
-		fmt.Println("Error:", err)
-		os.Exit(1)
-	}
 	ghlog.Logger.Info("Reading input values for generating pre-signed URL")
 
-	bucket, _ := cmd.Flags().GetString("bucket")
+	bucket := flagOrSetting(cmd, "bucket", config.AWSBucket)
 	blobName, _ := cmd.Flags().GetString("blob-name")
 	duration, _ := cmd.Flags().GetDuration("duration")
 
-	if bucket == "" {
-		return fmt.Errorf("bucket name is required. Please provide it using --bucket flag or set it in the environment variable AWS_BUCKET")
+	if err := checkAwsSettings(cmd, bucket); err != nil {
+		return err
 	}
 
 	ctx, cancel := context.WithTimeout(cmd.Context(), 2*time.Minute)
@@ -84,35 +81,32 @@ func generatePresignedURL(cmd *cobra.Command, args []string) error {
 
 	if err != nil {
 		ghlog.Logger.Error("failed to create S3Manager", zap.Error(err))
-		return fmt.Errorf("failed to initialize AWS S3 manager: %w", err)
+		return failure.Storage(fmt.Errorf("failed to initialize AWS S3 manager: %w", err))
 	}
 
 	// Generate presigned URL using the manager
 	url, err := s3Manager.GeneratePresignedURL(ctx, blobName, duration)
 	if err != nil {
-		return fmt.Errorf("failed to generate presigned URL: %w", err)
+		return failure.Storage(fmt.Errorf("failed to generate presigned URL: %w", err))
 	}
 
 	ghlog.Logger.Info("Generated pre-signed URL S3 Successfully")
-	ghlog.Logger.Info("Pre-signed URL", zap.String("url", url))
 	ghlog.Logger.Info("URL will expire at", zap.Time("expiration", time.Now().Add(duration)))
 
+	// The URL is the output of the command. Logs only show it redacted.
+	fmt.Println(url)
+
 	return nil
 }
 
 func uploadToS3Bucket(cmd *cobra.Command, args []string) error {
-	// Verify required AWS environment variables on startup
-	if err := verifyAws(); err != nil {
-		fmt.Println("Error:", err)
-		os.Exit(1)
-	}
 	ghlog.Logger.Info("Reading input values for uploading to S3 bucket")
-	bucket, _ := cmd.Flags().GetString("bucket")
+	bucket := flagOrSetting(cmd, "bucket", config.AWSBucket)
 	blobName, _ := cmd.Flags().GetString("blob-name")
 	archiveFilePath, _ := cmd.Flags().GetString("archive-file-path")
 
-	if bucket == "" {
-		return fmt.Errorf("bucket name is required. Please provide it using --bucket flag or set it in the environment variable AWS_BUCKET")
+	if err := checkAwsSettings(cmd, bucket); err != nil {
+		return err
 	}
 
 	ctx, cancel := context.WithTimeout(cmd.Context(), 30*time.Minute)
@@ -141,13 +135,13 @@ func uploadToS3Bucket(cmd *cobra.Command, args []string) error {
 	s3Manager, err := awsUtils.NewS3Manager(ctx, awsClient, bucket)
 	if err != nil {
 		ghlog.Logger.Error("failed to create S3Manager", zap.Error(err))
-		return fmt.Errorf("failed to initialize AWS S3 manager: %w", err)
+		return failure.Storage(fmt.Errorf("failed to initialize AWS S3 manager: %w", err))
 	}
 
 	// s3Manager.SetPartSize(200 * 1024 * 1024) // 200MB parts
 
 	if err := s3Manager.Upload(ctx, blobName, file); err != nil {
-		return fmt.Errorf("failed to upload to S3 bucket: %w", err)
+		return failure.Storage(fmt.Errorf("failed to upload to S3 bucket: %w", err))
 	}
 
 	ghlog.Logger.Info("File uploaded successfully",
@@ -155,3 +149,12 @@ func uploadToS3Bucket(cmd *cobra.Command, args []string) error {
 		zap.String("blobName", blobName))
 	return nil
 }
+
+// checkAwsSettings fails when the AWS credentials, region or bucket, given
+// with --bucket, are not set.
+func checkAwsSettings(cmd *cobra.Command, bucket string) error {
+	values := config.Snapshot()
+	values.Set(config.AWSBucket, bucket)
+	_, err := checkSettings(cmd, values, config.Requirements{Storage: config.StorageAWS})
+	return err
+}
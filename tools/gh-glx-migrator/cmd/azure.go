@@ -2,10 +2,11 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 	"time"
 
 	"github.com/ps-resources/gh-glx-migrator/internal/azure"
+	"github.com/ps-resources/gh-glx-migrator/internal/config"
+	"github.com/ps-resources/gh-glx-migrator/internal/failure"
 	ghlog "github.com/ps-resources/gh-glx-migrator/pkg/logger"
 
 	"github.com/spf13/cobra"
@@ -38,10 +39,8 @@ Azure credentials must be set via environment variables:
 }
 
 func runUploadToAzure(cmd *cobra.Command, args []string) error {
-	// Verify required Azure environment variables on startup
-	if err := verifyAzure(); err != nil {
-		fmt.Println("Error:", err)
-		os.Exit(1)
+	if _, err := checkSettings(cmd, config.Snapshot(), config.Requirements{Storage: config.StorageAzure}); err != nil {
+		return err
 	}
 	ghlog.Logger.Info("Reading input values for uploading to Azure Blob Storage")
 
@@ -51,12 +50,8 @@ func runUploadToAzure(cmd *cobra.Command, args []string) error {
 	duration, _ := cmd.Flags().GetDuration("duration")
 
 	// Get Azure credentials from environment
-	storageAccount := os.Getenv("AZURE_STORAGE_ACCOUNT")
-	storageAccessKey := os.Getenv("AZURE_STORAGE_ACCESS_KEY")
-
-	if storageAccount == "" || storageAccessKey == "" {
-		return fmt.Errorf("AZURE_STORAGE_ACCOUNT and AZURE_STORAGE_ACCESS_KEY environment variables must be set")
-	}
+	storageAccount := config.Lookup(config.AzureStorageAccount)
+	storageAccessKey := config.Lookup(config.AzureStorageAccessKey)
 
 	opts := &azure.AzureOptions{
 		StorageAccount:   storageAccount,
@@ -64,6 +59,7 @@ func runUploadToAzure(cmd *cobra.Command, args []string) error {
 		ContainerName:    containerName,
 		BlobName:         blobName,
 		ArchiveFilePath:  archiveFilePath,
+		BlobEndpoint:     config.Lookup(config.AzureBlobEndpoint),
 	}
 
 	ghlog.Logger.Info("Uploading file to Azure Blob Storage",
@@ -71,14 +67,16 @@ func runUploadToAzure(cmd *cobra.Command, args []string) error {
 		zap.String("blobName", blobName),
 		zap.String("archive-file-path", archiveFilePath))
 
-	presignedURL, err := azure.UploadToAzureBlob(opts, duration)
+	presignedURL, err := azure.UploadToAzureBlob(cmd.Context(), opts, duration)
 	if err != nil {
 		ghlog.Logger.Error("Failed to upload file to Azure", zap.Error(err))
-		return fmt.Errorf("failed to upload file to Azure: %v", err)
+		return failure.Storage(fmt.Errorf("failed to upload file to Azure: %w", err))
 	}
 
 	ghlog.Logger.Info("File uploaded successfully")
-	ghlog.Logger.Info("Presigned URL: " + presignedURL)
+
+	// The URL is the output of the command. Logs only show it redacted.
+	fmt.Println(presignedURL)
 
 	return nil
 }
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/ps-resources/gh-glx-migrator/internal/clients"
+	"github.com/ps-resources/gh-glx-migrator/internal/config"
+	ghlog "github.com/ps-resources/gh-glx-migrator/pkg/logger"
+
+	"github.com/spf13/cobra"
+)
+
+func ConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "View, set and validate the configuration file",
+		Long: `View, set and validate the configuration file.
+
+The configuration file (~/.config/gh-glx/config.yaml, or --config) holds named
+profiles with the source GitLab, target GitHub and storage settings. The
+profile is selected with --profile, GH_GLX_PROFILE or the current-profile of
+the file, in that order.
+
+Every setting is resolved in the order flag > environment variable > profile >
+default.`,
+		Example: `gh glx config set github.token vault://kv/migrations#github_pat --profile prod
+gh glx config set current-profile prod
+gh glx config view
+gh glx config validate`,
+	}
+
+	cmd.AddCommand(configViewCmd(), configSetCmd(), configValidateCmd())
+	return cmd
+}
+
+// noProfileAnnotation marks commands that must run even when the selected
+// profile does not exist yet.
+const noProfileAnnotation = "gh-glx/no-profile"
+
+// UseProfile makes the profile selected by --config and --profile the
+// fallback for settings that are not set by flag or environment variable, and
+// drops the GitHub credential created from the previous configuration.
+func UseProfile(cmd *cobra.Command) error {
+	if _, ok := cmd.Annotations[noProfileAnnotation]; ok {
+		return nil
+	}
+	path, _ := cmd.Flags().GetString("config")
+	profile, _ := cmd.Flags().GetString("profile")
+	if err := config.Use(cmd.Context(), path, profile); err != nil {
+		return err
+	}
+	clients.ResetDefaultGitHubCredential()
+	return nil
+}
+
+// flagOrSetting returns the value of the named flag if it is set, and the
+// resolved setting otherwise.
+func flagOrSetting(cmd *cobra.Command, name string, setting config.Setting) string {
+	if value, _ := cmd.Flags().GetString(name); value != "" {
+		return value
+	}
+	return config.Lookup(setting)
+}
+
+func configViewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "view",
+		Short: "Show the resolved settings and where they come from",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			showSecrets, _ := cmd.Flags().GetBool("show-secrets")
+			return writeConfigTable(os.Stdout, showSecrets)
+		},
+	}
+	cmd.Flags().Bool("show-secrets", false, "Print tokens and keys instead of masking them")
+	return cmd
+}
+
+func configSetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Set a setting of the selected profile, or the current-profile",
+		Args:  cobra.ExactArgs(2),
+		// The profile being set may not exist yet.
+		Annotations: map[string]string{noProfileAnnotation: ""},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, _ := cmd.Flags().GetString("config")
+			profile, _ := cmd.Flags().GetString("profile")
+
+			file, err := config.Load(path)
+			if err != nil {
+				return err
+			}
+
+			if args[0] == "current-profile" {
+				file.CurrentProfile = args[1]
+			} else {
+				setting, err := config.FindSetting(args[0])
+				if err != nil {
+					return err
+				}
+				file.Set(profile, setting, args[1])
+			}
+
+			if err := file.Save(path); err != nil {
+				return err
+			}
+			fmt.Printf("Set %s in %s\n", args[0], path)
+			return nil
+		},
+	}
+}
+
+func configValidateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate",
+		Short: "Check the resolved settings for missing or inconsistent values",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			problems := config.Validate(config.Snapshot())
+			problems = append(problems, config.ValidateReferences()...)
+			if notifications, err := config.Notifications(); err == nil {
+				problems = append(problems, config.ValidateNotifications(notifications)...)
+			}
+			// A required setting whose reference cannot be resolved is
+			// reported by both checks.
+			reported := map[string]bool{}
+			for _, problem := range problems {
+				if !reported[problem.Message] {
+					reported[problem.Message] = true
+					fmt.Println("✗ " + problem.Message)
+				}
+			}
+			if len(problems) > 0 {
+				return fmt.Errorf("configuration has %d problems", len(reported))
+			}
+			fmt.Println("✓ Configuration is valid")
+			return nil
+		},
+	}
+}
+
+func writeConfigTable(w io.Writer, showSecrets bool) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "KEY\tENV\tVALUE\tSOURCE"); err != nil {
+		return err
+	}
+	for _, setting := range config.All {
+		value, source, err := config.Resolve(setting)
+		if setting.Secret && value != "" && !showSecrets {
+			value = ghlog.Mask
+		}
+		if err != nil {
+			value = "unresolved: " + ghlog.Redact(err.Error())
+		}
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", setting.Key, setting.Env, value, source); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/ps-resources/gh-glx-migrator/internal/github"
+
+	"github.com/spf13/cobra"
+)
+
+// addDryRunFlag adds --dry-run to a command that honours it. The flag is not
+// global, so commands that would still make changes reject it.
+func addDryRunFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("dry-run", false, "Validate inputs and print the uploads and mutations that would be performed, without performing them")
+}
+
+// isDryRun reports whether the --dry-run flag is set.
+func isDryRun(cmd *cobra.Command) bool {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	return dryRun
+}
+
+// dryRunPlan collects the uploads and mutations a command would perform.
+type dryRunPlan struct {
+	actions []string
+}
+
+func (p *dryRunPlan) add(format string, args ...interface{}) {
+	p.actions = append(p.actions, fmt.Sprintf(format, args...))
+}
+
+func (p *dryRunPlan) print(w io.Writer) error {
+	if _, err := fmt.Fprintln(w, "Dry run: no changes were made. The following actions would be performed:"); err != nil {
+		return err
+	}
+	for i, action := range p.actions {
+		if _, err := fmt.Fprintf(w, "%d. %s\n", i+1, action); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkGitHubToken fails when the GitHub token lacks the scopes, or when org
+// is set, the role needed to run migrations.
+func checkGitHubToken(ctx context.Context, org string) error {
+	permissions, err := github.InspectToken(ctx, org)
+	if err != nil {
+		return err
+	}
+	if missing := permissions.MissingScopes(); !permissions.FineGrained && len(missing) > 0 {
+		return fmt.Errorf("GitHub token is missing scopes: %s", strings.Join(missing, ", "))
+	}
+	if org != "" && !permissions.CanMigrate && permissions.App {
+		return fmt.Errorf("%s cannot run migrations in %s; its installation needs the organization administration permission", permissions.Login, org)
+	}
+	if org != "" && !permissions.CanMigrate {
+		return fmt.Errorf("%s cannot run migrations in %s; run: %s", permissions.Login, org, grantMigratorRoleCommand(org, permissions.Login))
+	}
+	return nil
+}
+
+// validateArchive checks that the migration archive is a readable gzipped tar
+// file and returns its size. Only the first entry is read, so large archives
+// are not decompressed in full.
+func validateArchive(path string) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat archive: %w", err)
+	}
+	if info.Size() == 0 {
+		return 0, fmt.Errorf("archive %s is empty", path)
+	}
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return 0, fmt.Errorf("archive %s is not gzip compressed: %v", path, err)
+	}
+	if _, err := tar.NewReader(gz).Next(); err != nil {
+		return 0, fmt.Errorf("archive %s is not a tar archive: %v", path, err)
+	}
+
+	return info.Size(), nil
+}
+
+// checkArchiveURL checks that GitHub will be able to download the archive by
+// requesting its first byte. Presigned URLs are only signed for GET requests.
+func checkArchiveURL(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("invalid archive URL: %v", err)
+	}
+	req.Header.Set("Range", "bytes=0-0")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach archive URL: %v", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		return fmt.Errorf("archive URL returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -1,14 +1,17 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"os"
 	"strings"
 	"time"
 
+	"github.com/ps-resources/gh-glx-migrator/internal/config"
+	"github.com/ps-resources/gh-glx-migrator/internal/failure"
 	"github.com/ps-resources/gh-glx-migrator/internal/github"
 	ghlog "github.com/ps-resources/gh-glx-migrator/pkg/logger"
+	"github.com/ps-resources/gh-glx-migrator/pkg/migrator"
 
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
@@ -66,7 +69,10 @@ GitHub credentials must be configured via environment variables.`,
 	cmd.Flags().String("source-repo", "", "Source repository URL")
 	cmd.Flags().String("archive-url", "", "Archive URL")
 	cmd.Flags().String("visibility", "private", "Repository visibility (private/internal/public)")
-	cmd.Flags().String("repo-name", "", "Destination repository name (defaults to source repo name if not specified)")
+	cmd.Flags().String("repo-name", "", "Destination repository name (defaults to a name derived with --name-strategy)")
+	addNamingFlags(cmd)
+	addDryRunFlag(cmd)
+	addExitOnWarningsFlag(cmd)
 
 	// Mark required flags
 	errSource := cmd.MarkFlagRequired("migration-source-id")
@@ -109,9 +115,9 @@ func getOrgInfoHelper(cmd *cobra.Command, args []string) error {
 	jsonOutput, _ := cmd.Flags().GetBool("json")
 
 	var orgMap map[string]interface{}
-	orgMap, err := fetchOrgInfo(org)
+	orgMap, err := fetchOrgInfo(cmd.Context(), org)
 	if err != nil {
-		return fmt.Errorf("failed to fetch organization information: %v", err)
+		return fmt.Errorf("failed to fetch organization information: %w", err)
 	}
 
 	// Handle JSON output format
@@ -151,11 +157,11 @@ func getOrgInfoHelper(cmd *cobra.Command, args []string) error {
 }
 
 // create a fetchOrgInfo function that takes org as input and return orgMap
-func fetchOrgInfo(org string) (map[string]interface{}, error) {
-	orgInfo, err := github.GetOrgInfo(org)
+func fetchOrgInfo(ctx context.Context, org string) (map[string]interface{}, error) {
+	orgInfo, err := github.GetOrgInfo(ctx, org)
 	if err != nil {
 		ghlog.Logger.Debug("failed to get organization information from GitHub", zap.Error(err))
-		return nil, fmt.Errorf("failed to get organization information from GitHub: %v", err)
+		return nil, fmt.Errorf("failed to get organization information from GitHub: %w", err)
 	}
 
 	ghlog.Logger.Info("Organization information from GitHub", zap.Any("orgInfo", orgInfo))
@@ -193,7 +199,7 @@ func createMigrationSource(cmd *cobra.Command, args []string) error {
 	owner, _ := cmd.Flags().GetString("owner")
 	name, _ := cmd.Flags().GetString("name")
 
-	gitLabHost := os.Getenv("GITLAB_HOST")
+	gitLabHost := config.Lookup(config.GitLabHost)
 	if gitLabHost == "" {
 		gitLabHost = "https://gitlab.com"
 	}
@@ -204,9 +210,9 @@ func createMigrationSource(cmd *cobra.Command, args []string) error {
 		URL:     gitLabHost,
 	}
 
-	migrationSource, err := github.CreateMigrationSource(input)
+	migrationSource, err := github.CreateMigrationSource(cmd.Context(), input)
 	if err != nil {
-		return fmt.Errorf("failed to create migration source: %v", err)
+		return fmt.Errorf("failed to create migration source: %w", err)
 	}
 
 	ghlog.Logger.Info("Migration source ID: " + fmt.Sprintf("%v", migrationSource.CreateMigrationSource.MigrationSource.ID))
@@ -237,54 +243,53 @@ func startMigration(cmd *cobra.Command, args []string) error {
 	}
 
 	destinationRepositoryName, _ := cmd.Flags().GetString("repo-name")
+	strategy, _ := cmd.Flags().GetString("name-strategy")
+	collision, _ := cmd.Flags().GetString("on-collision")
+	exitOnWarnings, _ := cmd.Flags().GetBool("exit-on-warnings")
 
-	if destinationRepositoryName == "" {
-		parts := strings.Split(sourceRepositoryUrl, "/")
-		destinationRepositoryName = parts[len(parts)-1]
-		ghlog.Logger.Warn("Empty repository name, using the default:", zap.String("repository", destinationRepositoryName))
-	}
-
-	input := github.MigrationInput{
-		SourceID:             migrationSourceID,
-		OwnerID:              migrationOwnerId,
-		SourceRepositoryURL:  sourceRepositoryUrl,
-		RepositoryName:       destinationRepositoryName,
-		ContinueOnError:      true,
-		SkipReleases:         false,
-		GitArchiveURL:        archiveUrl,
-		MetadataArchiveURL:   archiveUrl,
-		AccessToken:          "not-used",
-		GithubPat:            os.Getenv("GITHUB_PAT"),
-		TargetRepoVisibility: visibility,
-		LockSource:           false,
-	}
-
-	response, err := github.StartMigration(input)
+	m, err := newMigrator(nil)
 	if err != nil {
-		ghlog.Logger.Error("Migration failed",
-			zap.String("repository", input.RepositoryName),
-			zap.Error(err))
-		return nil
+		return err
+	}
+	opts := migrator.MigrateOptions{
+		MigrationSourceID: migrationSourceID,
+		OwnerID:           migrationOwnerId,
+		SourceURL:         sourceRepositoryUrl,
+		ArchiveURL:        archiveUrl,
+		RepositoryName:    destinationRepositoryName,
+		Visibility:        visibility,
+		NameStrategy:      strategy,
+		OnCollision:       collision,
+		FailOnWarnings:    exitOnWarnings,
 	}
 
-	migrationID := response.StartRepositoryMigration.RepositoryMigration.ID
-	ghlog.Logger.Info("Migration started",
-		zap.String("migration_id", migrationID),
-		zap.String("repository", input.RepositoryName))
+	if isDryRun(cmd) {
+		_, name, skipped, err := m.ResolveMigrationRepositoryName(cmd.Context(), opts)
+		if err != nil {
+			return err
+		}
+		if skipped {
+			ghlog.Logger.Warn("Skipping migration, target repository already exists",
+				zap.String("source", sourceRepositoryUrl),
+				zap.String("repository", name))
+			return nil
+		}
+		if err := checkGitHubToken(cmd.Context(), ""); err != nil {
+			return err
+		}
+		if err := checkArchiveURL(cmd.Context(), archiveUrl); err != nil {
+			return err
+		}
 
-	status, err := github.VerifyMigrationStatus(migrationID, 60*time.Minute)
-	if err != nil {
-		ghlog.Logger.Error("Migration verification failed",
-			zap.String("migration_id", migrationID),
-			zap.Error(err))
-		return nil // Return nil to prevent the error from bubbling up
+		var actions dryRunPlan
+		actions.add("startRepositoryMigration(sourceId: %q, ownerId: %q, sourceRepositoryUrl: %q, repositoryName: %q, targetRepoVisibility: %q, continueOnError: true, lockSource: false)",
+			migrationSourceID, migrationOwnerId, sourceRepositoryUrl, name, visibility)
+		actions.add("Wait up to 60m for the migration of %s to finish", name)
+		return actions.print(cmd.OutOrStdout())
 	}
 
-	ghlog.Logger.Info("Migration completed successfully",
-		zap.String("repository", status.Node.RepositoryName),
-		zap.String("state", status.Node.State))
-
-	return nil
+	_, err = m.Migrate(cmd.Context(), opts)
+	return err
 }
 
 func ExportGHECCmd() *cobra.Command {
@@ -331,13 +336,13 @@ func exportGHEC(cmd *cobra.Command, args []string) error {
 		OutputPath:      output,
 	}
 
-	export, err := github.ExportRepositories(org, input)
+	export, err := github.ExportRepositories(cmd.Context(), org, input)
 	if err != nil {
 		ghlog.Logger.Error("Failed to start export", zap.Error(err))
 		return err
 	}
 
-	status, err := github.WaitForExportCompletion(org, export.ID, 2*time.Hour, output)
+	status, err := github.WaitForExportCompletion(cmd.Context(), org, export.ID, 2*time.Hour, output)
 	if err != nil {
 		ghlog.Logger.Error("Export failed", zap.Error(err))
 		return err
@@ -349,3 +354,19 @@ func exportGHEC(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// migrationGitHubPat returns the token GEI uses as the githubPat of a
+// migration: GITHUB_PAT or, when authenticating as a GitHub App, the
+// user-to-server token in GH_USER_TOKEN.
+func migrationGitHubPat() string {
+	if token := config.Lookup(config.GitHubToken); token != "" {
+		return token
+	}
+	return config.Lookup(config.GitHubUserToken)
+}
+
+// addExitOnWarningsFlag adds the --exit-on-warnings flag to a command that
+// waits for a migration.
+func addExitOnWarningsFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("exit-on-warnings", false, fmt.Sprintf("Exit with code %d when the migration succeeds with warnings", failure.ExitWarnings))
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ps-resources/gh-glx-migrator/internal/testutil"
+)
+
+// migrate runs migrate of group/api into the organization of gh, through a
+// migration source created first, with the given extra arguments.
+func migrate(t *testing.T, gh *testutil.GitHub, args ...string) error {
+	t.Helper()
+	if len(gh.MigrationSources()) == 0 {
+		if err := runCommand(context.Background(), CreateMigrationSourceCmd(), &bytes.Buffer{}, "--owner", gh.Org.ID, "--name", "gitlab"); err != nil {
+			t.Fatalf("create-migration-source error = %v", err)
+		}
+	}
+	return runCommand(context.Background(), StartMigrationCmd(), &bytes.Buffer{}, append([]string{
+		"--migration-source-id", gh.MigrationSources()[0].ID,
+		"--org-owner-id", gh.Org.ID,
+		"--source-repo", "https://gitlab.example.com/group/api",
+		"--archive-url", "https://storage.example.com/archive.tar.gz",
+	}, args...)...)
+}
+
+func TestMigrateOnCollision(t *testing.T) {
+	gh := newGitHubFixture(t)
+	gh.Repositories = []string{"api"}
+
+	if err := migrate(t, gh); err == nil || !strings.Contains(err.Error(), "repository api already exists") {
+		t.Errorf("migrate of an existing repository error = %v, want a collision", err)
+	}
+	if err := migrate(t, gh, "--on-collision", "skip"); err != nil {
+		t.Errorf("migrate --on-collision skip error = %v", err)
+	}
+	if migrations := gh.Migrations(); len(migrations) != 0 {
+		t.Fatalf("started %d migrations, want none for an existing repository", len(migrations))
+	}
+	if err := migrate(t, gh, "--repo-name", "api", "--on-collision", "suffix"); err == nil {
+		t.Error("migrate --repo-name of an existing repository returned no error, want no suffix for an explicit name")
+	}
+
+	if err := migrate(t, gh, "--on-collision", "suffix"); err != nil {
+		t.Fatalf("migrate --on-collision suffix error = %v", err)
+	}
+	if migrations := gh.Migrations(); len(migrations) != 1 || migrations[0].RepositoryName != "api-2" {
+		t.Errorf("started migrations %+v, want one of api-2", migrations)
+	}
+}
+
+func TestMigrateDryRun(t *testing.T) {
+	gh := newGitHubFixture(t)
+	archive := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusPartialContent)
+	}))
+	defer archive.Close()
+	if err := runCommand(context.Background(), CreateMigrationSourceCmd(), &bytes.Buffer{}, "--owner", gh.Org.ID, "--name", "gitlab"); err != nil {
+		t.Fatalf("create-migration-source error = %v", err)
+	}
+
+	var out bytes.Buffer
+	err := runCommand(context.Background(), StartMigrationCmd(), &out,
+		"--migration-source-id", gh.MigrationSources()[0].ID,
+		"--org-owner-id", gh.Org.ID,
+		"--source-repo", "https://gitlab.example.com/group/api",
+		"--archive-url", archive.URL+"/archive.tar.gz",
+		"--dry-run")
+	if err != nil {
+		t.Fatalf("migrate --dry-run error = %v", err)
+	}
+
+	if want := `repositoryName: "api"`; !strings.Contains(out.String(), "startRepositoryMigration(") || !strings.Contains(out.String(), want) {
+		t.Errorf("dry run plan does not show the migration of api:\n%s", out.String())
+	}
+	if migrations := gh.Migrations(); len(migrations) != 0 {
+		t.Errorf("started migrations %+v, want none in a dry run", migrations)
+	}
+}
+
+func TestDryRunRejectedByCommandsThatMakeChanges(t *testing.T) {
+	gh := newGitHubFixture(t)
+	queued := gh.AddMigration("https://gitlab.example.com/group/api", "api")
+
+	err := runCommand(context.Background(), AbortMigrationCmd(), &bytes.Buffer{}, "--migration-id", queued, "--dry-run")
+	if err == nil || !strings.Contains(err.Error(), "dry-run") {
+		t.Errorf("abort-migration --dry-run error = %v, want the flag rejected", err)
+	}
+	if aborted := gh.AbortedMigrations(); len(aborted) != 0 {
+		t.Errorf("aborted migrations %v, want none when --dry-run is rejected", aborted)
+	}
+}
+
+func TestMigrateAsGitHubApp(t *testing.T) {
+	gh := newGitHubFixture(t)
+	testutil.SetEnv(t, gh.AppEnv(t, "ghu_user"))
+
+	if err := migrate(t, gh); err != nil {
+		t.Fatalf("migrate error = %v", err)
+	}
+	migrations := gh.Migrations()
+	if len(migrations) != 1 {
+		t.Fatalf("started %d migrations, want 1", len(migrations))
+	}
+	if migrations[0].GithubPat != "ghu_user" {
+		t.Errorf("githubPat = %q, want the user token of GH_USER_TOKEN without GITHUB_PAT", migrations[0].GithubPat)
+	}
+	if gh.InstallationTokens() == 0 {
+		t.Error("migrate did not authenticate as the app installation")
+	}
+}
@@ -2,12 +2,17 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 
+	"github.com/ps-resources/gh-glx-migrator/internal/clients"
+	"github.com/ps-resources/gh-glx-migrator/internal/config"
+	"github.com/ps-resources/gh-glx-migrator/internal/failure"
 	gl "github.com/ps-resources/gh-glx-migrator/internal/gitlab"
+	"github.com/ps-resources/gh-glx-migrator/internal/telemetry"
 	ghlog "github.com/ps-resources/gh-glx-migrator/pkg/logger"
+	"github.com/ps-resources/gh-glx-migrator/pkg/migrator"
 
 	"github.com/spf13/cobra"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
 	"go.uber.org/zap"
 )
 
@@ -69,7 +74,7 @@ func exportArchive(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	gitLabAPIEndpoint := os.Getenv("GITLAB_API_ENDPOINT")
+	gitLabAPIEndpoint := flagOrSetting(cmd, "gl-api-endpoint", config.GitLabAPIEndpoint)
 	if gitLabAPIEndpoint == "" {
 		gitLabAPIEndpoint = "gitlab.com/api/v4"
 	}
@@ -78,15 +83,54 @@ func exportArchive(cmd *cobra.Command, args []string) error {
 		CsvFile:           csvFile,
 		OutputFile:        outputFile,
 		GitLabAPIEndpoint: gitLabAPIEndpoint,
-		GitLabUsername:    os.Getenv("GITLAB_USERNAME"),
-		GitLabAPIToken:    os.Getenv("GITLAB_PAT"),
-		DockerImage:       os.Getenv("GL_EXPORTER_DOCKER_IMAGE"),
+		GitLabUsername:    flagOrSetting(cmd, "gl-username", config.GitLabUsername),
+		GitLabAPIToken:    flagOrSetting(cmd, "gl-api-token", config.GitLabToken),
+		DockerImage:       config.Lookup(config.GitLabExporterImage),
 		GitLabNamespace:   glNamespace,
 		GitLabProject:     glProject,
 	}
 
-	if err := gl.ExportFromGitLab(opts); err != nil {
+	// A CSV export covers several projects, so it is traced as one unit.
+	source := csvFile
+	if source == "" {
+		source = glNamespace + "/" + glProject
+	}
+	events, err := newEventBus()
+	if err != nil {
 		return err
 	}
+
+	ctx, span := telemetry.StartRepository(cmd.Context(), source, "")
+	defer span.End()
+
+	events.Publish(ctx, migrator.Event{Type: migrator.EventExportStarted, SourceURL: source})
+	_, endExport := telemetry.StartPhase(ctx, telemetry.PhaseExport)
+	err = gl.ExportFromGitLab(ctx, opts)
+	endExport(err)
+	if err != nil {
+		events.Publish(ctx, migrator.Event{Type: migrator.EventExportFailed, SourceURL: source, Error: ghlog.Redact(err.Error())})
+		return err
+	}
+	events.Publish(ctx, migrator.Event{Type: migrator.EventExportCompleted, SourceURL: source})
 	return nil
 }
+
+// gitlabClientFromEnv builds a GitLab API client from GITLAB_API_ENDPOINT and
+// GITLAB_PAT, or the gitlab settings of the profile.
+func gitlabClientFromEnv() (*gitlab.Client, error) {
+	gitlabPAT := config.Lookup(config.GitLabToken)
+	if gitlabPAT == "" {
+		return nil, failure.Auth(fmt.Errorf("GITLAB_PAT environment variable is not set"))
+	}
+
+	gitLabAPIEndpoint := config.Lookup(config.GitLabAPIEndpoint)
+	if gitLabAPIEndpoint == "" {
+		gitLabAPIEndpoint = "gitlab.com/api/v4"
+	}
+
+	client, err := clients.NewGitlabClient(gitLabAPIEndpoint, gitlabPAT).GitlabAuth()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GitLab client: %w", err)
+	}
+	return client, nil
+}
@@ -13,7 +13,10 @@ func HelpCmd() *cobra.Command {
 		Long: `gh-glx is a CLI tool for migrating repositories from GitLab to GitHub Enterprise Cloud with Data Residency.
 
 Required Environment Variables:
-GITHUB_PAT                  GitHub Personal Access Token
+GITHUB_PAT                  GitHub Personal Access Token (GITHUB_GHEC_PAT and GITHUB_TOKEN are read when it is not set)
+GH_APP_ID                   GitHub App ID, to authenticate as an app installation instead (optional)
+GH_APP_PRIVATE_KEY          GitHub App private key, PEM contents or file path (optional)
+GH_USER_TOKEN               User-to-server token of the GitHub App, used as the githubPat of migrations (optional)
 GITHUB_API_ENDPOINT         GitHub Enterprise URL (e.g., github.example.com)
 GITHUB_ORG                  GitHub Organization name
 GITLAB_PAT                  GitLab Personal Access Token
@@ -25,27 +28,74 @@ AWS_SECRET_ACCESS_KEY       AWS Secret Key for S3
 AWS_REGION                  AWS Region (e.g., us-west-2)
 AWS_BUCKET                  S3 Bucket name (optional)
 
+These settings can also be kept in named profiles in ~/.config/gh-glx/config.yaml
+(see gh glx config). Flags take precedence over environment variables, which
+take precedence over the profile.
+
+Tokens and keys may be given as references to an external secret instead:
+vault://<mount>/<path>#<key> (VAULT_ADDR, VAULT_TOKEN), file:///run/secrets/<name>
+or env://<NAME>.
+
 Available Commands:
 verify                      Verify configuration and credentials
+config                      View, set and validate the configuration file
 generate-aws-presigned-url  Generate pre-signed URL for S3 archive
 upload-to-s3                Upload a file to S3 bucket
 export-archive              Export GitLab repository as archive
+inventory                   Report the size and contents of the projects in a GitLab group
+preflight                   Check the projects of a migration plan for known GEI blockers
+map-names                   Print the GitLab to GitHub repository name mapping of a plan
 get-org-info                Get GitHub organization information
 create-migration-source     Create migration source for GitLab
 migrate                     Start repository migration
 migrate-repo                Perform complete repository migration
+list-migrations             List repository migrations in an organization
+abort-migration             Abort in-flight repository migrations
+generate-mannequin-csv      Generate a CSV of the mannequins in an organization
+reclaim-mannequins          Reclaim mannequins from a user mapping CSV
+map-users                   Propose a GitLab to GitHub user mapping
+grant-migrator-role         Grant the migrator role to a user or team
+revoke-migrator-role        Revoke the migrator role from a user or team
 help                        Show this help message
 
+Global Flags:
+--config                    Configuration file with named profiles (default ~/.config/gh-glx/config.yaml)
+--profile                   Profile of the configuration file to use (default GH_GLX_PROFILE or current-profile)
+--non-interactive           Never prompt, e.g. for the storage backend; implied when stdin is not a terminal
+--log-format                Log format: console or json (default console)
+--log-level                 Lowest level logged: debug, info, warn or error (default info; earlier versions logged debug)
+--log-file                  Also write logs to this file, rotated at --log-max-size MiB keeping --log-max-backups files
+--metrics-addr              Serve Prometheus metrics on this address while the command runs
+--otlp-endpoint             Export OpenTelemetry traces over OTLP/HTTP to this collector (add --otlp-insecure for a local one)
+
+Exit Codes:
+0 success, 1 other error, 2 invalid flags or settings, 3 authentication,
+4 storage, 5 migration failed, 6 timeout, 7 succeeded with warnings (--exit-on-warnings),
+130 interrupted by SIGINT or SIGTERM (a second signal exits immediately)
+
 Examples:
 # Verify configuration
 gh glx verify
 
+# Store a reference to the GitHub token in a profile and make it the default
+gh glx config set github.token vault://kv/migrations#github_pat --profile prod
+gh glx config set current-profile prod
+
 # Generate pre-signed URL for S3 archive
 gh glx generate-aws-presigned-url --bucket my-bucket --key archive.tar.gz --duration 30m
 
 # Upload file to S3
 gh glx upload-to-s3 --bucket my-bucket --key archive.tar.gz --file-path ./archive.tar.gz
 
+# Inventory a GitLab group and its subgroups
+gh glx inventory --gl-group my-group --recursive
+
+# Check a migration plan for GEI blockers
+gh glx preflight --plan inventory.csv --org my-org
+
+# Review the repository names of a migration plan
+gh glx map-names --plan inventory.csv --org my-org --name-strategy prefix-group --on-collision suffix
+
 # Export GitLab repository
 gh glx export-archive --gl-project group/project --output-file archive.tar.gz
 
@@ -70,7 +120,24 @@ gh glx migrate-repo \
   --bucket my-bucket \
   --org my-org \
   --visibility private \
-  --repo-name new-repo`,
+  --repo-name new-repo
+
+# Check an import without uploading or migrating anything
+gh glx import-archive --dry-run --archive-file-path archive.tar.gz --source-repo https://gitlab.com/org/repo --org my-org
+
+# List failed migrations from the last 24 hours
+gh glx list-migrations --org my-org --state FAILED --since 24h
+
+# Abort every queued migration in an organization
+gh glx abort-migration --org my-org --state QUEUED
+
+# Reclaim mannequins
+gh glx generate-mannequin-csv --org my-org --output user-mappings-gei.csv
+gh glx map-users --org my-org --output user-mappings-proposed.csv
+gh glx reclaim-mannequins --org my-org --csv user-mappings-gei.csv
+
+# Let a non-owner run migrations
+gh glx grant-migrator-role --org my-org --actor octocat --actor-type USER`,
 		Run: func(cmd *cobra.Command, args []string) {
 			fmt.Println(cmd.Long)
 		},
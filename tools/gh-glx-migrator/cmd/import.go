@@ -3,16 +3,17 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"os"
+	"io"
 	"path/filepath"
-	"strings"
 	"time"
 
 	awsUtils "github.com/ps-resources/gh-glx-migrator/internal/aws"
 	"github.com/ps-resources/gh-glx-migrator/internal/azure"
 	"github.com/ps-resources/gh-glx-migrator/internal/clients"
-	"github.com/ps-resources/gh-glx-migrator/internal/github"
+	"github.com/ps-resources/gh-glx-migrator/internal/config"
+	"github.com/ps-resources/gh-glx-migrator/internal/report"
 	ghlog "github.com/ps-resources/gh-glx-migrator/pkg/logger"
+	"github.com/ps-resources/gh-glx-migrator/pkg/migrator"
 
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
@@ -23,6 +24,12 @@ func ImportArchiveCmd() *cobra.Command {
 		Use:   "import-archive",
 		Short: "Import a gitlab archive to GitHub",
 		Long: `Import a gitlab archive to GitHub start to finish.
+
+With --report-json, every import is appended to a JSON report with the source
+URL, target repository, migration ID, archive size, time per phase, storage
+backend, final state, failure reason and migration log warnings. With
+--report-markdown, the whole report is also rendered as Markdown, ready for
+$GITHUB_STEP_SUMMARY or an issue comment.
 			
 GitHub credentials must be configured via environment variables.`,
 		Example: `gh glx import-archive --bucket s3bucket --blob-name migration_archive.tar.gz --org org --source-repo https://gitlab.com/org/repo --visibility private --repo-name my-repo`,
@@ -30,14 +37,20 @@ GitHub credentials must be configured via environment variables.`,
 	}
 
 	//cmd.Flags().String("archive", "migration_archive.tar.gz", "Migration archive file")
-	cmd.Flags().String("bucket", os.Getenv("AWS_BUCKET"), "S3 bucket name")
+	cmd.Flags().String("bucket", "", "S3 bucket name (default AWS_BUCKET or the profile's storage.aws.bucket)")
 	cmd.Flags().String("blob-name", "", "Name to use for blob in S3 or Azure (defaults to local file name)")
 	cmd.Flags().String("archive-file-path", "", "Path to migration archive file")
 	cmd.Flags().Duration("duration", 20*time.Minute, "Duration for the presigned URL in minutes")
 	cmd.Flags().String("org", "", "GitHub organization to import to")
 	cmd.Flags().String("source-repo", "", "GitLab source repository URL")
 	cmd.Flags().String("visibility", "private", "Visibility of the new repository (public, private, internal)")
-	cmd.Flags().String("repo-name", "", "Name of the new repository")
+	cmd.Flags().String("repo-name", "", "Name of the new repository (defaults to a name derived with --name-strategy)")
+	cmd.Flags().String("report-json", "", "Append the result of the import to this JSON report")
+	cmd.Flags().String("report-markdown", "", "Render the JSON report as Markdown to this file")
+	addStorageFlag(cmd)
+	addExitOnWarningsFlag(cmd)
+	addNamingFlags(cmd)
+	addDryRunFlag(cmd)
 
 	errOrg := cmd.MarkFlagRequired("org")
 	if errOrg != nil {
@@ -58,267 +71,228 @@ GitHub credentials must be configured via environment variables.`,
 	return cmd
 }
 
-func importArchive(cmd *cobra.Command, args []string) error {
-	// Verify required environment variables on startup
-	if err := VerifyRequiredEnvVars(); err != nil {
-		fmt.Println("Error:", err)
-		os.Exit(1)
-	}
+func importArchive(cmd *cobra.Command, args []string) (err error) {
 	ghlog.Logger.Info("Reading input values for starting migration")
 	org, _ := cmd.Flags().GetString("org")
 	sourceRepositoryUrl, _ := cmd.Flags().GetString("source-repo")
 	visibility, _ := cmd.Flags().GetString("visibility")
 	destinationRepositoryName, _ := cmd.Flags().GetString("repo-name")
-	bucket, _ := cmd.Flags().GetString("bucket")
+	bucket := flagOrSetting(cmd, "bucket", config.AWSBucket)
 	blobName, _ := cmd.Flags().GetString("blob-name")
 	duration, _ := cmd.Flags().GetDuration("duration")
 	archiveFilePath, _ := cmd.Flags().GetString("archive-file-path")
-
-	awsAccessKeyId := os.Getenv("AWS_ACCESS_KEY_ID")
-	awsSecretAccessKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
-
-	azureStorageAccount := os.Getenv("AZURE_STORAGE_ACCOUNT")
-	azureStorageAccessKey := os.Getenv("AZURE_STORAGE_ACCESS_KEY")
-
-	blobStorageAws := false
-	blobStorageAzure := false
-	blobStorageGithub := false
-
-	if awsAccessKeyId != "" && awsSecretAccessKey != "" {
-		if bucket == "" {
-			return fmt.Errorf("bucket name is required when using AWS storage. Please provide it using --bucket flag or set it in the environment variable AWS_BUCKET")
-		} else {
-			blobStorageAws = true
-		}
-	} else if azureStorageAccount != "" && azureStorageAccessKey != "" {
-		blobStorageAzure = true
-	} else {
-		blobStorageGithub = true
+	reportJSON, _ := cmd.Flags().GetString("report-json")
+	reportMarkdown, _ := cmd.Flags().GetString("report-markdown")
+	strategy, _ := cmd.Flags().GetString("name-strategy")
+	collision, _ := cmd.Flags().GetString("on-collision")
+	exitOnWarnings, _ := cmd.Flags().GetBool("exit-on-warnings")
+
+	values := config.Snapshot()
+	values.Set(config.AWSBucket, bucket)
+	storage, err := checkSettings(cmd, values, config.Requirements{GitHub: true, Storage: storageChoice(cmd)})
+	if err != nil {
+		return err
 	}
 
-	if blobName == "" {
-		blobName = filepath.Base(archiveFilePath)
+	store, err := newStore(storage, bucket)
+	if err != nil {
+		return err
 	}
-
-	if destinationRepositoryName == "" {
-		parts := strings.Split(sourceRepositoryUrl, "/")
-		destinationRepositoryName = parts[len(parts)-1]
+	m, err := newMigrator(store)
+	if err != nil {
+		return err
+	}
+	opts := migrator.ImportArchiveOptions{
+		Org:            org,
+		SourceURL:      sourceRepositoryUrl,
+		ArchivePath:    archiveFilePath,
+		BlobName:       blobName,
+		RepositoryName: destinationRepositoryName,
+		Visibility:     visibility,
+		NameStrategy:   strategy,
+		OnCollision:    collision,
+		URLDuration:    duration,
+		FailOnWarnings: exitOnWarnings,
 	}
 
-	ctx, cancel := context.WithTimeout(cmd.Context(), 30*time.Minute)
-	defer cancel()
-
-	var presignedUrl string
-	var err error
-	awsClient := clients.NewAwsClient()
-
-	if blobStorageAws {
-		s3Manager, err := awsUtils.NewS3Manager(ctx, awsClient, bucket)
-		if err != nil {
-			ghlog.Logger.Error("failed to create S3Manager", zap.Error(err))
-			return fmt.Errorf("failed to initialize AWS S3 manager: %w", err)
-		}
-		presignedUrl, err = uploadToS3AndGeneratePresignedURL(ctx, s3Manager, bucket, archiveFilePath, blobName, duration)
-		if err != nil {
+	if isDryRun(cmd) {
+		name, skipped, err := m.ResolveRepositoryName(cmd.Context(), opts)
+		if err != nil || skipped {
 			return err
 		}
+		if blobName == "" {
+			blobName = filepath.Base(archiveFilePath)
+		}
+		ctx, cancel := context.WithTimeout(cmd.Context(), 30*time.Minute)
+		defer cancel()
+		return dryRunImportArchive(ctx, cmd.OutOrStdout(), importArchiveDryRun{
+			Org:             org,
+			SourceURL:       sourceRepositoryUrl,
+			RepositoryName:  name,
+			Visibility:      visibility,
+			ArchiveFilePath: archiveFilePath,
+			Storage:         storage,
+			Bucket:          bucket,
+			BlobName:        blobName,
+			Duration:        duration,
+		})
 	}
 
-	if blobStorageAzure {
-		// upload to Azure Blob Storage and return presigned URL
-		presignedUrl, err = uploadToAzureStorageAndGeneratePresignedURL(bucket, archiveFilePath, blobName, duration)
-		if err != nil {
-			ghlog.Logger.Error("failed to upload to Azure Blob Storage", zap.Error(err))
-			return fmt.Errorf("failed to upload to Azure Blob Storage: %w", err)
+	result, err := m.ImportArchive(cmd.Context(), opts)
+	if reportJSON != "" || reportMarkdown != "" {
+		if err := report.Append(reportJSON, reportMarkdown, reportEntry(result)); err != nil {
+			ghlog.Logger.Error("failed to write migration report", zap.Error(err))
 		}
 	}
+	return err
+}
 
-	orgMap, err := fetchOrgInfo(org)
+// newMigrator returns a Migrator for the configured GitHub and GitLab that
+// uploads archives to store.
+func newMigrator(store migrator.Store) (*migrator.Migrator, error) {
+	credential, err := clients.DefaultGitHubCredential()
 	if err != nil {
-		return fmt.Errorf("failed to fetch organization information: %w", err)
+		return nil, err
+	}
+	events, err := newEventBus()
+	if err != nil {
+		return nil, err
 	}
 
-	var orgId = orgMap["id"]
-	var orgDatabaseId = orgMap["databaseId"]
-	// convert orgDatabaseId to int
-	orgDatabaseId = int(orgDatabaseId.(float64))
-	// convert orgDatabaseId to string
-	orgDatabaseId = fmt.Sprintf("%v", orgDatabaseId)
-	ghlog.Logger.Info("orgId: " + fmt.Sprintf("%v", orgId))
-
-	if blobStorageGithub {
-		// upload to GitHub storage
-		uploadArchiveInput := github.UploadArchiveInput{
-			ArchiveFilePath: archiveFilePath,
-			OrganizationId:  orgDatabaseId.(string),
-		}
+	return &migrator.Migrator{
+		GitHub: migrator.GitHub{
+			APIEndpoint:     config.Lookup(config.GitHubAPIEndpoint),
+			UploadsEndpoint: config.Lookup(config.GitHubUploadsEndpoint),
+			Credential:      credential,
+			MigrationToken:  migrationGitHubPat(),
+		},
+		GitLab: migrator.GitLab{Host: config.Lookup(config.GitLabHost)},
+		Store:  store,
+		Events: events,
+	}, nil
+}
 
-		presignedUrl, err = github.UploadArchiveToGitHub(ctx, uploadArchiveInput)
-		if err != nil {
-			ghlog.Logger.Error("failed to upload to GitHub storage", zap.Error(err))
-			return fmt.Errorf("failed to upload to GitHub storage: %w", err)
-		}
-		ghlog.Logger.Info("Uploaded archive to GitHub storage successfully")
-		ghlog.Logger.Info("URL: " + presignedUrl)
+// newStore returns the store for the storage backend selected with --storage.
+func newStore(storage, bucket string) (migrator.Store, error) {
+	switch storage {
+	case config.StorageAWS:
+		return &migrator.S3Store{Bucket: bucket}, nil
+	case config.StorageAzure:
+		return &migrator.AzureStore{
+			Account:      config.Lookup(config.AzureStorageAccount),
+			AccessKey:    config.Lookup(config.AzureStorageAccessKey),
+			Container:    bucket,
+			BlobEndpoint: config.Lookup(config.AzureBlobEndpoint),
+		}, nil
+	case config.StorageGitHub:
+		return &migrator.GitHubStore{}, nil
+	default:
+		return nil, fmt.Errorf("unknown storage: %s", storage)
 	}
+}
 
-	gitLabHost := os.Getenv("GITLAB_HOST")
-	if gitLabHost == "" {
-		gitLabHost = "https://gitlab.com"
+// reportEntry converts the result of an import to its entry in the report.
+func reportEntry(result migrator.Result) report.Repository {
+	entry := report.Repository{
+		SourceURL:       result.SourceURL,
+		TargetRepo:      result.Repository,
+		MigrationID:     result.MigrationID,
+		ArchiveSize:     result.ArchiveSize,
+		Storage:         result.Storage,
+		State:           result.State,
+		FailureReason:   result.FailureReason,
+		Warnings:        result.Warnings,
+		MigrationLogURL: result.MigrationLogURL,
+		StartedAt:       result.StartedAt,
+		FinishedAt:      result.FinishedAt,
 	}
-	migrationSourceInput := github.MigrationSourceInput{
-		Name:    "GitLab Archive Migration",
-		OwnerID: orgId.(string),
-		Type:    "GL_EXPORTER_ARCHIVE",
-		URL:     gitLabHost,
+	for _, phase := range result.Phases {
+		entry.Phases = append(entry.Phases, report.Phase{
+			Name:    phase.Name,
+			Seconds: phase.Duration.Round(time.Millisecond).Seconds(),
+			Error:   phase.Error,
+		})
 	}
+	return entry
+}
 
-	migrationSource, err := github.CreateMigrationSource(migrationSourceInput)
-	if err != nil {
-		return fmt.Errorf("failed to create migration source: %w", err)
-	}
-
-	ghlog.Logger.Info("Migration source ID: " + fmt.Sprintf("%v", migrationSource.CreateMigrationSource.MigrationSource.ID))
-
-	var migrationSourceId = migrationSource.CreateMigrationSource.MigrationSource.ID
-
-	migrationInput := github.MigrationInput{
-		SourceID:             migrationSourceId,
-		OwnerID:              orgId.(string),
-		SourceRepositoryURL:  sourceRepositoryUrl,
-		RepositoryName:       destinationRepositoryName,
-		ContinueOnError:      true,
-		SkipReleases:         false,
-		GitArchiveURL:        presignedUrl,
-		MetadataArchiveURL:   presignedUrl,
-		AccessToken:          "not-used",
-		GithubPat:            os.Getenv("GITHUB_PAT"),
-		TargetRepoVisibility: visibility,
-		LockSource:           false,
-	}
+// importArchiveDryRun holds the resolved inputs of an import-archive run.
+type importArchiveDryRun struct {
+	Org             string
+	SourceURL       string
+	RepositoryName  string
+	Visibility      string
+	ArchiveFilePath string
+	// Storage is aws, azure or github.
+	Storage  string
+	Bucket   string
+	BlobName string
+	Duration time.Duration
+}
 
-	response, err := github.StartMigration(migrationInput)
+// dryRunImportArchive runs the read-only steps of import-archive and prints
+// the uploads and mutations it would perform to w.
+func dryRunImportArchive(ctx context.Context, w io.Writer, run importArchiveDryRun) error {
+	size, err := validateArchive(run.ArchiveFilePath)
 	if err != nil {
-		ghlog.Logger.Error("Starting Migration failed",
-			zap.String("repository", migrationInput.RepositoryName),
-			zap.Error(err))
 		return err
 	}
 
-	migrationID := response.StartRepositoryMigration.RepositoryMigration.ID
-	ghlog.Logger.Info("Migration started",
-		zap.String("migration_id", migrationID),
-		zap.String("repository", migrationInput.RepositoryName))
-
-	status, err := github.VerifyMigrationStatus(migrationID, 90*time.Minute)
-	if err != nil {
-		ghlog.Logger.Error("Migration verification failed",
-			zap.String("migration_id", migrationID),
-			zap.Error(err))
-		return nil
+	if err := checkGitHubToken(ctx, run.Org); err != nil {
+		return err
 	}
 
-	ghlog.Logger.Info("Migration completed successfully",
-		zap.String("repository", status.Node.RepositoryName),
-		zap.String("state", status.Node.State))
-
-	if blobStorageAws {
-		// Initialize s3Manager before using it
-		s3Manager, err := awsUtils.NewS3Manager(ctx, awsClient, bucket)
+	var storageURL string
+	switch run.Storage {
+	case "aws":
+		s3Manager, err := awsUtils.NewS3Manager(ctx, clients.NewAwsClient(), run.Bucket)
 		if err != nil {
-			ghlog.Logger.Error("failed to create S3Manager", zap.Error(err))
 			return fmt.Errorf("failed to initialize AWS S3 manager: %w", err)
 		}
-
-		if err := s3Manager.DeleteObject(ctx, blobName); err != nil {
-			ghlog.Logger.Error("failed to delete file from S3", zap.Error(err))
-			return fmt.Errorf("failed to delete file from S3: %w", err)
+		if err := s3Manager.CheckBucketAccess(ctx); err != nil {
+			return err
 		}
-		ghlog.Logger.Info("Deleted file from S3 bucket", zap.String("bucket", bucket), zap.String("key", blobName))
-	}
-
-	if blobStorageAzure {
-		storageAccount := os.Getenv("AZURE_STORAGE_ACCOUNT")
-		storageAccessKey := os.Getenv("AZURE_STORAGE_ACCESS_KEY")
+		storageURL = fmt.Sprintf("s3://%s/%s", run.Bucket, run.BlobName)
+	case "azure":
 		opts := &azure.AzureOptions{
-			StorageAccount:   storageAccount,
-			StorageAccessKey: storageAccessKey,
-			ContainerName:    bucket,
-			BlobName:         blobName,
-			ArchiveFilePath:  archiveFilePath,
+			StorageAccount:   config.Lookup(config.AzureStorageAccount),
+			StorageAccessKey: config.Lookup(config.AzureStorageAccessKey),
+			ContainerName:    run.Bucket,
+			BlobName:         run.BlobName,
+			BlobEndpoint:     config.Lookup(config.AzureBlobEndpoint),
 		}
-		err = azure.DeleteBlob(opts)
-		if err != nil {
-			ghlog.Logger.Error("failed to delete file from Azure Blob Storage", zap.Error(err))
-			return fmt.Errorf("failed to delete file from Azure Blob Storage: %w", err)
+		if err := azure.CheckContainerAccess(ctx, opts); err != nil {
+			return err
 		}
-		ghlog.Logger.Info("Deleted file from Azure Blob Storage", zap.String("container", bucket), zap.String("blob", blobName))
+		storageURL = azure.BlobURL(opts)
 	}
-	ghlog.Logger.Info("Migration completed successfully",
-		zap.String("repository", status.Node.RepositoryName),
-		zap.String("state", status.Node.State))
 
-	return nil
-}
-
-func uploadToS3AndGeneratePresignedURL(ctx context.Context, s3Manager *awsUtils.S3Manager, bucket, archiveFilePath, blobName string, duration time.Duration) (string, error) {
-	file, err := os.Open(archiveFilePath)
+	orgMap, err := fetchOrgInfo(ctx, run.Org)
 	if err != nil {
-		return "", fmt.Errorf("failed to open file: %w", err)
-	}
-	defer func() {
-		if err := file.Close(); err != nil {
-			ghlog.Logger.Error("failed to close file", zap.Error(err))
-		}
-	}()
-
-	ghlog.Logger.Info("Uploading to S3 bucket",
-		zap.String("bucket", bucket),
-		zap.String("key", blobName),
-		zap.String("file-path", archiveFilePath))
-
-	if err := s3Manager.Upload(ctx, blobName, file); err != nil {
-		return "", fmt.Errorf("failed to upload to S3 bucket: %w", err)
+		return fmt.Errorf("failed to fetch organization information: %w", err)
 	}
+	orgID := fmt.Sprintf("%v", orgMap["id"])
 
-	presignedUrl, err := s3Manager.GeneratePresignedURL(ctx, blobName, duration)
-	if err != nil {
-		ghlog.Logger.Error("failed to generate pre-signed URL", zap.Error(err))
-		return "", fmt.Errorf("failed to generate presigned URL: %w", err)
+	gitLabHost := config.Lookup(config.GitLabHost)
+	if gitLabHost == "" {
+		gitLabHost = "https://gitlab.com"
 	}
 
-	ghlog.Logger.Info("Generated pre-signed URL S3 Successfully")
-	ghlog.Logger.Info("Pre-signed URL", zap.String("url", presignedUrl))
-	ghlog.Logger.Info("URL will expire at", zap.Time("expiration", time.Now().Add(duration)))
-
-	return presignedUrl, nil
-}
-
-func uploadToAzureStorageAndGeneratePresignedURL(bucket, archiveFilePath, blobName string, duration time.Duration) (string, error) {
-	// Get Azure credentials from environment
-	storageAccount := os.Getenv("AZURE_STORAGE_ACCOUNT")
-	storageAccessKey := os.Getenv("AZURE_STORAGE_ACCESS_KEY")
-
-	opts := &azure.AzureOptions{
-		StorageAccount:   storageAccount,
-		StorageAccessKey: storageAccessKey,
-		ContainerName:    bucket,
-		BlobName:         blobName,
-		ArchiveFilePath:  archiveFilePath,
+	var actions dryRunPlan
+	if run.Storage == "github" {
+		actions.add("Upload %s (%d bytes) to the GitHub-owned storage of %s", run.ArchiveFilePath, size, run.Org)
+	} else {
+		actions.add("Upload %s (%d bytes) to %s", run.ArchiveFilePath, size, storageURL)
+		actions.add("Generate a read-only URL for %s valid for %s", storageURL, run.Duration)
 	}
-
-	ghlog.Logger.Info("Uploading file to Azure Blob Storage",
-		zap.String("file", archiveFilePath),
-		zap.String("container", bucket))
-
-	presignedUrl, err := azure.UploadToAzureBlob(opts, duration)
-	if err != nil {
-		ghlog.Logger.Error("Failed to upload file to Azure", zap.Error(err))
-		return "", fmt.Errorf("failed to upload file to Azure: %v", err)
+	actions.add("createMigrationSource(name: %q, type: GL_EXPORTER_ARCHIVE, url: %q, ownerId: %q)",
+		"GitLab Archive Migration", gitLabHost, orgID)
+	actions.add("startRepositoryMigration(sourceRepositoryUrl: %q, repositoryName: %q, ownerId: %q, targetRepoVisibility: %q, continueOnError: true, lockSource: false)",
+		run.SourceURL, run.RepositoryName, orgID, run.Visibility)
+	actions.add("Wait up to 90m for the migration of %s/%s to finish", run.Org, run.RepositoryName)
+	if run.Storage != "github" {
+		actions.add("Delete %s", storageURL)
 	}
 
-	ghlog.Logger.Info("File uploaded successfully")
-	ghlog.Logger.Info("Presigned URL: " + presignedUrl)
-
-	return presignedUrl, nil
+	return actions.print(w)
 }
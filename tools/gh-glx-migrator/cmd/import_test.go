@@ -0,0 +1,395 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ps-resources/gh-glx-migrator/internal/clients"
+	"github.com/ps-resources/gh-glx-migrator/internal/config"
+	"github.com/ps-resources/gh-glx-migrator/internal/failure"
+	"github.com/ps-resources/gh-glx-migrator/internal/github"
+	"github.com/ps-resources/gh-glx-migrator/internal/report"
+	"github.com/ps-resources/gh-glx-migrator/internal/testutil"
+	ghlog "github.com/ps-resources/gh-glx-migrator/pkg/logger"
+	"github.com/ps-resources/gh-glx-migrator/pkg/migrator"
+
+	"github.com/spf13/cobra"
+)
+
+func TestMain(m *testing.M) {
+	ghlog.InitLogger()
+	github.MigrationPollInterval = 10 * time.Millisecond
+	os.Exit(m.Run())
+}
+
+// importFixture is an archive and report paths for one import-archive run
+// against a fake GitHub.
+type importFixture struct {
+	gh          *testutil.GitHub
+	archive     []byte
+	archivePath string
+	reportPath  string
+	out         bytes.Buffer
+}
+
+func newImportFixture(t *testing.T) *importFixture {
+	t.Helper()
+	// Settings of the environment running the tests must not leak in.
+	testutil.SetEnv(t, map[string]string{
+		"GH_APP_ID":          "",
+		"USE_GITHUB_STORAGE": "",
+		"GITLAB_HOST":        "https://gitlab.example.com",
+	})
+
+	gh := testutil.NewGitHub(t)
+	testutil.SetEnv(t, gh.Env())
+	clients.ResetDefaultGitHubCredential()
+	t.Cleanup(clients.ResetDefaultGitHubCredential)
+
+	dir := t.TempDir()
+	f := &importFixture{
+		gh:          gh,
+		archive:     []byte("gl-exporter archive"),
+		archivePath: filepath.Join(dir, "migration_archive.tar.gz"),
+		reportPath:  filepath.Join(dir, "report.json"),
+	}
+	if err := os.WriteFile(f.archivePath, f.archive, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+// run runs import-archive with the fixture's archive and report and the given
+// extra arguments.
+func (f *importFixture) run(t *testing.T, args ...string) error {
+	t.Helper()
+	root := &cobra.Command{Use: "gh-glx", SilenceUsage: true, SilenceErrors: true}
+	root.PersistentFlags().Bool("non-interactive", true, "")
+	root.AddCommand(ImportArchiveCmd())
+	f.out.Reset()
+	root.SetOut(&f.out)
+	root.SetArgs(append([]string{
+		"import-archive",
+		"--org", f.gh.Org.Login,
+		"--source-repo", "https://gitlab.example.com/group/api",
+		"--archive-file-path", f.archivePath,
+		"--report-json", f.reportPath,
+		"--report-markdown", "",
+	}, args...))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	return root.ExecuteContext(ctx)
+}
+
+// reported returns the only repository of the fixture's report.
+func (f *importFixture) reported(t *testing.T) report.Repository {
+	t.Helper()
+	r, err := report.Load(f.reportPath)
+	if err != nil {
+		t.Fatalf("report.Load() error = %v", err)
+	}
+	if len(r.Repositories) != 1 {
+		t.Fatalf("report has %d repositories, want 1", len(r.Repositories))
+	}
+	return r.Repositories[0]
+}
+
+// startedMigration returns the only migration started on the fake GitHub and
+// checks it was started from the source and organization of the fixture.
+func (f *importFixture) startedMigration(t *testing.T) testutil.Migration {
+	t.Helper()
+	migrations := f.gh.Migrations()
+	if len(migrations) != 1 {
+		t.Fatalf("started %d migrations, want 1", len(migrations))
+	}
+	migration := migrations[0]
+	if migration.OwnerID != f.gh.Org.ID {
+		t.Errorf("ownerId = %q, want %q", migration.OwnerID, f.gh.Org.ID)
+	}
+	if migration.RepositoryName != "api" {
+		t.Errorf("repositoryName = %q, want api", migration.RepositoryName)
+	}
+	if migration.SourceRepositoryURL != "https://gitlab.example.com/group/api" {
+		t.Errorf("sourceRepositoryUrl = %q", migration.SourceRepositoryURL)
+	}
+	if migration.GitArchiveURL != migration.MetadataArchiveURL {
+		t.Errorf("gitArchiveUrl = %q, metadataArchiveUrl = %q, want the same archive", migration.GitArchiveURL, migration.MetadataArchiveURL)
+	}
+
+	sources := f.gh.MigrationSources()
+	if len(sources) != 1 || sources[0].ID != migration.SourceID {
+		t.Errorf("migration source = %q, created sources %+v", migration.SourceID, sources)
+	} else if sources[0].URL != "https://gitlab.example.com" || sources[0].Type != "GL_EXPORTER_ARCHIVE" {
+		t.Errorf("migration source = %+v, want a GL_EXPORTER_ARCHIVE source for https://gitlab.example.com", sources[0])
+	}
+	return migration
+}
+
+func TestImportArchiveAWS(t *testing.T) {
+	f := newImportFixture(t)
+	s3 := testutil.NewS3(t, "archives")
+	testutil.SetEnv(t, s3.Env())
+
+	// The archive is deleted once the migration succeeds, so it is checked
+	// when GitHub first reports the migration's state.
+	var uploaded []byte
+	f.gh.OnStatus = func() { uploaded, _ = s3.Object("archives", "migration_archive.tar.gz") }
+
+	if err := f.run(t, "--storage", "aws", "--bucket", "archives"); err != nil {
+		t.Fatalf("import-archive error = %v", err)
+	}
+
+	if !bytes.Equal(uploaded, f.archive) {
+		t.Errorf("uploaded archive = %q, want %q", uploaded, f.archive)
+	}
+	migration := f.startedMigration(t)
+	if !strings.HasPrefix(migration.GitArchiveURL, s3.Server.URL+"/archives/migration_archive.tar.gz?") ||
+		!strings.Contains(migration.GitArchiveURL, "X-Amz-Signature=") {
+		t.Errorf("gitArchiveUrl = %q, want a presigned URL of the object", migration.GitArchiveURL)
+	}
+	if keys := s3.Keys("archives"); len(keys) != 0 {
+		t.Errorf("bucket holds %v after the migration, want the archive deleted", keys)
+	}
+
+	entry := f.reported(t)
+	if entry.State != github.MigrationStateSucceeded || entry.Storage != "aws" || entry.MigrationID != migration.ID {
+		t.Errorf("report entry = %+v", entry)
+	}
+	if entry.ArchiveSize != int64(len(f.archive)) {
+		t.Errorf("report archive size = %d, want %d", entry.ArchiveSize, len(f.archive))
+	}
+}
+
+func TestImportArchiveAzure(t *testing.T) {
+	f := newImportFixture(t)
+	azure := testutil.NewAzure(t, "archives")
+	testutil.SetEnv(t, azure.Env())
+
+	var uploaded []byte
+	f.gh.OnStatus = func() { uploaded, _ = azure.Blob("archives", "archive.tar.gz") }
+
+	if err := f.run(t, "--storage", "azure", "--bucket", "archives", "--blob-name", "archive.tar.gz"); err != nil {
+		t.Fatalf("import-archive error = %v", err)
+	}
+
+	if !bytes.Equal(uploaded, f.archive) {
+		t.Errorf("uploaded blob = %q, want %q", uploaded, f.archive)
+	}
+	migration := f.startedMigration(t)
+	if !strings.HasPrefix(migration.GitArchiveURL, azure.Env()["AZURE_STORAGE_BLOB_ENDPOINT"]+"/archives/archive.tar.gz?") ||
+		!strings.Contains(migration.GitArchiveURL, "sig=") {
+		t.Errorf("gitArchiveUrl = %q, want a SAS URL of the blob", migration.GitArchiveURL)
+	}
+	if _, ok := azure.Blob("archives", "archive.tar.gz"); ok {
+		t.Error("blob still exists after the migration, want it deleted")
+	}
+	if entry := f.reported(t); entry.State != github.MigrationStateSucceeded || entry.Storage != "azure" {
+		t.Errorf("report entry = %+v", entry)
+	}
+}
+
+func TestImportArchiveGitHubStorage(t *testing.T) {
+	f := newImportFixture(t)
+
+	if err := f.run(t, "--storage", "github"); err != nil {
+		t.Fatalf("import-archive error = %v", err)
+	}
+
+	migration := f.startedMigration(t)
+	uploaded, ok := f.gh.Archive(migration.GitArchiveURL)
+	if !ok {
+		t.Fatalf("gitArchiveUrl = %q, want the URI of an archive in GitHub-owned storage", migration.GitArchiveURL)
+	}
+	if !bytes.Equal(uploaded, f.archive) {
+		t.Errorf("uploaded archive = %q, want %q", uploaded, f.archive)
+	}
+	if migration.GithubPat != f.gh.Token {
+		t.Errorf("githubPat = %q, want the token of GITHUB_PAT", migration.GithubPat)
+	}
+	if entry := f.reported(t); entry.State != github.MigrationStateSucceeded || entry.Storage != "github" {
+		t.Errorf("report entry = %+v", entry)
+	}
+}
+
+func TestImportArchiveMigrationFailed(t *testing.T) {
+	f := newImportFixture(t)
+	f.gh.States = []string{"QUEUED", "FAILED"}
+	f.gh.FailureReason = "archive is corrupt"
+
+	err := f.run(t, "--storage", "github")
+	if kind := failure.KindOf(err); kind != failure.KindMigrationFailed {
+		t.Fatalf("import-archive error = %v (%s), want a migration failure", err, kind)
+	}
+
+	entry := f.reported(t)
+	if entry.State != github.MigrationStateFailed || entry.FailureReason != "archive is corrupt" {
+		t.Errorf("report entry state = %q, failure reason = %q", entry.State, entry.FailureReason)
+	}
+}
+
+func TestImportArchiveWarnings(t *testing.T) {
+	f := newImportFixture(t)
+	f.gh.WarningsCount = 3
+
+	if err := f.run(t, "--storage", "github"); err != nil {
+		t.Fatalf("import-archive error = %v, want warnings to be ignored by default", err)
+	}
+
+	err := f.run(t, "--storage", "github", "--exit-on-warnings")
+	if kind := failure.KindOf(err); kind != failure.KindWarnings {
+		t.Errorf("import-archive --exit-on-warnings error = %v (%s), want warnings", err, kind)
+	}
+}
+
+func TestImportArchiveSkipsExistingRepository(t *testing.T) {
+	f := newImportFixture(t)
+	f.gh.Repositories = []string{"api"}
+
+	if err := f.run(t, "--storage", "github", "--on-collision", "skip"); err != nil {
+		t.Fatalf("import-archive error = %v", err)
+	}
+
+	if migrations := f.gh.Migrations(); len(migrations) != 0 {
+		t.Errorf("started %d migrations, want none for an existing repository", len(migrations))
+	}
+	if entry := f.reported(t); entry.State != report.StateSkipped {
+		t.Errorf("report entry state = %q, want %q", entry.State, report.StateSkipped)
+	}
+}
+
+func TestImportArchiveNotifications(t *testing.T) {
+	f := newImportFixture(t)
+	f.gh.WarningsCount = 1
+
+	var mu sync.Mutex
+	var received []string
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get(migrator.SignatureHeader) != migrator.Sign("s3cret", body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		received = append(received, r.Header.Get(migrator.EventHeader))
+		mu.Unlock()
+	}))
+	defer webhook.Close()
+
+	dir := t.TempDir()
+	eventsPath := filepath.Join(dir, "events.jsonl")
+	configPath := filepath.Join(dir, "config.yaml")
+	data := fmt.Sprintf(`profiles:
+  default:
+    notifications:
+      - type: webhook
+        url: %s
+        secret: s3cret
+      - type: file
+        path: %s
+        events: [migration.succeeded, migration.warnings]
+`, webhook.URL, eventsPath)
+	if err := os.WriteFile(configPath, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Use(context.Background(), configPath, ""); err != nil {
+		t.Fatalf("config.Use() error = %v", err)
+	}
+	t.Cleanup(func() { _ = config.Use(context.Background(), filepath.Join(dir, "missing.yaml"), "") })
+
+	if err := f.run(t, "--storage", "github"); err != nil {
+		t.Fatalf("import-archive error = %v", err)
+	}
+
+	mu.Lock()
+	got := strings.Join(received, ",")
+	mu.Unlock()
+	if want := "upload.completed,migration.queued,migration.succeeded,migration.warnings"; got != want {
+		t.Errorf("webhook received %s, want %s", got, want)
+	}
+	events, err := os.ReadFile(eventsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(events), "\n"); lines != 2 {
+		t.Errorf("event file has %d lines, want the succeeded and warnings events:\n%s", lines, events)
+	}
+}
+
+func TestImportArchiveDryRun(t *testing.T) {
+	f := newImportFixture(t)
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Name: "project.json", Mode: 0o600}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(f.archivePath, archive.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.run(t, "--storage", "github", "--dry-run"); err != nil {
+		t.Fatalf("import-archive --dry-run error = %v", err)
+	}
+
+	plan := f.out.String()
+	for _, want := range []string{
+		"Dry run: no changes were made",
+		"to the GitHub-owned storage of " + f.gh.Org.Login,
+		"createMigrationSource(",
+		`startRepositoryMigration(sourceRepositoryUrl: "https://gitlab.example.com/group/api", repositoryName: "api"`,
+	} {
+		if !strings.Contains(plan, want) {
+			t.Errorf("dry run plan does not mention %q:\n%s", want, plan)
+		}
+	}
+	if sources := f.gh.MigrationSources(); len(sources) != 0 {
+		t.Errorf("created migration sources %+v, want none in a dry run", sources)
+	}
+	if migrations := f.gh.Migrations(); len(migrations) != 0 {
+		t.Errorf("started migrations %+v, want none in a dry run", migrations)
+	}
+	if _, err := os.Stat(f.reportPath); !os.IsNotExist(err) {
+		t.Errorf("dry run wrote the report %s", f.reportPath)
+	}
+}
+
+func TestImportArchiveMissingFlags(t *testing.T) {
+	root := &cobra.Command{
+		Use:               "gh-glx",
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+		PersistentPreRunE: func(c *cobra.Command, args []string) error { return ValidateFlags(c) },
+	}
+	root.AddCommand(ImportArchiveCmd(), AbortMigrationCmd())
+
+	for _, args := range [][]string{
+		{"import-archive"},
+		{"import-archive", "--org", "my-org"},
+		{"abort-migration", "--org", "my-org"},
+	} {
+		root.SetArgs(args)
+		err := root.ExecuteContext(context.Background())
+		if code := failure.ExitCode(err); code != failure.ExitValidation {
+			t.Errorf("%v: exit code = %d (%v), want %d for a missing flag", args, code, err, failure.ExitValidation)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	gl "github.com/ps-resources/gh-glx-migrator/internal/gitlab"
+	ghlog "github.com/ps-resources/gh-glx-migrator/pkg/logger"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// inventoryCSVHeader lists the columns of the inventory CSV. The gl_namespace,
+// gl_project and source_url columns make the file usable as a migration plan.
+var inventoryCSVHeader = []string{
+	"gl_namespace", "gl_project", "path_with_namespace", "source_url",
+	"repository_size", "lfs_size", "has_wiki", "issues", "merge_requests", "pipelines",
+	"last_activity_at", "archived", "has_container_registry", "has_packages", "ci_variables",
+}
+
+func InventoryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "inventory",
+		Short: "Report the size and contents of the projects in a GitLab group",
+		Long: `Report the size and contents of every project in a GitLab group, to plan migration waves.
+
+For each project the report includes the repository and LFS sizes in bytes,
+whether it has a wiki, the number of issues, merge requests and pipelines,
+the last activity, the archived flag, whether it has container registry
+images or packages (which are not migrated), and the number of CI variables.
+Counts the token is not allowed to read are reported as -1.
+
+The CSV can be used directly as a migration plan.
+
+GitLab credentials must be configured via GITLAB_API_ENDPOINT and GITLAB_PAT.`,
+		Example: `gh glx inventory --gl-group my-group --recursive --csv inventory.csv --json inventory.json`,
+		RunE:    runInventory,
+	}
+
+	cmd.Flags().String("gl-group", "", "GitLab group full path or ID")
+	cmd.Flags().Bool("recursive", false, "Include projects of subgroups")
+	cmd.Flags().String("csv", "inventory.csv", "Path of the CSV report (empty to disable)")
+	cmd.Flags().String("json", "inventory.json", "Path of the JSON report (empty to disable)")
+	cmd.Flags().Int("concurrency", 4, "Number of projects inspected in parallel")
+
+	errGroup := cmd.MarkFlagRequired("gl-group")
+	if errGroup != nil {
+		ghlog.Logger.Error("failed to mark flag as required", zap.Error(errGroup))
+		return nil
+	}
+
+	return cmd
+}
+
+func runInventory(cmd *cobra.Command, args []string) error {
+	group, _ := cmd.Flags().GetString("gl-group")
+	recursive, _ := cmd.Flags().GetBool("recursive")
+	csvPath, _ := cmd.Flags().GetString("csv")
+	jsonPath, _ := cmd.Flags().GetString("json")
+	concurrency, _ := cmd.Flags().GetInt("concurrency")
+
+	client, err := gitlabClientFromEnv()
+	if err != nil {
+		return err
+	}
+
+	ghlog.Logger.Info("Collecting GitLab inventory",
+		zap.String("group", group),
+		zap.Bool("recursive", recursive))
+
+	inventory, err := gl.Inventory(cmd.Context(), client, gl.InventoryOptions{
+		Group:       group,
+		Recursive:   recursive,
+		Concurrency: concurrency,
+	})
+	if err != nil {
+		return err
+	}
+
+	if csvPath != "" {
+		if err := writeCSV(csvPath, inventoryCSVHeader, func(w *csv.Writer) error {
+			for _, p := range inventory {
+				if err := w.Write([]string{
+					p.GLNamespace, p.GLProject, p.PathWithNamespace, p.SourceURL,
+					strconv.FormatInt(p.RepositorySize, 10), strconv.FormatInt(p.LFSSize, 10),
+					strconv.FormatBool(p.HasWiki), strconv.Itoa(p.Issues), strconv.Itoa(p.MergeRequests), strconv.Itoa(p.Pipelines),
+					p.LastActivityAt, strconv.FormatBool(p.Archived),
+					strconv.FormatBool(p.HasContainerRegistry), strconv.FormatBool(p.HasPackages), strconv.Itoa(p.CIVariables),
+				}); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	if jsonPath != "" {
+		jsonData, err := json.MarshalIndent(inventory, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal data to JSON: %v", err)
+		}
+		if err := os.WriteFile(jsonPath, jsonData, 0o644); err != nil {
+			return fmt.Errorf("failed to write JSON report: %w", err)
+		}
+	}
+
+	var repositorySize, lfsSize int64
+	unsized := 0
+	for _, p := range inventory {
+		if p.RepositorySize == gl.CountUnavailable {
+			unsized++
+			continue
+		}
+		repositorySize += p.RepositorySize
+		lfsSize += p.LFSSize
+	}
+
+	ghlog.Logger.Info("GitLab inventory written",
+		zap.String("group", group),
+		zap.Int("projects", len(inventory)),
+		zap.Int64("repository_bytes", repositorySize),
+		zap.Int64("lfs_bytes", lfsSize),
+		zap.Int("projects_without_statistics", unsized),
+		zap.String("csv", csvPath),
+		zap.String("json", jsonPath))
+
+	return nil
+}
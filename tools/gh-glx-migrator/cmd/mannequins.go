@@ -0,0 +1,340 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ps-resources/gh-glx-migrator/internal/failure"
+	"github.com/ps-resources/gh-glx-migrator/internal/github"
+	ghlog "github.com/ps-resources/gh-glx-migrator/pkg/logger"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// mannequinCSVHeader is the header of the mannequin mapping CSV, matching the
+// user-mappings-gei.csv file shipped with the migration workflows.
+var mannequinCSVHeader = []string{"mannequin-user", "mannequin-id", "target-user"}
+
+func GenerateMannequinCSVCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "generate-mannequin-csv",
+		Short: "Generate a CSV of the mannequins in an organization",
+		Long: `Generate a CSV listing the mannequins of a GitHub organization.
+
+Fill in the target-user column with the GitHub login that should be credited
+for each mannequin, then pass the file to reclaim-mannequins.
+
+An existing output file is not overwritten, so a curated mapping file is not
+lost by accident; pass --force to replace it.
+
+GitHub credentials must be configured via environment variables.`,
+		Example: `gh glx generate-mannequin-csv --org my-org --output user-mappings-gei.csv`,
+		RunE:    generateMannequinCSV,
+	}
+
+	cmd.Flags().String("org", "", "GitHub organization name")
+	cmd.Flags().String("output", "user-mappings-gei.csv", "Path of the CSV file to write")
+	cmd.Flags().Bool("include-reclaimed", false, "Include mannequins that have already been reclaimed")
+	cmd.Flags().Bool("force", false, "Overwrite the output file if it already exists")
+
+	errOrg := cmd.MarkFlagRequired("org")
+	if errOrg != nil {
+		ghlog.Logger.Error("failed to mark flag as required", zap.Error(errOrg))
+		return nil
+	}
+
+	return cmd
+}
+
+func ReclaimMannequinsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reclaim-mannequins",
+		Short: "Reclaim mannequins from a user mapping CSV",
+		Long: `Attribute the contributions of mannequins to GitHub users listed in a CSV file.
+
+The CSV uses the mannequin-user,mannequin-id,target-user format written by
+generate-mannequin-csv. Rows without a target-user are skipped.
+
+By default each target user receives an attribution invitation. Organizations
+owned by an Enterprise Managed Users enterprise can use --skip-invitation to
+reattribute the contributions immediately.
+
+GitHub credentials must be configured via environment variables.`,
+		Example: `gh glx reclaim-mannequins --org my-org --csv user-mappings-gei.csv`,
+		RunE:    reclaimMannequins,
+	}
+
+	cmd.Flags().String("org", "", "GitHub organization name")
+	cmd.Flags().String("csv", "user-mappings-gei.csv", "Path of the mannequin mapping CSV")
+	cmd.Flags().Bool("skip-invitation", false, "Reattribute without sending invitations (Enterprise Managed Users only)")
+
+	errOrg := cmd.MarkFlagRequired("org")
+	if errOrg != nil {
+		ghlog.Logger.Error("failed to mark flag as required", zap.Error(errOrg))
+		return nil
+	}
+
+	return cmd
+}
+
+func generateMannequinCSV(cmd *cobra.Command, args []string) error {
+	org, _ := cmd.Flags().GetString("org")
+	output, _ := cmd.Flags().GetString("output")
+	includeReclaimed, _ := cmd.Flags().GetBool("include-reclaimed")
+	force, _ := cmd.Flags().GetBool("force")
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	} else if _, err := os.Stat(output); err == nil {
+		return failure.Validation(fmt.Errorf("output file %s already exists; pass --force to overwrite it", output))
+	}
+
+	mannequins, err := github.ListMannequins(cmd.Context(), org)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(output, flags, 0o644)
+	if errors.Is(err, fs.ErrExist) {
+		return failure.Validation(fmt.Errorf("output file %s already exists; pass --force to overwrite it", output))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			ghlog.Logger.Error("failed to close file", zap.Error(err))
+		}
+	}()
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write(mannequinCSVHeader); err != nil {
+		return fmt.Errorf("failed to write CSV header: %v", err)
+	}
+
+	written := 0
+	for _, m := range mannequins {
+		targetUser := ""
+		if m.Claimant != nil {
+			if !includeReclaimed {
+				continue
+			}
+			targetUser = m.Claimant.Login
+		}
+		if err := writer.Write([]string{m.Login, m.ID, targetUser}); err != nil {
+			return fmt.Errorf("failed to write CSV row: %v", err)
+		}
+		written++
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV file: %v", err)
+	}
+
+	ghlog.Logger.Info("Mannequin CSV written",
+		zap.String("output", output),
+		zap.Int("mannequins", written))
+
+	return nil
+}
+
+// mannequinMapping is a single row of the mannequin mapping CSV.
+type mannequinMapping struct {
+	Line          int
+	MannequinUser string
+	MannequinID   string
+	TargetUser    string
+}
+
+// reclaimResult is the outcome of reclaiming one row of the mapping CSV.
+type reclaimResult struct {
+	mannequinMapping
+	Result string
+	Error  string
+}
+
+func reclaimMannequins(cmd *cobra.Command, args []string) error {
+	org, _ := cmd.Flags().GetString("org")
+	csvPath, _ := cmd.Flags().GetString("csv")
+	skipInvitation, _ := cmd.Flags().GetBool("skip-invitation")
+
+	mappings, err := readMannequinCSV(csvPath)
+	if err != nil {
+		return err
+	}
+
+	orgMap, err := fetchOrgInfo(cmd.Context(), org)
+	if err != nil {
+		return fmt.Errorf("failed to fetch organization information: %w", err)
+	}
+	orgID := fmt.Sprintf("%v", orgMap["id"])
+
+	// Mannequin IDs are optional in the CSV; missing ones are resolved by login.
+	var mannequinIDs map[string]string
+	userIDs := make(map[string]string)
+
+	var results []reclaimResult
+	failed := 0
+	for _, m := range mappings {
+		if cmd.Context().Err() != nil {
+			break
+		}
+		result := reclaimResult{mannequinMapping: m}
+
+		if m.TargetUser == "" {
+			result.Result = "skipped"
+			result.Error = "no target-user"
+			results = append(results, result)
+			continue
+		}
+
+		if m.MannequinID == "" {
+			if mannequinIDs == nil {
+				mannequinIDs, err = mannequinIDsByLogin(cmd.Context(), org)
+				if err != nil {
+					return err
+				}
+			}
+			m.MannequinID = mannequinIDs[m.MannequinUser]
+			result.MannequinID = m.MannequinID
+		}
+
+		var reclaimErr error
+		targetID, ok := userIDs[m.TargetUser]
+		if !ok {
+			targetID, reclaimErr = github.GetUserID(cmd.Context(), m.TargetUser)
+			if reclaimErr == nil {
+				userIDs[m.TargetUser] = targetID
+			}
+		}
+
+		switch {
+		case m.MannequinID == "":
+			reclaimErr = fmt.Errorf("mannequin %s not found in organization %s", m.MannequinUser, org)
+		case reclaimErr == nil:
+			reclaimErr = github.ReclaimMannequin(cmd.Context(), github.ReclaimMannequinInput{
+				OwnerID:        orgID,
+				MannequinID:    m.MannequinID,
+				TargetUserID:   targetID,
+				SkipInvitation: skipInvitation,
+			})
+		}
+
+		if reclaimErr != nil {
+			ghlog.Logger.Error("Failed to reclaim mannequin",
+				zap.Int("line", m.Line),
+				zap.String("mannequin", m.MannequinUser),
+				zap.String("target", m.TargetUser),
+				zap.Error(reclaimErr))
+			result.Result = "failed"
+			result.Error = reclaimErr.Error()
+			failed++
+		} else if skipInvitation {
+			result.Result = "reclaimed"
+		} else {
+			result.Result = "invited"
+		}
+		results = append(results, result)
+	}
+
+	if err := writeReclaimResults(cmd.OutOrStdout(), results); err != nil {
+		return err
+	}
+
+	if err := cmd.Context().Err(); err != nil {
+		return fmt.Errorf("stopped after %d of %d mannequins: %w", len(results), len(mappings), err)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to reclaim %d of %d mannequins", failed, len(mappings))
+	}
+
+	ghlog.Logger.Info("Mannequins reclaimed", zap.Int("rows", len(mappings)))
+	return nil
+}
+
+// readMannequinCSV parses a mannequin-user,mannequin-id,target-user CSV file.
+func readMannequinCSV(path string) ([]mannequinMapping, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open CSV file: %w", err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			ghlog.Logger.Error("failed to close file", zap.Error(err))
+		}
+	}()
+
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %v", err)
+	}
+	if len(header) < len(mannequinCSVHeader) {
+		return nil, fmt.Errorf("invalid CSV header %q, expected %q", strings.Join(header, ","), strings.Join(mannequinCSVHeader, ","))
+	}
+	for i, name := range mannequinCSVHeader {
+		if strings.TrimSpace(strings.ToLower(header[i])) != name {
+			return nil, fmt.Errorf("invalid CSV header %q, expected %q", strings.Join(header, ","), strings.Join(mannequinCSVHeader, ","))
+		}
+	}
+
+	var mappings []mannequinMapping
+	line := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV line %d: %v", line, err)
+		}
+		mappings = append(mappings, mannequinMapping{
+			Line:          line,
+			MannequinUser: strings.TrimSpace(record[0]),
+			MannequinID:   strings.TrimSpace(record[1]),
+			TargetUser:    strings.TrimSpace(record[2]),
+		})
+	}
+
+	return mappings, nil
+}
+
+func mannequinIDsByLogin(ctx context.Context, org string) (map[string]string, error) {
+	mannequins, err := github.ListMannequins(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]string, len(mannequins))
+	for _, m := range mannequins {
+		ids[m.Login] = m.ID
+	}
+	return ids, nil
+}
+
+func writeReclaimResults(w io.Writer, results []reclaimResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "LINE\tMANNEQUIN\tTARGET\tRESULT\tERROR"); err != nil {
+		return err
+	}
+	for _, r := range results {
+		if _, err := fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n",
+			r.Line, r.MannequinUser, r.TargetUser, r.Result, r.Error); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
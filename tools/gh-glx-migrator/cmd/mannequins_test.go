@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ps-resources/gh-glx-migrator/internal/failure"
+	"github.com/ps-resources/gh-glx-migrator/internal/testutil"
+)
+
+func TestGenerateMannequinCSV(t *testing.T) {
+	gh := newGitHubFixture(t)
+	gh.Mannequins = []testutil.Mannequin{
+		{ID: "MDg6_alice", Login: "alice"},
+		{ID: "MDg6_bob", Login: "bob", Claimant: "bob-gh"},
+	}
+	gh.Users = map[string]string{"bob-gh": "U_bob"}
+	output := filepath.Join(t.TempDir(), "user-mappings-gei.csv")
+
+	if err := runCommand(context.Background(), GenerateMannequinCSVCmd(), &bytes.Buffer{}, "--org", gh.Org.Login, "--output", output); err != nil {
+		t.Fatalf("generate-mannequin-csv error = %v", err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "mannequin-user,mannequin-id,target-user\nalice,MDg6_alice,\n"; string(data) != want {
+		t.Errorf("CSV =\n%s\nwant\n%s", data, want)
+	}
+
+	// A curated mapping file is kept unless --force is given.
+	curated := []byte("mannequin-user,mannequin-id,target-user\nalice,MDg6_alice,alice-gh\n")
+	if err := os.WriteFile(output, curated, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err = runCommand(context.Background(), GenerateMannequinCSVCmd(), &bytes.Buffer{}, "--org", gh.Org.Login, "--output", output)
+	if kind := failure.KindOf(err); kind != failure.KindValidation {
+		t.Errorf("generate-mannequin-csv over an existing file error = %v (%s), want a validation error", err, kind)
+	}
+	if data, _ := os.ReadFile(output); !bytes.Equal(data, curated) {
+		t.Errorf("existing CSV was overwritten:\n%s", data)
+	}
+
+	if err := runCommand(context.Background(), GenerateMannequinCSVCmd(), &bytes.Buffer{}, "--org", gh.Org.Login, "--output", output, "--include-reclaimed", "--force"); err != nil {
+		t.Fatalf("generate-mannequin-csv --force error = %v", err)
+	}
+	if data, _ := os.ReadFile(output); !strings.HasSuffix(string(data), "bob,MDg6_bob,bob-gh\n") {
+		t.Errorf("CSV with reclaimed mannequins =\n%s", data)
+	}
+}
+
+func TestReclaimMannequins(t *testing.T) {
+	gh := newGitHubFixture(t)
+	gh.Mannequins = []testutil.Mannequin{{ID: "MDg6_carol", Login: "carol"}}
+	gh.Users = map[string]string{"alice-gh": "U_alice", "carol-gh": "U_carol"}
+	csvPath := filepath.Join(t.TempDir(), "user-mappings-gei.csv")
+	data := `mannequin-user,mannequin-id,target-user
+alice,MDg6_alice,alice-gh
+bob,MDg6_bob,
+carol,,carol-gh
+dave,MDg6_dave,nobody
+`
+	if err := os.WriteFile(csvPath, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	err := runCommand(context.Background(), ReclaimMannequinsCmd(), &out, "--org", gh.Org.Login, "--csv", csvPath)
+	if err == nil || !strings.Contains(err.Error(), "failed to reclaim 1 of 4 mannequins") {
+		t.Errorf("reclaim-mannequins error = %v, want the unknown target user to fail", err)
+	}
+
+	attributions := gh.Attributions()
+	if len(attributions) != 2 {
+		t.Fatalf("attributions = %+v, want alice and carol", attributions)
+	}
+	if a := attributions[0]; a.OwnerID != gh.Org.ID || a.SourceID != "MDg6_alice" || a.TargetID != "U_alice" || a.SkipInvitation {
+		t.Errorf("first attribution = %+v, want an invitation of alice-gh", a)
+	}
+	if a := attributions[1]; a.SourceID != "MDg6_carol" || a.TargetID != "U_carol" {
+		t.Errorf("second attribution = %+v, want the mannequin ID of carol looked up by login", a)
+	}
+
+	results := out.String()
+	for _, want := range []string{"invited", "skipped", "no target-user", "user nobody not found"} {
+		if !strings.Contains(results, want) {
+			t.Errorf("results do not mention %q:\n%s", want, results)
+		}
+	}
+
+	if err := runCommand(context.Background(), ReclaimMannequinsCmd(), &bytes.Buffer{}, "--org", gh.Org.Login, "--csv", csvPath, "--skip-invitation"); err == nil {
+		t.Error("reclaim-mannequins --skip-invitation returned no error for the unknown target user")
+	}
+	if a := gh.Attributions(); len(a) != 4 || !a[2].SkipInvitation {
+		t.Errorf("attributions with --skip-invitation = %+v, want reattributions", a)
+	}
+}
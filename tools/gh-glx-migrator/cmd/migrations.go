@@ -0,0 +1,312 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ps-resources/gh-glx-migrator/internal/github"
+	"github.com/ps-resources/gh-glx-migrator/internal/report"
+	ghlog "github.com/ps-resources/gh-glx-migrator/pkg/logger"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+func ListMigrationsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list-migrations",
+		Short: "List repository migrations in an organization",
+		Long: `List the repository migrations that are queued, running or finished in a GitHub organization.
+
+The duration column shows how long an unfinished migration has been running.
+GitHub does not report completion times, so it is empty for finished migrations.
+
+GitHub credentials must be configured via environment variables.`,
+		Example: `gh glx list-migrations --org my-org --state FAILED --since 24h --format csv`,
+		RunE:    listMigrations,
+	}
+
+	cmd.Flags().String("org", "", "GitHub organization name")
+	cmd.Flags().String("state", "", "Only show migrations in this state (e.g. QUEUED, IN_PROGRESS, FAILED, SUCCEEDED)")
+	cmd.Flags().Duration("since", 0, "Only show migrations created within this period (e.g. 24h)")
+	cmd.Flags().String("format", "table", "Output format (table, json, csv)")
+	cmd.Flags().Duration("watch", 0, "Refresh the list at this interval until interrupted (e.g. 30s)")
+
+	errOrg := cmd.MarkFlagRequired("org")
+	if errOrg != nil {
+		ghlog.Logger.Error("failed to mark flag as required", zap.Error(errOrg))
+		return nil
+	}
+
+	return cmd
+}
+
+func AbortMigrationCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "abort-migration",
+		Short: "Abort in-flight repository migrations",
+		Long: `Abort a repository migration that has not finished yet.
+
+Either abort a single migration with --migration-id, or abort every migration
+of an organization in a given state with --org and --state.
+With --report, each abort is appended to a JSON run report.
+
+GitHub credentials must be configured via environment variables.`,
+		Example: `gh glx abort-migration --migration-id RM_xxx
+gh glx abort-migration --org my-org --state QUEUED --report abort-report.json`,
+		RunE: abortMigration,
+	}
+
+	cmd.Flags().String("migration-id", "", "ID of the migration to abort")
+	cmd.Flags().String("org", "", "GitHub organization whose migrations should be aborted")
+	cmd.Flags().String("state", "", "Abort every migration of the organization in this state (e.g. QUEUED)")
+	cmd.Flags().String("report", "", "Append the aborts to this JSON run report")
+
+	cmd.MarkFlagsMutuallyExclusive("migration-id", "org")
+	cmd.MarkFlagsOneRequired("migration-id", "org")
+	cmd.MarkFlagsRequiredTogether("org", "state")
+
+	return cmd
+}
+
+// migrationRow is the flattened view of a repository migration used for output.
+type migrationRow struct {
+	ID             string `json:"id"`
+	SourceURL      string `json:"sourceUrl"`
+	RepositoryName string `json:"repositoryName"`
+	State          string `json:"state"`
+	FailureReason  string `json:"failureReason"`
+	CreatedAt      string `json:"createdAt"`
+	Duration       string `json:"duration"`
+}
+
+func listMigrations(cmd *cobra.Command, args []string) error {
+	org, _ := cmd.Flags().GetString("org")
+	state, _ := cmd.Flags().GetString("state")
+	since, _ := cmd.Flags().GetDuration("since")
+	format, _ := cmd.Flags().GetString("format")
+	watch, _ := cmd.Flags().GetDuration("watch")
+
+	format = strings.ToLower(format)
+	if format != "table" && format != "json" && format != "csv" {
+		return fmt.Errorf("unknown format: %s. Available formats: table, json, csv", format)
+	}
+
+	if watch <= 0 {
+		return printMigrations(cmd.Context(), cmd.OutOrStdout(), org, state, since, format)
+	}
+
+	// The list is refreshed until the command is interrupted, which ends the
+	// watch without an error.
+	ticker := time.NewTicker(watch)
+	defer ticker.Stop()
+	for {
+		if err := printMigrations(cmd.Context(), cmd.OutOrStdout(), org, state, since, format); err != nil {
+			if cmd.Context().Err() != nil {
+				return nil
+			}
+			return err
+		}
+		select {
+		case <-cmd.Context().Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func printMigrations(ctx context.Context, w io.Writer, org, state string, since time.Duration, format string) error {
+	input := github.ListMigrationsInput{
+		Organization: org,
+		State:        state,
+	}
+	if since > 0 {
+		input.Since = time.Now().Add(-since)
+	}
+
+	migrations, err := github.ListRepositoryMigrations(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	rows := make([]migrationRow, 0, len(migrations))
+	for _, m := range migrations {
+		rows = append(rows, migrationRow{
+			ID:             m.ID,
+			SourceURL:      m.SourceURL,
+			RepositoryName: m.RepositoryName,
+			State:          m.State,
+			FailureReason:  m.FailureReason,
+			CreatedAt:      m.CreatedAt.Format(time.RFC3339),
+			Duration:       migrationDuration(m),
+		})
+	}
+
+	switch format {
+	case "json":
+		return writeMigrationsJSON(w, rows)
+	case "csv":
+		return writeMigrationsCSV(w, rows)
+	default:
+		return writeMigrationsTable(w, rows)
+	}
+}
+
+// migrationDuration returns how long an unfinished migration has been running.
+func migrationDuration(m github.RepositoryMigration) string {
+	if github.IsTerminalMigrationState(m.State) || m.CreatedAt.IsZero() {
+		return ""
+	}
+	return time.Since(m.CreatedAt).Round(time.Second).String()
+}
+
+func writeMigrationsJSON(w io.Writer, rows []migrationRow) error {
+	jsonData, err := json.MarshalIndent(rows, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal data to JSON: %v", err)
+	}
+	_, err = fmt.Fprintln(w, string(jsonData))
+	return err
+}
+
+func writeMigrationsCSV(w io.Writer, rows []migrationRow) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"id", "source_url", "repository_name", "state", "failure_reason", "created_at", "duration"}); err != nil {
+		return fmt.Errorf("failed to write CSV header: %v", err)
+	}
+	for _, r := range rows {
+		if err := writer.Write([]string{r.ID, r.SourceURL, r.RepositoryName, r.State, r.FailureReason, r.CreatedAt, r.Duration}); err != nil {
+			return fmt.Errorf("failed to write CSV row: %v", err)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeMigrationsTable(w io.Writer, rows []migrationRow) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "REPOSITORY\tSTATE\tSOURCE URL\tCREATED\tDURATION\tFAILURE REASON"); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.RepositoryName, r.State, r.SourceURL, r.CreatedAt, r.Duration,
+			strings.ReplaceAll(r.FailureReason, "\n", " ")); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+// abortRecord is a single entry of the abort run report.
+type abortRecord struct {
+	MigrationID    string    `json:"migrationId"`
+	RepositoryName string    `json:"repositoryName,omitempty"`
+	SourceURL      string    `json:"sourceUrl,omitempty"`
+	PreviousState  string    `json:"previousState,omitempty"`
+	Aborted        bool      `json:"aborted"`
+	Error          string    `json:"error,omitempty"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+func abortMigration(cmd *cobra.Command, args []string) error {
+	migrationID, _ := cmd.Flags().GetString("migration-id")
+	org, _ := cmd.Flags().GetString("org")
+	state, _ := cmd.Flags().GetString("state")
+	reportPath, _ := cmd.Flags().GetString("report")
+
+	var targets []github.RepositoryMigration
+	if migrationID != "" {
+		targets = append(targets, github.RepositoryMigration{ID: migrationID})
+	} else {
+		state = strings.ToUpper(state)
+		if github.IsTerminalMigrationState(state) {
+			return fmt.Errorf("migrations in state %s have already finished and cannot be aborted", state)
+		}
+
+		migrations, err := github.ListRepositoryMigrations(cmd.Context(), github.ListMigrationsInput{
+			Organization: org,
+			State:        state,
+		})
+		if err != nil {
+			return err
+		}
+		targets = migrations
+
+		ghlog.Logger.Info("Found migrations to abort",
+			zap.String("organization", org),
+			zap.String("state", state),
+			zap.Int("count", len(targets)))
+	}
+
+	var records []abortRecord
+	failed := 0
+	for _, m := range targets {
+		if cmd.Context().Err() != nil {
+			break
+		}
+		record := abortRecord{
+			MigrationID:    m.ID,
+			RepositoryName: m.RepositoryName,
+			SourceURL:      m.SourceURL,
+			PreviousState:  m.State,
+			Timestamp:      time.Now().UTC(),
+		}
+
+		if err := github.AbortRepositoryMigration(cmd.Context(), m.ID); err != nil {
+			ghlog.Logger.Error("Failed to abort migration",
+				zap.String("migration_id", m.ID),
+				zap.String("repository", m.RepositoryName),
+				zap.Error(err))
+			record.Error = err.Error()
+			failed++
+		} else {
+			record.Aborted = true
+		}
+		records = append(records, record)
+	}
+
+	if reportPath != "" {
+		if err := appendAbortReport(reportPath, records); err != nil {
+			return err
+		}
+		ghlog.Logger.Info("Recorded aborts in run report", zap.String("report", reportPath))
+	}
+
+	if err := cmd.Context().Err(); err != nil {
+		return fmt.Errorf("stopped after %d of %d migrations: %w", len(records), len(targets), err)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to abort %d of %d migrations", failed, len(targets))
+	}
+
+	ghlog.Logger.Info("Aborted migrations", zap.Int("count", len(targets)))
+	return nil
+}
+
+// appendAbortReport adds records to the JSON run report at path, keeping the
+// entries written by earlier runs. The report is rewritten under the lock of
+// internal/report, so concurrent aborts do not drop each other's entries.
+func appendAbortReport(path string, records []abortRecord) error {
+	return report.Update(path, func(data []byte) ([]byte, error) {
+		var existing []abortRecord
+		if data != nil {
+			if err := json.Unmarshal(data, &existing); err != nil {
+				return nil, fmt.Errorf("failed to parse existing run report %s: %v", path, err)
+			}
+		}
+
+		jsonData, err := json.MarshalIndent(append(existing, records...), "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal run report: %v", err)
+		}
+		return jsonData, nil
+	})
+}
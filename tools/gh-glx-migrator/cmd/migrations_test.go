@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ps-resources/gh-glx-migrator/internal/clients"
+	"github.com/ps-resources/gh-glx-migrator/internal/testutil"
+
+	"github.com/spf13/cobra"
+)
+
+// newGitHubFixture starts a fake GitHub that the commands use, with no other
+// GitHub settings of the environment running the tests and no credential
+// shared with earlier tests.
+func newGitHubFixture(t *testing.T) *testutil.GitHub {
+	t.Helper()
+	testutil.SetEnv(t, map[string]string{"GH_APP_ID": ""})
+	gh := testutil.NewGitHub(t)
+	testutil.SetEnv(t, gh.Env())
+	clients.ResetDefaultGitHubCredential()
+	t.Cleanup(clients.ResetDefaultGitHubCredential)
+	return gh
+}
+
+// runCommand runs sub under a root command with the persistent flags of
+// gh-glx, writing its output to out.
+func runCommand(ctx context.Context, sub *cobra.Command, out *bytes.Buffer, args ...string) error {
+	root := &cobra.Command{Use: "gh-glx", SilenceUsage: true, SilenceErrors: true}
+	root.PersistentFlags().Bool("non-interactive", true, "")
+	root.AddCommand(sub)
+	root.SetOut(out)
+	root.SetArgs(append([]string{sub.Name()}, args...))
+	return root.ExecuteContext(ctx)
+}
+
+// cancelWriter cancels a context once the given text was written n times.
+type cancelWriter struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	text   string
+	n      int
+	cancel context.CancelFunc
+}
+
+func (w *cancelWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	n, err := w.buf.Write(p)
+	if strings.Count(w.buf.String(), w.text) >= w.n {
+		w.cancel()
+	}
+	return n, err
+}
+
+func TestListMigrations(t *testing.T) {
+	gh := newGitHubFixture(t)
+	gh.AddMigration("https://gitlab.example.com/group/api", "api")
+
+	var out bytes.Buffer
+	if err := runCommand(context.Background(), ListMigrationsCmd(), &out, "--org", gh.Org.Login, "--format", "csv"); err != nil {
+		t.Fatalf("list-migrations error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[1], "RM_1,https://gitlab.example.com/group/api,api,QUEUED,") {
+		t.Errorf("list-migrations output:\n%s", out.String())
+	}
+}
+
+func TestListMigrationsWatchStopsOnCancel(t *testing.T) {
+	gh := newGitHubFixture(t)
+	gh.AddMigration("https://gitlab.example.com/group/api", "api")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	w := &cancelWriter{text: "REPOSITORY", n: 3, cancel: cancel}
+	sub := ListMigrationsCmd()
+	sub.SetOut(w)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- runCommand(ctx, sub, &bytes.Buffer{}, "--org", gh.Org.Login, "--watch", "10ms")
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("list-migrations --watch error = %v, want none when interrupted", err)
+		}
+	case <-time.After(30 * time.Second):
+		t.Fatal("list-migrations --watch did not stop when its context was cancelled")
+	}
+	if ctx.Err() != context.Canceled {
+		t.Errorf("watch stopped before it was interrupted: %v", ctx.Err())
+	}
+}
+
+func TestAbortMigration(t *testing.T) {
+	gh := newGitHubFixture(t)
+	queued := gh.AddMigration("https://gitlab.example.com/group/api", "api")
+	testutil.Chdir(t, t.TempDir())
+
+	if err := runCommand(context.Background(), AbortMigrationCmd(), &bytes.Buffer{}, "--migration-id", queued); err != nil {
+		t.Fatalf("abort-migration error = %v", err)
+	}
+	if aborted := gh.AbortedMigrations(); len(aborted) != 1 || aborted[0] != queued {
+		t.Errorf("aborted migrations = %v, want %s", aborted, queued)
+	}
+	if entries, _ := os.ReadDir("."); len(entries) != 0 {
+		t.Errorf("abort-migration wrote %s without --report", entries[0].Name())
+	}
+
+	reportPath := filepath.Join(t.TempDir(), "abort-report.json")
+	if err := runCommand(context.Background(), AbortMigrationCmd(), &bytes.Buffer{}, "--org", gh.Org.Login, "--state", "QUEUED", "--report", reportPath); err != nil {
+		t.Fatalf("abort-migration --org error = %v", err)
+	}
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []abortRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].MigrationID != queued || !records[0].Aborted || records[0].RepositoryName != "api" {
+		t.Errorf("run report = %+v", records)
+	}
+}
+
+func TestAbortMigrationReportWaitsForLock(t *testing.T) {
+	gh := newGitHubFixture(t)
+	queued := gh.AddMigration("https://gitlab.example.com/group/api", "api")
+	dir := t.TempDir()
+	reportPath := filepath.Join(dir, "abort-report.json")
+	// Another abort-migration run holds the report.
+	lockPath := reportPath + ".lock"
+	if err := os.WriteFile(lockPath, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- runCommand(context.Background(), AbortMigrationCmd(), &bytes.Buffer{}, "--migration-id", queued, "--report", reportPath)
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("abort-migration = %v while another run held the report", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+	if err := os.Remove(lockPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("abort-migration error = %v", err)
+	}
+
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []abortRecord
+	if err := json.Unmarshal(data, &records); err != nil || len(records) != 1 {
+		t.Errorf("run report = %s, %v, want the abort", data, err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("report directory holds %d files, want no temporary or lock files left", len(entries))
+	}
+}
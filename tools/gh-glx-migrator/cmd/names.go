@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ps-resources/gh-glx-migrator/internal/github"
+	"github.com/ps-resources/gh-glx-migrator/internal/naming"
+	"github.com/ps-resources/gh-glx-migrator/internal/plan"
+	"github.com/ps-resources/gh-glx-migrator/internal/preflight"
+	ghlog "github.com/ps-resources/gh-glx-migrator/pkg/logger"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+func MapNamesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "map-names",
+		Short: "Print the GitLab to GitHub repository name mapping of a plan",
+		Long: `Resolve the GitHub repository name of every project of a migration plan, without migrating anything.
+
+Names are derived from the GitLab project path with --name-strategy:
+- last-segment:  group/sub/api becomes api
+- flatten-path:  group/sub/api becomes group-sub-api
+- prefix-group:  group/sub/api becomes sub-api
+
+A target_repo column in the plan overrides the strategy for that project.
+When two projects, or a project and an existing repository of --org, resolve
+to the same name, --on-collision decides what happens:
+- fail:    report the conflict and exit non-zero
+- suffix:  append -2, -3, ... until the name is free
+- skip:    leave the project out
+
+A target_repo given in the plan is never suffixed; it is a conflict instead.
+Only the suffix mode lists every repository of --org. The other modes look up
+each target name on its own.
+
+--output writes the plan with the resolved target_repo column, so later runs
+use exactly the names that were reviewed.
+
+GitHub credentials must be configured via environment variables.`,
+		Example: `gh glx map-names --plan inventory.csv --org my-org --name-strategy prefix-group --on-collision suffix`,
+		RunE:    mapNames,
+	}
+
+	cmd.Flags().String("plan", "", "Path of the migration plan CSV")
+	cmd.Flags().String("org", "", "GitHub organization to check for existing repositories")
+	cmd.Flags().String("format", "table", "Output format (table, json)")
+	cmd.Flags().String("output", "", "Write the plan with the resolved target_repo column to this CSV")
+	addNamingFlags(cmd)
+
+	errPlan := cmd.MarkFlagRequired("plan")
+	if errPlan != nil {
+		ghlog.Logger.Error("failed to mark flag as required", zap.Error(errPlan))
+		return nil
+	}
+
+	return cmd
+}
+
+// addNamingFlags adds the flags read by namingPolicyFromFlags.
+func addNamingFlags(cmd *cobra.Command) {
+	cmd.Flags().String("name-strategy", string(naming.DefaultPolicy.Strategy), "How target repository names are derived (last-segment, flatten-path, prefix-group)")
+	cmd.Flags().String("on-collision", string(naming.DefaultPolicy.Collision), "What to do when target names collide (fail, suffix, skip)")
+}
+
+func namingPolicyFromFlags(cmd *cobra.Command) (naming.Policy, error) {
+	strategy, _ := cmd.Flags().GetString("name-strategy")
+	collision, _ := cmd.Flags().GetString("on-collision")
+	return naming.NewPolicy(strings.ToLower(strategy), strings.ToLower(collision))
+}
+
+func mapNames(cmd *cobra.Command, args []string) error {
+	planPath, _ := cmd.Flags().GetString("plan")
+	org, _ := cmd.Flags().GetString("org")
+	format, _ := cmd.Flags().GetString("format")
+	output, _ := cmd.Flags().GetString("output")
+
+	format = strings.ToLower(format)
+	if format != "table" && format != "json" {
+		return fmt.Errorf("unknown format: %s. Available formats: table, json", format)
+	}
+
+	policy, err := namingPolicyFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	entries, err := plan.Read(planPath)
+	if err != nil {
+		return err
+	}
+
+	var repositories naming.Organization
+	if org != "" {
+		target, err := github.DefaultTarget()
+		if err != nil {
+			return err
+		}
+		repositories = github.OrgRepositories{Target: target, Org: org}
+	}
+
+	mappings, err := policy.ResolveIn(cmd.Context(), preflight.NamingSources(entries), repositories)
+	if err != nil {
+		return err
+	}
+
+	if format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(mappings); err != nil {
+			return fmt.Errorf("failed to encode JSON: %v", err)
+		}
+	} else if err := writeNameMappingTable(os.Stdout, mappings); err != nil {
+		return err
+	}
+
+	conflicts := 0
+	for _, m := range mappings {
+		if m.Conflict != "" {
+			conflicts++
+		}
+	}
+	if conflicts > 0 {
+		return fmt.Errorf("%d of %d projects have conflicting target names", conflicts, len(mappings))
+	}
+
+	if output != "" {
+		if err := writeCSV(output, []string{"path_with_namespace", "target_repo"}, func(w *csv.Writer) error {
+			for _, m := range mappings {
+				if m.Skipped {
+					continue
+				}
+				if err := w.Write([]string{m.Source, m.Target}); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		ghlog.Logger.Info("Name mapping written", zap.String("output", output))
+	}
+
+	return nil
+}
+
+func writeNameMappingTable(w io.Writer, mappings []naming.Mapping) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "SOURCE\tTARGET\tSTATUS\tDETAIL"); err != nil {
+		return err
+	}
+	for _, m := range mappings {
+		status := "ok"
+		switch {
+		case m.Conflict != "":
+			status = "conflict"
+		case m.Skipped:
+			status = "skipped"
+		}
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", m.Source, m.Target, status, m.Conflict); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/ps-resources/gh-glx-migrator/internal/config"
+	"github.com/ps-resources/gh-glx-migrator/internal/failure"
+	"github.com/ps-resources/gh-glx-migrator/pkg/migrator"
+)
+
+// newEventBus returns a bus that sends lifecycle events to the notifications
+// of the profile, or nil when the profile has none.
+func newEventBus() (*migrator.Bus, error) {
+	notifications, err := config.Notifications()
+	if err != nil {
+		return nil, err
+	}
+	if len(notifications) == 0 {
+		return nil, nil
+	}
+
+	if problems := config.ValidateNotifications(notifications); len(problems) > 0 {
+		return nil, problemsError(problems)
+	}
+
+	bus := &migrator.Bus{}
+	for i, notification := range notifications {
+		var types []migrator.EventType
+		for _, name := range notification.Events {
+			eventType, err := migrator.ParseEventType(name)
+			if err != nil {
+				return nil, failure.Validation(fmt.Errorf("notifications[%d].events: %w", i, err))
+			}
+			types = append(types, eventType)
+		}
+
+		var sink migrator.Sink
+		switch notification.Type {
+		case config.NotificationWebhook:
+			sink = &migrator.WebhookSink{URL: notification.URL, Secret: notification.Secret}
+		case config.NotificationSlack:
+			sink = &migrator.SlackSink{URL: notification.URL}
+		case config.NotificationTeams:
+			sink = &migrator.TeamsSink{URL: notification.URL}
+		case config.NotificationFile:
+			sink = &migrator.FileSink{Path: notification.Path}
+		}
+		bus.Subscribe(sink, types...)
+	}
+	return bus, nil
+}
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ps-resources/gh-glx-migrator/internal/config"
+	"github.com/ps-resources/gh-glx-migrator/internal/plan"
+	"github.com/ps-resources/gh-glx-migrator/internal/preflight"
+	ghlog "github.com/ps-resources/gh-glx-migrator/pkg/logger"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+const mebibyte = 1024 * 1024
+
+func PreflightCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "preflight",
+		Short: "Check the projects of a migration plan for known GEI blockers",
+		Long: `Check every project of a migration plan for problems that make GitHub Enterprise Importer fail.
+
+The plan is a CSV with path_with_namespace, gl_namespace and gl_project, or
+source_url columns, such as the one written by the inventory command. An
+optional target_repo column sets the GitHub repository name, which otherwise
+is derived with --name-strategy and --on-collision (see map-names).
+
+Blockers (the command exits non-zero when any is found):
+- repositories larger than the archive size limit
+- git objects larger than the object size limit (with --inspect-objects)
+- branches or tags whose names git or GEI reject
+- target names GitHub does not accept
+- target names used twice in the plan or already taken in the organization
+
+Warnings:
+- LFS objects, which are not migrated
+- more branches and tags than --max-refs
+
+--inspect-objects mirrors every repository into a temporary directory, so it
+needs git and enough disk space for the largest repository.
+
+GitLab credentials must be configured via GITLAB_API_ENDPOINT and GITLAB_PAT.
+GitHub credentials must be configured via environment variables.`,
+		Example: `gh glx preflight --plan inventory.csv --org my-org --inspect-objects`,
+		RunE:    runPreflight,
+	}
+
+	cmd.Flags().String("plan", "", "Path of the migration plan CSV")
+	cmd.Flags().String("org", "", "GitHub organization to check for name collisions")
+	cmd.Flags().Int64("max-repo-size-mb", 40*1024, "Largest repository size in MiB")
+	cmd.Flags().Int64("max-object-size-mb", 100, "Largest git object size in MiB")
+	cmd.Flags().Int("max-refs", 10000, "Number of branches and tags above which a warning is reported")
+	cmd.Flags().Bool("inspect-objects", false, "Clone each repository to look for large git objects")
+	cmd.Flags().Int("concurrency", 4, "Number of projects checked in parallel")
+	cmd.Flags().String("format", "table", "Output format (table, json)")
+	addNamingFlags(cmd)
+
+	errPlan := cmd.MarkFlagRequired("plan")
+	if errPlan != nil {
+		ghlog.Logger.Error("failed to mark flag as required", zap.Error(errPlan))
+		return nil
+	}
+
+	return cmd
+}
+
+func runPreflight(cmd *cobra.Command, args []string) error {
+	planPath, _ := cmd.Flags().GetString("plan")
+	org, _ := cmd.Flags().GetString("org")
+	maxRepoSize, _ := cmd.Flags().GetInt64("max-repo-size-mb")
+	maxObjectSize, _ := cmd.Flags().GetInt64("max-object-size-mb")
+	maxRefs, _ := cmd.Flags().GetInt("max-refs")
+	inspectObjects, _ := cmd.Flags().GetBool("inspect-objects")
+	concurrency, _ := cmd.Flags().GetInt("concurrency")
+	format, _ := cmd.Flags().GetString("format")
+
+	format = strings.ToLower(format)
+	if format != "table" && format != "json" {
+		return fmt.Errorf("unknown format: %s. Available formats: table, json", format)
+	}
+
+	policy, err := namingPolicyFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	entries, err := plan.Read(planPath)
+	if err != nil {
+		return err
+	}
+
+	client, err := gitlabClientFromEnv()
+	if err != nil {
+		return err
+	}
+
+	ghlog.Logger.Info("Running preflight checks",
+		zap.String("plan", planPath),
+		zap.Int("projects", len(entries)),
+		zap.Bool("inspect_objects", inspectObjects))
+
+	results, err := preflight.Run(cmd.Context(), client, entries, preflight.Options{
+		Org:               org,
+		MaxRepositorySize: maxRepoSize * mebibyte,
+		MaxObjectSize:     maxObjectSize * mebibyte,
+		MaxRefs:           maxRefs,
+		InspectObjects:    inspectObjects,
+		GitLabToken:       config.Lookup(config.GitLabToken),
+		Concurrency:       concurrency,
+		Naming:            policy,
+	})
+	if err != nil {
+		return err
+	}
+
+	if format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(results); err != nil {
+			return fmt.Errorf("failed to encode JSON: %v", err)
+		}
+	} else if err := writePreflightTable(os.Stdout, results); err != nil {
+		return err
+	}
+
+	blockers, blocked := 0, 0
+	for _, r := range results {
+		if n := r.Blockers(); n > 0 {
+			blockers += n
+			blocked++
+		}
+	}
+
+	if blockers > 0 {
+		return fmt.Errorf("preflight found %d blockers in %d of %d projects", blockers, blocked, len(results))
+	}
+
+	ghlog.Logger.Info("Preflight checks passed", zap.Int("projects", len(results)))
+	return nil
+}
+
+func writePreflightTable(w io.Writer, results []preflight.Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "LINE\tPROJECT\tTARGET\tSEVERITY\tCHECK\tDETAIL"); err != nil {
+		return err
+	}
+	for _, r := range results {
+		if len(r.Findings) == 0 {
+			if _, err := fmt.Fprintf(tw, "%d\t%s\t%s\tok\t\t\n", r.Line, r.Project, r.TargetRepo); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, f := range r.Findings {
+			if _, err := fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n",
+				r.Line, r.Project, r.TargetRepo, f.Severity, f.Check, f.Detail); err != nil {
+				return err
+			}
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/ps-resources/gh-glx-migrator/internal/github"
+	ghlog "github.com/ps-resources/gh-glx-migrator/pkg/logger"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+func GrantMigratorRoleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "grant-migrator-role",
+		Short: "Grant the migrator role to a user or team",
+		Long: `Grant the migrator role in an organization to a user or team.
+
+The migrator role allows non-owners to run migrations into the organization.
+Only organization owners can grant it.
+
+GitHub credentials must be configured via environment variables.`,
+		Example: `gh glx grant-migrator-role --org my-org --actor octocat --actor-type USER`,
+		RunE:    grantMigratorRole,
+	}
+
+	addMigratorRoleFlags(cmd)
+	return cmd
+}
+
+func RevokeMigratorRoleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "revoke-migrator-role",
+		Short: "Revoke the migrator role from a user or team",
+		Long: `Revoke the migrator role in an organization from a user or team.
+
+Only organization owners can revoke it.
+
+GitHub credentials must be configured via environment variables.`,
+		Example: `gh glx revoke-migrator-role --org my-org --actor migration-team --actor-type TEAM`,
+		RunE:    revokeMigratorRole,
+	}
+
+	addMigratorRoleFlags(cmd)
+	return cmd
+}
+
+func addMigratorRoleFlags(cmd *cobra.Command) {
+	cmd.Flags().String("org", "", "GitHub organization name")
+	cmd.Flags().String("actor", "", "Login of the user or slug of the team")
+	cmd.Flags().String("actor-type", "USER", "Type of the actor (USER or TEAM)")
+
+	for _, flag := range []string{"org", "actor"} {
+		if err := cmd.MarkFlagRequired(flag); err != nil {
+			ghlog.Logger.Error("failed to mark flag as required", zap.Error(err))
+		}
+	}
+}
+
+func grantMigratorRole(cmd *cobra.Command, args []string) error {
+	input, err := migratorRoleInput(cmd)
+	if err != nil {
+		return err
+	}
+	return github.GrantMigratorRole(cmd.Context(), input)
+}
+
+func revokeMigratorRole(cmd *cobra.Command, args []string) error {
+	input, err := migratorRoleInput(cmd)
+	if err != nil {
+		return err
+	}
+	return github.RevokeMigratorRole(cmd.Context(), input)
+}
+
+func migratorRoleInput(cmd *cobra.Command) (github.MigratorRoleInput, error) {
+	org, _ := cmd.Flags().GetString("org")
+	actor, _ := cmd.Flags().GetString("actor")
+	actorType, _ := cmd.Flags().GetString("actor-type")
+
+	orgMap, err := fetchOrgInfo(cmd.Context(), org)
+	if err != nil {
+		return github.MigratorRoleInput{}, fmt.Errorf("failed to fetch organization information: %w", err)
+	}
+
+	return github.MigratorRoleInput{
+		OrganizationID: fmt.Sprintf("%v", orgMap["id"]),
+		Actor:          actor,
+		ActorType:      actorType,
+	}, nil
+}
+
+// grantMigratorRoleCommand returns the command an organization owner can run
+// to give a user the migrator role.
+func grantMigratorRoleCommand(org, login string) string {
+	return fmt.Sprintf("gh glx grant-migrator-role --org %s --actor %s --actor-type USER", org, login)
+}
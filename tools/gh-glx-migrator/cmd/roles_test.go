@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestMigratorRole(t *testing.T) {
+	gh := newGitHubFixture(t)
+	gh.Migrators = []string{"migration-team"}
+
+	if err := runCommand(context.Background(), GrantMigratorRoleCmd(), &bytes.Buffer{}, "--org", gh.Org.Login, "--actor", "octocat"); err != nil {
+		t.Fatalf("grant-migrator-role error = %v", err)
+	}
+	if want := []string{"migration-team", "octocat"}; !reflect.DeepEqual(gh.Migrators, want) {
+		t.Errorf("migrators after grant = %v, want %v", gh.Migrators, want)
+	}
+
+	if err := runCommand(context.Background(), RevokeMigratorRoleCmd(), &bytes.Buffer{}, "--org", gh.Org.Login, "--actor", "migration-team", "--actor-type", "team"); err != nil {
+		t.Fatalf("revoke-migrator-role error = %v", err)
+	}
+	if want := []string{"octocat"}; !reflect.DeepEqual(gh.Migrators, want) {
+		t.Errorf("migrators after revoke = %v, want %v", gh.Migrators, want)
+	}
+
+	if err := runCommand(context.Background(), GrantMigratorRoleCmd(), &bytes.Buffer{}, "--org", gh.Org.Login, "--actor", "octocat", "--actor-type", "bot"); err == nil {
+		t.Error("grant-migrator-role --actor-type bot returned no error")
+	}
+}
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ps-resources/gh-glx-migrator/internal/github"
+	gl "github.com/ps-resources/gh-glx-migrator/internal/gitlab"
+	"github.com/ps-resources/gh-glx-migrator/internal/usermap"
+	ghlog "github.com/ps-resources/gh-glx-migrator/pkg/logger"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+func MapUsersCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "map-users",
+		Short: "Propose a GitLab to GitHub user mapping",
+		Long: `Propose a GitHub login for every GitLab user.
+
+GitLab users are matched to members of the GitHub organization by, in order of confidence:
+- high:   an email address known to the organization's SAML/SCIM identity provider,
+          or an email address on one of the organization's verified domains
+- medium: the username known to the identity provider
+- low:    an identical GitLab and GitHub login
+
+The mapping is written in the mannequin-user,mannequin-id,target-user format
+with extra confidence and matched-on columns, so it can be reviewed and passed
+to reclaim-mannequins. Users without a single best match are written to a
+separate unmatched list.
+
+GitLab credentials must be configured via GITLAB_API_ENDPOINT and GITLAB_PAT.
+Email addresses of GitLab users are only visible to administrators.
+GitHub credentials must be configured via environment variables.`,
+		Example: `gh glx map-users --org my-org --output user-mappings-proposed.csv --unmatched unmatched-users.csv`,
+		RunE:    mapUsers,
+	}
+
+	cmd.Flags().String("org", "", "GitHub organization name")
+	cmd.Flags().String("output", "user-mappings-proposed.csv", "Path of the proposed mapping CSV to write")
+	cmd.Flags().String("unmatched", "unmatched-users.csv", "Path of the unmatched users CSV to write")
+	cmd.Flags().Bool("include-inactive", false, "Also map blocked and deactivated GitLab users")
+
+	errOrg := cmd.MarkFlagRequired("org")
+	if errOrg != nil {
+		ghlog.Logger.Error("failed to mark flag as required", zap.Error(errOrg))
+		return nil
+	}
+
+	return cmd
+}
+
+func mapUsers(cmd *cobra.Command, args []string) error {
+	org, _ := cmd.Flags().GetString("org")
+	output, _ := cmd.Flags().GetString("output")
+	unmatchedOutput, _ := cmd.Flags().GetString("unmatched")
+	includeInactive, _ := cmd.Flags().GetBool("include-inactive")
+
+	gitlabClient, err := gitlabClientFromEnv()
+	if err != nil {
+		return err
+	}
+
+	gitlabUsers, err := gl.ListUsers(cmd.Context(), gitlabClient)
+	if err != nil {
+		return err
+	}
+
+	var users []gl.User
+	for _, u := range gitlabUsers {
+		if u.Bot || (!includeInactive && u.State != "active") {
+			continue
+		}
+		users = append(users, u)
+	}
+	ghlog.Logger.Info("Fetched GitLab users",
+		zap.Int("total", len(gitlabUsers)),
+		zap.Int("mapped", len(users)))
+
+	members, err := github.ListOrgMembers(cmd.Context(), org)
+	if err != nil {
+		return err
+	}
+
+	identities, err := github.ListExternalIdentities(cmd.Context(), org)
+	if err != nil {
+		return err
+	}
+
+	matches, unmatched := usermap.Map(users, members, identities)
+
+	if err := writeCSV(output,
+		[]string{"mannequin-user", "mannequin-id", "target-user", "confidence", "matched-on"},
+		func(w *csv.Writer) error {
+			for _, m := range matches {
+				if err := w.Write([]string{m.GitLabUser, "", m.GitHubUser, string(m.Confidence), m.MatchedOn}); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+		return err
+	}
+
+	if err := writeCSV(unmatchedOutput,
+		[]string{"gitlab-user", "name", "emails", "reason"},
+		func(w *csv.Writer) error {
+			for _, u := range unmatched {
+				if err := w.Write([]string{u.User.Username, u.User.Name, strings.Join(u.User.Emails(), " "), u.Reason}); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+		return err
+	}
+
+	ghlog.Logger.Info("User mapping written",
+		zap.String("output", output),
+		zap.Int("matched", len(matches)),
+		zap.String("unmatched_output", unmatchedOutput),
+		zap.Int("unmatched", len(unmatched)))
+
+	return nil
+}
+
+// writeCSV creates path and writes the header followed by the rows produced by
+// writeRows.
+func writeCSV(path string, header []string, writeRows func(w *csv.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			ghlog.Logger.Error("failed to close file", zap.Error(err))
+		}
+	}()
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV header: %v", err)
+	}
+	if err := writeRows(writer); err != nil {
+		return fmt.Errorf("failed to write CSV row: %v", err)
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV file %s: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/ps-resources/gh-glx-migrator/internal/config"
+	"github.com/ps-resources/gh-glx-migrator/internal/failure"
+	ghlog "github.com/ps-resources/gh-glx-migrator/pkg/logger"
+)
+
+// addStorageFlag adds the --storage flag to a command that uploads archives.
+func addStorageFlag(cmd *cobra.Command) {
+	cmd.Flags().String("storage", config.StorageAuto, fmt.Sprintf("Storage to upload the archive to (%s). auto uses AWS or Azure, whichever credentials are set", strings.Join(config.Storages, ", ")))
+}
+
+// storageChoice returns the --storage flag. USE_GITHUB_STORAGE=true, which
+// older versions set, still selects GitHub-owned storage when the flag is not
+// given.
+func storageChoice(cmd *cobra.Command) string {
+	storage, _ := cmd.Flags().GetString("storage")
+	if !cmd.Flags().Changed("storage") && os.Getenv("USE_GITHUB_STORAGE") == "true" {
+		return config.StorageGitHub
+	}
+	return storage
+}
+
+// isInteractive reports whether the command may prompt: the global
+// --non-interactive flag is not set and stdin is a terminal.
+func isInteractive(cmd *cobra.Command) bool {
+	nonInteractive, _ := cmd.Flags().GetBool("non-interactive")
+	if nonInteractive {
+		return false
+	}
+	return isatty.IsTerminal(os.Stdin.Fd()) || isatty.IsCygwinTerminal(os.Stdin.Fd())
+}
+
+// checkSettings checks values against the requirements of a command and
+// returns the storage backend to upload to. When --storage auto finds no
+// storage credentials, an interactive session is asked whether to use
+// GitHub-owned storage instead; otherwise that is an error like any other
+// problem.
+func checkSettings(cmd *cobra.Command, values config.Values, requirements config.Requirements) (string, error) {
+	storage, problems := config.Check(values, requirements)
+	if storage == "" && requirements.Storage == config.StorageAuto && isInteractive(cmd) {
+		useGitHub, err := confirm(cmd, "No AWS or Azure storage credentials are set. Do you want to use GitHub-owned storage? (y/n): ")
+		if err != nil {
+			return "", err
+		}
+		if useGitHub {
+			requirements.Storage = config.StorageGitHub
+			storage, problems = config.Check(values, requirements)
+		}
+	}
+
+	if len(problems) > 0 {
+		return "", problemsError(problems)
+	}
+	if storage == config.StorageGitHub {
+		ghlog.Logger.Info("Using GitHub-owned storage for migration")
+	}
+	return storage, nil
+}
+
+// ValidateFlags checks the required flags and flag groups of cmd. Cobra
+// checks them only after the persistent pre-runs, and returns untyped errors
+// that bypass the flag error function, so the root command calls this first
+// to fail a missing flag with the exit code of a validation error.
+func ValidateFlags(cmd *cobra.Command) error {
+	if err := cmd.ValidateRequiredFlags(); err != nil {
+		return failure.Validation(err)
+	}
+	if err := cmd.ValidateFlagGroups(); err != nil {
+		return failure.Validation(err)
+	}
+	return nil
+}
+
+// problemsError joins problems into one error, one problem per line.
+func problemsError(problems []config.Problem) error {
+	messages := make([]string, len(problems))
+	keys := make([]string, len(problems))
+	for i, problem := range problems {
+		messages[i] = "  - " + problem.Message
+		keys[i] = problem.Key
+	}
+	ghlog.Logger.Debug("Invalid settings", zap.Strings("keys", keys))
+	return failure.Validation(fmt.Errorf("invalid settings:\n%s", strings.Join(messages, "\n")))
+}
+
+// confirm asks a yes or no question on the input of cmd.
+func confirm(cmd *cobra.Command, question string) (bool, error) {
+	if _, err := fmt.Fprint(cmd.OutOrStdout(), question); err != nil {
+		return false, err
+	}
+	answer, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	if err != nil {
+		return false, fmt.Errorf("failed to read answer: %w", err)
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
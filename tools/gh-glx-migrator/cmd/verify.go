@@ -1,14 +1,23 @@
 package cmd
 
 import (
-	"bufio"
+	"bytes"
+	"context"
 	"fmt"
-	"os"
+	"io"
 	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
 
 	"go.uber.org/zap"
 
+	awsUtils "github.com/ps-resources/gh-glx-migrator/internal/aws"
+	"github.com/ps-resources/gh-glx-migrator/internal/azure"
 	"github.com/ps-resources/gh-glx-migrator/internal/clients"
+	"github.com/ps-resources/gh-glx-migrator/internal/config"
+	"github.com/ps-resources/gh-glx-migrator/internal/github"
+	gl "github.com/ps-resources/gh-glx-migrator/internal/gitlab"
 	ghlog "github.com/ps-resources/gh-glx-migrator/pkg/logger"
 
 	"github.com/spf13/cobra"
@@ -18,164 +27,265 @@ func VerifyCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "verify",
 		Short: "Verify connections and credentials",
-		Long: `Verify GitHub, GitLab, and AWS connections and configuration.
+		Long: `Verify GitHub, GitLab, and AWS or Azure storage connections and configuration.
 
 This command checks:
-- GitHub PAT authentication
+- GitHub PAT authentication, its admin:org, repo and workflow scopes, and that
+  the user is an owner of --org or has the migrator role there
 - GitHub Enterprise Cloud with Data Residency API access
-- GitLab API access
-- AWS S3 credentials
+- GitLab API access: the GITLAB_PAT must belong to an administrator or have
+  the api and read_repository scopes, and must be able to see every --gl-project
+- AWS S3 credentials or Azure Blob Storage credentials, whichever are configured
+  or selected with --storage
 - Required environment variables
 
+When --storage is auto and neither AWS nor Azure credentials are set, verify
+asks whether GitHub-owned storage should be used, unless --non-interactive is
+given or stdin is not a terminal, in which case it fails. Pass --storage github
+to use GitHub-owned storage without asking.
+
+All provider checks run concurrently and the result of each one is reported.
 All credentials must be set via environment variables before running this command.`,
-		RunE: runVerify,
+		Example: `gh glx verify --org my-org --gl-project group/project --gl-project group/other-project`,
+		RunE:    runVerify,
 	}
+
+	cmd.Flags().String("org", "", "GitHub organization the token must be able to migrate into (default GITHUB_ORG or the profile's github.org)")
+	cmd.Flags().StringSlice("gl-project", []string{}, "GitLab project path the token must be able to see (repeatable)")
+	addStorageFlag(cmd)
+
 	return cmd
 }
 
+// verifyCheck is a single provider check run by the verify command.
+type verifyCheck struct {
+	name string
+	run  func() error
+}
+
 func runVerify(cmd *cobra.Command, args []string) error {
-	// Verify required environment variables on startup
-	if err := VerifyRequiredEnvVars(); err != nil {
-		fmt.Println("Error:", err)
-		os.Exit(1)
+	storage, err := checkSettings(cmd, config.Snapshot(), config.Requirements{GitHub: true, Storage: storageChoice(cmd)})
+	if err != nil {
+		return err
 	}
 	ghlog.Logger.Info("Verifying configuration and credentials...")
 
-	errChan := make(chan error, 3)
+	org := flagOrSetting(cmd, "org", config.GitHubOrg)
+	glProjects, _ := cmd.Flags().GetStringSlice("gl-project")
 
-	go func() {
-		errChan <- verifyGitHubPAT()
-	}()
+	// The GitHub remediation table is printed once every check has finished so
+	// that it is not interleaved with the output of the other checks.
+	var githubReport bytes.Buffer
 
-	for i := 0; i < 1; i++ {
-		if err := <-errChan; err != nil {
-			return err
+	checks := []verifyCheck{
+		{"GitHub", func() error { return verifyGitHubPAT(cmd.Context(), org, &githubReport) }},
+		{"GitLab", func() error { return verifyGitLab(cmd.Context(), glProjects) }},
+	}
+	if storage == config.StorageAWS || config.Lookup(config.AWSAccessKeyID) != "" || config.Lookup(config.AWSSecretAccessKey) != "" {
+		checks = append(checks, verifyCheck{"AWS S3", func() error { return verifyAwsAccess(cmd.Context()) }})
+	}
+	if storage == config.StorageAzure || config.Lookup(config.AzureStorageAccount) != "" || config.Lookup(config.AzureStorageAccessKey) != "" {
+		checks = append(checks, verifyCheck{"Azure Blob Storage", func() error { return verifyAzureAccess(cmd.Context()) }})
+	}
+
+	errs := make([]error, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check verifyCheck) {
+			defer wg.Done()
+			errs[i] = check.run()
+		}(i, check)
+	}
+	wg.Wait()
+
+	if githubReport.Len() > 0 {
+		fmt.Fprint(cmd.OutOrStdout(), githubReport.String())
+	}
+
+	var failed []string
+	for i, check := range checks {
+		if errs[i] != nil {
+			ghlog.Logger.Error("✗ "+check.name+" verification failed", zap.Error(errs[i]))
+			failed = append(failed, check.name)
+			continue
 		}
+		ghlog.Logger.Info("✓ " + check.name + " verified")
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("verification failed for: %s", strings.Join(failed, ", "))
 	}
 
 	ghlog.Logger.Info("✓ All configurations and credentials verified successfully!")
 	return nil
 }
 
-func VerifyRequiredEnvVars() error {
-	required := []struct {
-		name  string
-		value string
-	}{
-		{"GITHUB_PAT", os.Getenv("GITHUB_PAT")},
-	}
+// remediation is a row of the table printed when a credential is missing a
+// permission.
+type remediation struct {
+	check  string
+	status string
+	fix    string
+}
+
+func verifyGitHubPAT(ctx context.Context, org string, report io.Writer) error {
 
-	awsRequired := []struct {
-		name  string
-		value string
-	}{
-		{"AWS_ACCESS_KEY_ID", os.Getenv("AWS_ACCESS_KEY_ID")},
-		{"AWS_SECRET_ACCESS_KEY", os.Getenv("AWS_SECRET_ACCESS_KEY")},
-		{"AWS_REGION", os.Getenv("AWS_REGION")},
+	if appID := config.Lookup(config.GitHubAppID); appID != "" {
+		ghlog.Logger.Info("Verifying GitHub App installation", zap.String("app_id", appID))
+	} else {
+		ghlog.Logger.Info("Verifying GITHUB_PAT")
+		if config.Lookup(config.GitHubToken) == "" {
+			return fmt.Errorf("GITHUB_PAT environment variable is not set")
+		}
+
+		ghlog.Logger.Info("GITHUB_PAT environment variable is set")
+		ghlog.Logger.Info("Checking GITHUB_PAT credentials and scope")
 	}
 
-	azureRequired := []struct {
-		name  string
-		value string
-	}{
-		{"AZURE_STORAGE_ACCOUNT", os.Getenv("AZURE_STORAGE_ACCOUNT")},
-		{"AZURE_STORAGE_ACCESS_KEY", os.Getenv("AZURE_STORAGE_ACCESS_KEY")},
+	githubClient, err := clients.DefaultGitHubClient()
+	if err != nil {
+		return err
 	}
+	if _, err := githubClient.GitHubAuth(); err != nil {
+		ghlog.Logger.Debug("GitHub authentication failed", zap.Error(err))
 
-	var missingAWS, missingAzure, missing []string
-	for _, r := range awsRequired {
-		if r.value == "" {
-			missingAWS = append(missingAWS, r.name)
-		}
+		return fmt.Errorf("GitHub authentication failed")
 	}
 
-	for _, r := range azureRequired {
-		if r.value == "" {
-			missingAzure = append(missingAzure, r.name)
-		}
+	permissions, err := github.InspectToken(ctx, org)
+	if err != nil {
+		return fmt.Errorf("GitHub authentication failed: %w", err)
 	}
 
-	for _, r := range required {
-		if r.value == "" {
-			missing = append(missing, r.name)
+	var rows []remediation
+	blocking := false
+
+	if permissions.App {
+		rows = append(rows, remediation{
+			check:  "app permissions",
+			status: "not reported",
+			fix:    "Installation tokens do not report scopes. Make sure the app can administer the organization, repositories and workflows",
+		})
+	} else if permissions.FineGrained {
+		rows = append(rows, remediation{
+			check:  "token scopes",
+			status: "not reported",
+			fix:    fmt.Sprintf("Fine-grained tokens do not report scopes. Make sure the token can administer the organization, repositories and workflows, or use a classic PAT with the %s scopes", strings.Join(github.RequiredScopes, ", ")),
+		})
+	} else {
+		for _, scope := range permissions.MissingScopes() {
+			blocking = true
+			rows = append(rows, remediation{
+				check:  "scope " + scope,
+				status: "missing",
+				fix:    fmt.Sprintf("Regenerate the PAT with the %s scope", scope),
+			})
 		}
 	}
 
-	// Ensure at least one provider's full set of credentials is present
-	if len(missingAWS) > 0 && len(missingAzure) > 0 {
-		// Check if the user has already set USE_GITHUB_STORAGE to true
-		if os.Getenv("USE_GITHUB_STORAGE") == "true" {
-			ghlog.Logger.Info("Using GitHub blob storage for migration (from environment variable)")
-			return nil
+	if org != "" {
+		switch {
+		case permissions.OrgRole == "admin":
+		case permissions.CanMigrate:
+		case permissions.App:
+			blocking = true
+			rows = append(rows, remediation{
+				check:  "migrations",
+				status: "not allowed",
+				fix:    fmt.Sprintf("Give the installation of the app in %s the organization administration permission", org),
+			})
+		case permissions.OrgRole == "":
+			blocking = true
+			rows = append(rows, remediation{
+				check:  "organization access",
+				status: "not a member",
+				fix:    fmt.Sprintf("Add %s to %s as an owner, or ask an owner to run: %s", permissions.Login, org, grantMigratorRoleCommand(org, permissions.Login)),
+			})
+		default:
+			blocking = true
+			rows = append(rows, remediation{
+				check:  "migrator role",
+				status: "missing",
+				fix:    fmt.Sprintf("Ask an owner of %s to run: %s", org, grantMigratorRoleCommand(org, permissions.Login)),
+			})
 		}
+	}
 
-		// Prompt the user to ask if using GitHub blob storage
-		reader := bufio.NewReader(os.Stdin)
-		fmt.Print("Do you want to use GitHub blob storage instead of AWS/Azure? (y/n): ")
-		text, err := reader.ReadString('\n')
-		if err != nil {
-			ghlog.Logger.Error("Error reading user input", zap.Error(err))
-			return fmt.Errorf("error reading user input: %w", err)
-		}
-		text = strings.TrimSpace(text)
-
-		if strings.ToLower(text) == "y" || strings.ToLower(text) == "yes" {
-			ghlog.Logger.Info("Using GitHub blob storage for migration")
-			// Set flag or environment variable to indicate GitHub blob storage usage
-			if err := os.Setenv("USE_GITHUB_STORAGE", "true"); err != nil {
-				ghlog.Logger.Error("Failed to set USE_GITHUB_STORAGE environment variable", zap.Error(err))
-				return fmt.Errorf("failed to set USE_GITHUB_STORAGE environment variable: %w", err)
-			}
-			return nil
-		} else {
-			ghlog.Logger.Error(
-				"Missing required environment variables. You must provide either AWS or Azure blob storage credentials",
-				zap.Strings("missing_aws", missingAWS),
-				zap.Strings("missing_azure", missingAzure),
-			)
-			return fmt.Errorf("missing required cloud storage credentials")
+	if len(rows) > 0 {
+		if err := writeRemediationTable(report, rows); err != nil {
+			return err
 		}
 	}
 
-	if len(missing) > 0 {
-		ghlog.Logger.Error("Missing required environment variables",
-			zap.Strings("missing", missing))
-		return fmt.Errorf("missing required environment variables")
+	if blocking {
+		return fmt.Errorf("GitHub token for %s is missing required permissions", permissions.Login)
 	}
 
-	ghlog.Logger.Info("All required environment variables are set")
+	ghlog.Logger.Info("GitHub credentials verified",
+		zap.String("user", permissions.Login),
+		zap.Strings("scopes", permissions.Scopes),
+		zap.String("org_role", permissions.OrgRole))
 	return nil
 }
 
-func verifyGitHubPAT() error {
+func writeRemediationTable(w io.Writer, rows []remediation) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "CHECK\tSTATUS\tREMEDIATION"); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\n", r.check, r.status, r.fix); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
 
-	ghlog.Logger.Info("Verifying GITHUB_PAT")
-	githubPAT := os.Getenv("GITHUB_PAT")
+func verifyGitLab(ctx context.Context, projects []string) error {
+	ghlog.Logger.Info("Verifying GITLAB_PAT")
 
-	if githubPAT == "" {
-		return fmt.Errorf("GITHUB_PAT environment variable is not set")
+	client, err := gitlabClientFromEnv()
+	if err != nil {
+		return err
 	}
 
-	ghlog.Logger.Info("GITHUB_PAT environment variable is set")
-	ghlog.Logger.Info("Checking GITHUB_PAT credentials and scope")
+	info, err := gl.VerifyToken(ctx, client, projects)
+	if err != nil {
+		return err
+	}
 
-	githubClient := clients.NewGitHubClient(githubPAT)
-	if _, err := githubClient.GitHubAuth(); err != nil {
-		ghlog.Logger.Debug("GitHub authentication failed", zap.Error(err))
+	ghlog.Logger.Info("GitLab credentials verified",
+		zap.String("user", info.Username),
+		zap.Bool("admin", info.IsAdmin),
+		zap.Strings("scopes", info.Scopes),
+		zap.Int("projects", len(projects)))
+	return nil
+}
 
-		return fmt.Errorf("GitHub authentication failed")
+// verifyAwsAccess checks that the AWS credentials are set and accepted by S3.
+func verifyAwsAccess(ctx context.Context) error {
+	if err := verifyAws(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	if _, err := awsUtils.NewS3Manager(ctx, clients.NewAwsClient(), config.Lookup(config.AWSBucket)); err != nil {
+		return err
 	}
 
-	ghlog.Logger.Info("GitHub credentials verified")
+	ghlog.Logger.Info("AWS credentials verified")
 	return nil
 }
 
 func verifyAws() error {
 	ghlog.Logger.Info("Verifying AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, and AWS_REGION")
 
-	awsAccessKey := os.Getenv("AWS_ACCESS_KEY_ID")
-	awsSecretKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
-	awsRegion := os.Getenv("AWS_REGION")
+	awsAccessKey := config.Lookup(config.AWSAccessKeyID)
+	awsSecretKey := config.Lookup(config.AWSSecretAccessKey)
+	awsRegion := config.Lookup(config.AWSRegion)
 
 	if awsAccessKey == "" || awsSecretKey == "" || awsRegion == "" {
 		return fmt.Errorf("AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, and AWS_REGION environment variables must be set")
@@ -184,11 +294,35 @@ func verifyAws() error {
 	return nil
 }
 
+// verifyAzureAccess checks that the Azure credentials are set and accepted by
+// the storage account, and that the container named by the bucket setting, if
+// any, exists.
+func verifyAzureAccess(ctx context.Context) error {
+	if err := verifyAzure(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	if err := azure.CheckAccountAccess(ctx, &azure.AzureOptions{
+		StorageAccount:   config.Lookup(config.AzureStorageAccount),
+		StorageAccessKey: config.Lookup(config.AzureStorageAccessKey),
+		ContainerName:    config.Lookup(config.AWSBucket),
+		BlobEndpoint:     config.Lookup(config.AzureBlobEndpoint),
+	}); err != nil {
+		return err
+	}
+
+	ghlog.Logger.Info("Azure credentials verified")
+	return nil
+}
+
 func verifyAzure() error {
 	ghlog.Logger.Info("Verifying AZURE_STORAGE_ACCOUNT and AZURE_STORAGE_ACCESS_KEY")
 
-	azureStorageAccount := os.Getenv("AZURE_STORAGE_ACCOUNT")
-	azureStorageAccessKey := os.Getenv("AZURE_STORAGE_ACCESS_KEY")
+	azureStorageAccount := config.Lookup(config.AzureStorageAccount)
+	azureStorageAccessKey := config.Lookup(config.AzureStorageAccessKey)
 
 	if azureStorageAccount == "" || azureStorageAccessKey == "" {
 		return fmt.Errorf("AZURE_STORAGE_ACCOUNT and AZURE_STORAGE_ACCESS_KEY environment variables must be set")
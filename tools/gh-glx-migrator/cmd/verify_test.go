@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ps-resources/gh-glx-migrator/internal/testutil"
+)
+
+// newVerifyFixture starts a fake GitHub and a fake GitLab that verify checks,
+// with no storage credentials in the environment.
+func newVerifyFixture(t *testing.T) (*testutil.GitHub, *testutil.GitLab) {
+	t.Helper()
+	testutil.SetEnv(t, map[string]string{
+		"AWS_ACCESS_KEY_ID":        "",
+		"AWS_SECRET_ACCESS_KEY":    "",
+		"AZURE_STORAGE_ACCOUNT":    "",
+		"AZURE_STORAGE_ACCESS_KEY": "",
+	})
+	gh := newGitHubFixture(t)
+	gl := testutil.NewGitLab(t)
+	gl.Projects = []string{"group/api"}
+	testutil.SetEnv(t, gl.Env())
+	return gh, gl
+}
+
+func TestVerify(t *testing.T) {
+	gh, _ := newVerifyFixture(t)
+
+	err := runCommand(context.Background(), VerifyCmd(), &bytes.Buffer{}, "--storage", "github", "--org", gh.Org.Login, "--gl-project", "group/api")
+	if err != nil {
+		t.Fatalf("verify error = %v", err)
+	}
+}
+
+func TestVerifyGitLab(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(gl *testutil.GitLab)
+		project string
+		wantErr string
+	}{
+		{name: "scoped token", project: "group/api"},
+		{name: "administrator without scopes", setup: func(gl *testutil.GitLab) { gl.IsAdmin, gl.Scopes = true, nil }, project: "group/api"},
+		{name: "missing scope", setup: func(gl *testutil.GitLab) { gl.Scopes = []string{"read_api"} }, project: "group/api", wantErr: "read_repository"},
+		{name: "invisible project", project: "group/secret", wantErr: "group/secret"},
+		{name: "invalid token", setup: func(gl *testutil.GitLab) { gl.Token = "glpat-other" }, project: "group/api", wantErr: "401"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, gl := newVerifyFixture(t)
+			if tt.setup != nil {
+				tt.setup(gl)
+			}
+
+			err := verifyGitLab(context.Background(), []string{tt.project})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("verifyGitLab() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("verifyGitLab() error = %v, want it to mention %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyReportsEveryProvider(t *testing.T) {
+	gh, gl := newVerifyFixture(t)
+	gh.Scopes = []string{"repo"}
+	gl.Scopes = []string{"read_api"}
+
+	err := runCommand(context.Background(), VerifyCmd(), &bytes.Buffer{}, "--storage", "github", "--org", gh.Org.Login)
+	if err == nil || !strings.Contains(err.Error(), "verification failed for: GitHub, GitLab") {
+		t.Errorf("verify error = %v, want both the GitHub and the GitLab check to fail", err)
+	}
+}
+
+func TestVerifyGitHubRemediation(t *testing.T) {
+	gh, _ := newVerifyFixture(t)
+	gh.OrgRole = "member"
+	gh.Scopes = []string{"admin:org", "repo"}
+
+	var out bytes.Buffer
+	err := runCommand(context.Background(), VerifyCmd(), &out, "--storage", "github", "--org", gh.Org.Login)
+	if err == nil || !strings.Contains(err.Error(), "GitHub") {
+		t.Errorf("verify error = %v, want the GitHub check to fail", err)
+	}
+	table := out.String()
+	for _, want := range []string{
+		"scope workflow",
+		"migrator role",
+		grantMigratorRoleCommand(gh.Org.Login, gh.Login),
+	} {
+		if !strings.Contains(table, want) {
+			t.Errorf("remediation table does not mention %q:\n%s", want, table)
+		}
+	}
+
+	// The migrator role is enough to run migrations without being an owner.
+	gh.Scopes = []string{"admin:org", "repo", "workflow"}
+	gh.Migrators = []string{gh.Login}
+	out.Reset()
+	if err := runCommand(context.Background(), VerifyCmd(), &out, "--storage", "github", "--org", gh.Org.Login); err != nil {
+		t.Errorf("verify of a migrator error = %v\n%s", err, out.String())
+	}
+}
+
+func TestVerifyAzure(t *testing.T) {
+	gh, _ := newVerifyFixture(t)
+	azure := testutil.NewAzure(t, "archives")
+	testutil.SetEnv(t, azure.Env())
+	t.Setenv("AWS_BUCKET", "")
+	verify := func() error {
+		return runCommand(context.Background(), VerifyCmd(), &bytes.Buffer{}, "--storage", "azure", "--org", gh.Org.Login, "--gl-project", "group/api")
+	}
+
+	if err := verify(); err != nil {
+		t.Fatalf("verify error = %v", err)
+	}
+	t.Setenv("AWS_BUCKET", "archives")
+	if err := verify(); err != nil {
+		t.Fatalf("verify with a container error = %v", err)
+	}
+
+	t.Setenv("AWS_BUCKET", "missing")
+	if err := verify(); err == nil || !strings.Contains(err.Error(), "verification failed for: Azure Blob Storage") {
+		t.Errorf("verify of a missing container error = %v, want the Azure check to fail", err)
+	}
+
+	// The fake rejects requests that are not signed for its account.
+	t.Setenv("AWS_BUCKET", "")
+	t.Setenv("AZURE_STORAGE_ACCOUNT", "otheraccount")
+	if err := verify(); err == nil || !strings.Contains(err.Error(), "verification failed for: Azure Blob Storage") {
+		t.Errorf("verify with credentials of another account error = %v, want the Azure check to fail", err)
+	}
+}
@@ -43,7 +43,7 @@ func NewS3Manager(ctx context.Context, awsClient clients.S3Client, bucket string
 		return nil, fmt.Errorf("AWS client is nil")
 	}
 
-	s3Client, err := awsClient.GetS3Client()
+	s3Client, err := awsClient.GetS3Client(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create S3 client: %w", err)
 	}
@@ -140,6 +140,11 @@ func (m *S3Manager) abortMultipartUpload(ctx context.Context, blobName string, u
 		return
 	}
 
+	// The upload may have failed because ctx was cancelled, so the abort gets
+	// a context of its own.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+	defer cancel()
+
 	_, err := m.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
 		Bucket:   aws.String(m.bucketName),
 		Key:      aws.String(blobName),
@@ -172,6 +177,21 @@ func (m *S3Manager) DeleteObject(ctx context.Context, blobName string) error {
 	return nil
 }
 
+// CheckBucketAccess verifies that the bucket exists and that the credentials
+// may access it, without reading or writing any object.
+func (m *S3Manager) CheckBucketAccess(ctx context.Context) error {
+	operation := "HeadBucket"
+
+	_, err := m.client.HeadBucket(ctx, &s3.HeadBucketInput{
+		Bucket: aws.String(m.bucketName),
+	})
+	if err != nil {
+		return logAndReturnError(operation, m.bucketName, "", fmt.Errorf("failed to access bucket: %w", err))
+	}
+
+	return nil
+}
+
 func (m *S3Manager) multipartUpload(ctx context.Context, blobName string, reader io.ReadSeeker, size int64) error {
 	operation := "MultipartUpload"
 	ghlog.Logger.Info("Starting multipart upload",
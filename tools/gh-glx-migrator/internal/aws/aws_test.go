@@ -0,0 +1,86 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ps-resources/gh-glx-migrator/internal/clients"
+	"github.com/ps-resources/gh-glx-migrator/internal/testutil"
+	ghlog "github.com/ps-resources/gh-glx-migrator/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	ghlog.InitLogger()
+	os.Exit(m.Run())
+}
+
+func TestS3ManagerUpload(t *testing.T) {
+	s3 := testutil.NewS3(t, "archives")
+	testutil.SetEnv(t, s3.Env())
+
+	archive := bytes.Repeat([]byte("gl-exporter "), 10)
+	path := filepath.Join(t.TempDir(), "archive.tar.gz")
+	if err := os.WriteFile(path, archive, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		threshold int64
+	}{
+		{name: "simple", threshold: DefaultMultipartThreshold},
+		{name: "multipart", threshold: 16},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			manager, err := NewS3Manager(ctx, clients.NewAwsClient(), "archives")
+			if err != nil {
+				t.Fatalf("NewS3Manager() error = %v", err)
+			}
+			manager.SetPartSize(32)
+			manager.SetMultipartThreshold(tt.threshold)
+
+			file, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+
+			if err := manager.Upload(ctx, tt.name+".tar.gz", file); err != nil {
+				t.Fatalf("Upload() error = %v", err)
+			}
+			uploaded, ok := s3.Object("archives", tt.name+".tar.gz")
+			if !ok {
+				t.Fatalf("object %s.tar.gz was not uploaded", tt.name)
+			}
+			if !bytes.Equal(uploaded, archive) {
+				t.Errorf("uploaded object = %q, want %q", uploaded, archive)
+			}
+
+			if err := manager.DeleteObject(ctx, tt.name+".tar.gz"); err != nil {
+				t.Fatalf("DeleteObject() error = %v", err)
+			}
+			if _, ok := s3.Object("archives", tt.name+".tar.gz"); ok {
+				t.Error("object still exists after DeleteObject()")
+			}
+		})
+	}
+}
+
+func TestCheckBucketAccess(t *testing.T) {
+	s3 := testutil.NewS3(t, "archives")
+	testutil.SetEnv(t, s3.Env())
+
+	ctx := context.Background()
+	manager, err := NewS3Manager(ctx, clients.NewAwsClient(), "missing")
+	if err != nil {
+		t.Fatalf("NewS3Manager() error = %v", err)
+	}
+	if err := manager.CheckBucketAccess(ctx); err == nil {
+		t.Error("CheckBucketAccess() returned no error for a missing bucket")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -25,6 +26,22 @@ type AzureOptions struct {
 	ContainerName    string
 	BlobName         string
 	ArchiveFilePath  string
+	// BlobEndpoint replaces https://<account>.blob.core.windows.net/, e.g.
+	// with the URL of an Azurite emulator.
+	BlobEndpoint string
+}
+
+// serviceURL returns the URL of the blob service of the storage account.
+func serviceURL(opts *AzureOptions) string {
+	if opts.BlobEndpoint != "" {
+		return strings.TrimSuffix(opts.BlobEndpoint, "/") + "/"
+	}
+	return fmt.Sprintf("https://%s.blob.core.windows.net/", opts.StorageAccount)
+}
+
+// BlobURL returns the URL of the blob, without a SAS token.
+func BlobURL(opts *AzureOptions) string {
+	return serviceURL(opts) + opts.ContainerName + "/" + opts.BlobName
 }
 
 // Singleton credential with lazy initialization
@@ -88,8 +105,10 @@ func calculateOptimalBlockSize(fileSize int64) (int64, int) {
 	return blockSize, parallelism
 }
 
-// UploadToAzureBlob uploads a file to Azure Blob Storage and returns a presigned URL
-func UploadToAzureBlob(opts *AzureOptions, duration time.Duration) (string, error) {
+// UploadToAzureBlob uploads a file to Azure Blob Storage and returns a presigned URL.
+// If ctx is cancelled the upload stops before the block list is committed, and
+// Azure discards the uncommitted blocks.
+func UploadToAzureBlob(ctx context.Context, opts *AzureOptions, duration time.Duration) (string, error) {
 	logger.Logger.Info("Starting upload to Azure Blob Storage",
 		zap.String("container", opts.ContainerName),
 		zap.String("blob", opts.BlobName))
@@ -133,7 +152,7 @@ func UploadToAzureBlob(opts *AzureOptions, duration time.Duration) (string, erro
 		zap.Int64("block_size", blockSize),
 		zap.Int("parallelism", parallelism))
 
-	var account = fmt.Sprintf("https://%s.blob.core.windows.net/", opts.StorageAccount)
+	var account = serviceURL(opts)
 	var containerName = opts.ContainerName
 	var blobName = opts.BlobName
 
@@ -150,13 +169,13 @@ func UploadToAzureBlob(opts *AzureOptions, duration time.Duration) (string, erro
 	}
 
 	// upload the file to the specified container with the specified blob name
-	_, err = client.UploadFile(context.TODO(), containerName, blobName, file,
+	_, err = client.UploadFile(ctx, containerName, blobName, file,
 		&azblob.UploadFileOptions{
 			BlockSize:   blockSize,           // 8 MiB
 			Concurrency: uint16(parallelism), // Higher concurrency for large files
 		})
 	if err != nil {
-		return "", fmt.Errorf("failed to upload file: %v", err)
+		return "", fmt.Errorf("failed to upload file: %w", err)
 	}
 
 	logger.Logger.Info("Upload completed successfully")
@@ -191,17 +210,66 @@ func GenerateSasUrl(opts *AzureOptions, duration time.Duration) (string, error)
 	}
 
 	// Build the SAS URL
-	sasURL := fmt.Sprintf("https://%s.blob.core.windows.net/%s/%s?%s", opts.StorageAccount, opts.ContainerName, opts.BlobName, sasQueryParams.Encode())
+	sasURL := BlobURL(opts) + "?" + sasQueryParams.Encode()
 
 	return sasURL, nil
 }
 
-func DeleteBlob(opts *AzureOptions) error {
+// CheckContainerAccess verifies that the container exists and that the shared
+// key may access it, without reading or writing any blob.
+func CheckContainerAccess(ctx context.Context, opts *AzureOptions) error {
+	var account = serviceURL(opts)
+
+	credential, err := getCredential(opts.StorageAccount, opts.StorageAccessKey)
+	if err != nil {
+		return fmt.Errorf("failed to create shared key credential: %v", err)
+	}
+
+	client, err := azblob.NewClientWithSharedKeyCredential(account, credential, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create blob client: %v", err)
+	}
+
+	_, err = client.ServiceClient().NewContainerClient(opts.ContainerName).GetProperties(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to access container %s: %w", opts.ContainerName, err)
+	}
+
+	return nil
+}
+
+// CheckAccountAccess verifies that the shared key is accepted by the storage
+// account, by listing its first container. It checks the container instead
+// when opts names one.
+func CheckAccountAccess(ctx context.Context, opts *AzureOptions) error {
+	if opts.ContainerName != "" {
+		return CheckContainerAccess(ctx, opts)
+	}
+
+	credential, err := getCredential(opts.StorageAccount, opts.StorageAccessKey)
+	if err != nil {
+		return fmt.Errorf("failed to create shared key credential: %v", err)
+	}
+
+	client, err := azblob.NewClientWithSharedKeyCredential(serviceURL(opts), credential, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create blob client: %v", err)
+	}
+
+	pager := client.NewListContainersPager(&azblob.ListContainersOptions{MaxResults: to.Ptr[int32](1)})
+	if _, err := pager.NextPage(ctx); err != nil {
+		return fmt.Errorf("failed to access storage account %s: %w", opts.StorageAccount, err)
+	}
+
+	return nil
+}
+
+func DeleteBlob(ctx context.Context, opts *AzureOptions) error {
 	logger.Logger.Info("Deleting blob from Azure Blob Storage",
 		zap.String("container", opts.ContainerName),
 		zap.String("blob", opts.BlobName))
 
-	var account = fmt.Sprintf("https://%s.blob.core.windows.net/", opts.StorageAccount)
+	var account = serviceURL(opts)
 
 	credential, err := getCredential(opts.StorageAccount, opts.StorageAccessKey)
 	if err != nil {
@@ -213,11 +281,11 @@ func DeleteBlob(opts *AzureOptions) error {
 		return fmt.Errorf("failed to create blob client: %v", err)
 	}
 
-	_, err = client.DeleteBlob(context.TODO(), opts.ContainerName, opts.BlobName, &blob.DeleteOptions{
+	_, err = client.DeleteBlob(ctx, opts.ContainerName, opts.BlobName, &blob.DeleteOptions{
 		DeleteSnapshots: to.Ptr(blob.DeleteSnapshotsOptionTypeInclude),
 	})
 	if err != nil {
-		return fmt.Errorf("failed to delete blob: %v", err)
+		return fmt.Errorf("failed to delete blob: %w", err)
 	}
 
 	// log response
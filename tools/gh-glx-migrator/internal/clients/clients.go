@@ -4,17 +4,24 @@ import (
   "context"
   "errors"
   "fmt"
+  "net/http"
+  "strings"
 
   "github.com/aws/aws-sdk-go-v2/config"
+  "github.com/aws/aws-sdk-go-v2/credentials"
   "github.com/aws/aws-sdk-go-v2/service/s3"
   "github.com/google/go-github/v69/github"
+  "github.com/hashicorp/go-retryablehttp"
   gitlab "gitlab.com/gitlab-org/api/client-go"
 
+	glxconfig "github.com/ps-resources/gh-glx-migrator/internal/config"
+	"github.com/ps-resources/gh-glx-migrator/internal/failure"
+	"github.com/ps-resources/gh-glx-migrator/internal/telemetry"
 	"github.com/ps-resources/gh-glx-migrator/pkg/logger"
 )
 
 type S3Client interface {
-  GetS3Client() (*s3.Client, error)
+  GetS3Client(ctx context.Context) (*s3.Client, error)
 }
 
 type GitLabClient interface {
@@ -34,40 +41,91 @@ type GitlabClientImpl struct {
 }
 
 type GitHubClientImpl struct {
-  githubPAT string
+  credential GitHubCredential
 }
 
 func NewAwsClient() S3Client {
   return &AwsClient{}
 }
 
+// NewGitlabClient returns a GitLab client for the given API endpoint, such as
+// gitlab.com/api/v4. The https scheme is assumed when none is given.
+func NewGitlabClient(apiEndpoint, pat string) GitLabClient {
+  if apiEndpoint != "" && !strings.HasPrefix(apiEndpoint, "http://") && !strings.HasPrefix(apiEndpoint, "https://") {
+    apiEndpoint = "https://" + apiEndpoint
+  }
+  return &GitlabClientImpl{
+    gitlabApiEndpoint: apiEndpoint,
+    gitlabPAT:         pat,
+  }
+}
+
 func NewGitHubClient(pat string) GitHubClient {
+  return NewGitHubClientWithCredential(PersonalAccessToken(pat))
+}
+
+// NewGitHubClientWithCredential returns a GitHub client whose requests are all
+// authenticated with the current token of credential.
+func NewGitHubClientWithCredential(credential GitHubCredential) GitHubClient {
   return &GitHubClientImpl{
-    githubPAT: pat,
+    credential: credential,
   }
 }
 
-func (a *AwsClient) GetS3Client() (*s3.Client, error) {
-  cfg, err := config.LoadDefaultConfig(context.TODO())
+// GetS3Client loads the default AWS configuration. Region and static
+// credentials set in the profile take the place of the AWS environment
+// variables, which the SDK reads itself. An endpoint URL selects an
+// S3-compatible service, addressed with path-style URLs.
+func (a *AwsClient) GetS3Client(ctx context.Context) (*s3.Client, error) {
+  var opts []func(*config.LoadOptions) error
+  if region := glxconfig.Lookup(glxconfig.AWSRegion); region != "" {
+    opts = append(opts, config.WithRegion(region))
+  }
+  accessKeyID := glxconfig.Lookup(glxconfig.AWSAccessKeyID)
+  secretAccessKey := glxconfig.Lookup(glxconfig.AWSSecretAccessKey)
+  if accessKeyID != "" && secretAccessKey != "" {
+    opts = append(opts, config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
+      accessKeyID, secretAccessKey, glxconfig.Lookup(glxconfig.AWSSessionToken))))
+  }
+
+  cfg, err := config.LoadDefaultConfig(ctx, opts...)
   if err != nil {
     return nil, err
   }
-  return s3.NewFromConfig(cfg), nil
+  endpointURL := glxconfig.Lookup(glxconfig.AWSEndpointURL)
+  return s3.NewFromConfig(cfg, func(o *s3.Options) {
+    if endpointURL != "" {
+      o.BaseEndpoint = &endpointURL
+      o.UsePathStyle = true
+    }
+  }), nil
 }
 
 func (g *GitlabClientImpl) GitlabAuth() (*gitlab.Client, error) {
   if g.gitlabPAT == "" {
     return nil, errors.New("GitLab PAT is required")
   }
-  return gitlab.NewOAuthClient(g.gitlabPAT, gitlab.WithBaseURL(g.gitlabApiEndpoint))
+  return gitlab.NewClient(g.gitlabPAT,
+    gitlab.WithBaseURL(g.gitlabApiEndpoint),
+    gitlab.WithRequestLogHook(countGitlabRetries))
+}
+
+// countGitlabRetries is called before every attempt of a GitLab request; any
+// attempt after the first is a retry.
+func countGitlabRetries(_ retryablehttp.Logger, _ *http.Request, attempt int) {
+  if attempt > 0 {
+    telemetry.RecordRetry("gitlab")
+  }
 }
 
 func (g *GitHubClientImpl) GitHubAuth() (*github.Client, error) {
-  if g.githubPAT == "" {
+  if pat, ok := g.credential.(PersonalAccessToken); ok && pat == "" {
     logger.Logger.Error("GitHub PAT is not set")
-    return nil, fmt.Errorf("GITHUB_PAT environment variable is not set")
+    return nil, failure.Auth(fmt.Errorf("GITHUB_PAT environment variable is not set"))
   }
-  client := github.NewClient(nil).WithAuthToken(g.githubPAT)
+  client := github.NewClient(&http.Client{
+    Transport: &credentialTransport{credential: g.credential, base: http.DefaultTransport},
+  })
   if client == nil {
     logger.Logger.Error("Failed to create GitHub client")
     return nil, fmt.Errorf("failed to initialize GitHub client")
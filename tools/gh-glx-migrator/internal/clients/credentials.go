@@ -0,0 +1,289 @@
+package clients
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ps-resources/gh-glx-migrator/internal/config"
+	"github.com/ps-resources/gh-glx-migrator/internal/failure"
+	"github.com/ps-resources/gh-glx-migrator/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// GitHubCredential supplies the token requests to the target GitHub are
+// authenticated with. Token is called for every request, so credentials that
+// expire can be refreshed.
+type GitHubCredential interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// PersonalAccessToken is a GitHubCredential for a personal access token.
+type PersonalAccessToken string
+
+func (t PersonalAccessToken) Token(context.Context) (string, error) {
+	if t == "" {
+		return "", fmt.Errorf("GITHUB_PAT environment variable is not set")
+	}
+	return string(t), nil
+}
+
+// appTokenRefreshMargin is how long before it expires an installation token
+// is replaced.
+const appTokenRefreshMargin = 5 * time.Minute
+
+// AppInstallation is a GitHubCredential that mints installation access tokens
+// for a GitHub App, and mints a new one shortly before the current one
+// expires.
+type AppInstallation struct {
+	appID          string
+	privateKey     *rsa.PrivateKey
+	installationID int64
+	org            string
+	apiEndpoint    string
+	httpClient     *http.Client
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// NewAppInstallation returns the credential of the installation of a GitHub
+// App. privateKey is the PEM encoded private key of the app, or the path of
+// a file holding it. When installationID is empty the installation of the app
+// in org is looked up.
+func NewAppInstallation(appID, privateKey, installationID, org, apiEndpoint string) (*AppInstallation, error) {
+	if !strings.Contains(privateKey, "-----BEGIN") {
+		data, err := os.ReadFile(privateKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read GitHub App private key: %v", err)
+		}
+		privateKey = string(data)
+	}
+
+	block, _ := pem.Decode([]byte(privateKey))
+	if block == nil {
+		return nil, fmt.Errorf("GitHub App private key is not PEM encoded")
+	}
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		parsed, pkcs8Err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		rsaKey, ok := parsed.(*rsa.PrivateKey)
+		if pkcs8Err != nil || !ok {
+			return nil, fmt.Errorf("failed to parse GitHub App private key: %v", err)
+		}
+		key = rsaKey
+	}
+
+	app := &AppInstallation{
+		appID:       appID,
+		privateKey:  key,
+		org:         org,
+		apiEndpoint: apiEndpoint,
+		httpClient:  &http.Client{Timeout: 30 * time.Second},
+	}
+	if app.apiEndpoint == "" {
+		app.apiEndpoint = "api.github.com"
+	}
+	if installationID != "" {
+		if app.installationID, err = strconv.ParseInt(installationID, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid GitHub App installation ID %q: %v", installationID, err)
+		}
+	} else if org == "" {
+		return nil, fmt.Errorf("GH_APP_INSTALLATION_ID or GITHUB_ORG is required to authenticate as a GitHub App")
+	}
+	return app, nil
+}
+
+// Token returns the current installation token, minting a new one when there
+// is none or it expires within appTokenRefreshMargin.
+func (a *AppInstallation) Token(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token != "" && time.Until(a.expiresAt) > appTokenRefreshMargin {
+		return a.token, nil
+	}
+
+	jwt, err := a.jwt()
+	if err != nil {
+		return "", err
+	}
+
+	if a.installationID == 0 {
+		var installation struct {
+			ID int64 `json:"id"`
+		}
+		if err := a.call(ctx, "GET", fmt.Sprintf("/orgs/%s/installation", a.org), jwt, http.StatusOK, &installation); err != nil {
+			return "", fmt.Errorf("failed to find the installation of GitHub App %s in %s: %v", a.appID, a.org, err)
+		}
+		a.installationID = installation.ID
+	}
+
+	var minted struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := a.call(ctx, "POST", fmt.Sprintf("/app/installations/%d/access_tokens", a.installationID), jwt, http.StatusCreated, &minted); err != nil {
+		return "", fmt.Errorf("failed to create an installation token for GitHub App %s: %v", a.appID, err)
+	}
+
+	logger.AddSecret(minted.Token)
+	a.token = minted.Token
+	a.expiresAt = minted.ExpiresAt
+	return a.token, nil
+}
+
+// jwt returns a JSON Web Token signed with the private key of the app, valid
+// for the maximum of ten minutes and backdated against clock drift.
+func (a *AppInstallation) jwt() (string, error) {
+	now := time.Now()
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": a.appID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, a.privateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign GitHub App JWT: %v", err)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// call performs a GitHub App API request authenticated with jwt and decodes
+// the response into out.
+func (a *AppInstallation) call(ctx context.Context, method, path, jwt string, wantStatus int, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, fmt.Sprintf("https://%s%s", a.apiEndpoint, path), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	req.Header.Set("User-Agent", "gh-glx-migrator")
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %v", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			logger.Logger.Error("failed to close response body", zap.Error(err))
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %v", err)
+	}
+	if resp.StatusCode != wantStatus {
+		return fmt.Errorf("unexpected response status: %d, body: %s", resp.StatusCode, string(body))
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to decode response: %v", err)
+	}
+	return nil
+}
+
+// GitHubCredentialFromConfig returns the credential for the target GitHub:
+// the installation of the GitHub App when GH_APP_ID is set, and GITHUB_PAT
+// otherwise.
+func GitHubCredentialFromConfig() (GitHubCredential, error) {
+	appID := config.Lookup(config.GitHubAppID)
+	if appID == "" {
+		return PersonalAccessToken(config.Lookup(config.GitHubToken)), nil
+	}
+
+	privateKey := config.Lookup(config.GitHubAppPrivateKey)
+	if privateKey == "" {
+		return nil, fmt.Errorf("GH_APP_PRIVATE_KEY is required when GH_APP_ID is set")
+	}
+	return NewAppInstallation(appID, privateKey,
+		config.Lookup(config.GitHubAppInstallationID),
+		config.Lookup(config.GitHubOrg),
+		config.Lookup(config.GitHubAPIEndpoint))
+}
+
+var (
+	defaultCredentialMu sync.Mutex
+	defaultCredential   GitHubCredential
+)
+
+// DefaultGitHubCredential returns GitHubCredentialFromConfig. The credential
+// is created once and shared, so an app installation token is reused across
+// calls and refreshed during long waits.
+func DefaultGitHubCredential() (GitHubCredential, error) {
+	defaultCredentialMu.Lock()
+	defer defaultCredentialMu.Unlock()
+
+	if defaultCredential == nil {
+		credential, err := GitHubCredentialFromConfig()
+		if err != nil {
+			return nil, failure.Auth(err)
+		}
+		defaultCredential = credential
+	}
+	return defaultCredential, nil
+}
+
+// ResetDefaultGitHubCredential drops the credential shared by
+// DefaultGitHubCredential, so the next call reads the configuration again.
+// Call it whenever the configuration changes, such as when a profile is
+// selected.
+func ResetDefaultGitHubCredential() {
+	defaultCredentialMu.Lock()
+	defer defaultCredentialMu.Unlock()
+	defaultCredential = nil
+}
+
+// DefaultGitHubClient returns a client for the target GitHub authenticated
+// with DefaultGitHubCredential.
+func DefaultGitHubClient() (GitHubClient, error) {
+	credential, err := DefaultGitHubCredential()
+	if err != nil {
+		return nil, err
+	}
+	return NewGitHubClientWithCredential(credential), nil
+}
+
+// credentialTransport authenticates every request with the current token of
+// a GitHubCredential.
+type credentialTransport struct {
+	credential GitHubCredential
+	base       http.RoundTripper
+}
+
+func (t *credentialTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.credential.Token(req.Context())
+	if err != nil {
+		return nil, failure.Auth(err)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,139 @@
+package clients
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppInstallationToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKey := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+
+	minted := 0
+	expiresIn := time.Hour
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		jwt := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		parts := strings.Split(jwt, ".")
+		if len(parts) != 3 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature) != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/orgs/my-org/installation":
+			_ = json.NewEncoder(w).Encode(map[string]int64{"id": 42})
+		case "/app/installations/42/access_tokens":
+			minted++
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"token":      fmt.Sprintf("ghs_token%d", minted),
+				"expires_at": time.Now().Add(expiresIn),
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	app, err := NewAppInstallation("1234", privateKey, "", "my-org", strings.TrimPrefix(server.URL, "https://"))
+	if err != nil {
+		t.Fatalf("NewAppInstallation() error = %v", err)
+	}
+	app.httpClient = server.Client()
+
+	for i := 0; i < 2; i++ {
+		token, err := app.Token(context.Background())
+		if err != nil {
+			t.Fatalf("Token() error = %v", err)
+		}
+		if token != "ghs_token1" {
+			t.Errorf("Token() = %q, want the cached ghs_token1", token)
+		}
+	}
+
+	// A token about to expire is replaced.
+	app.expiresAt = time.Now().Add(time.Minute)
+	token, err := app.Token(context.Background())
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	if token != "ghs_token2" {
+		t.Errorf("Token() = %q, want a new ghs_token2", token)
+	}
+}
+
+func TestGitHubAuthUsesCredential(t *testing.T) {
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	client, err := NewGitHubClientWithCredential(PersonalAccessToken("ghp_test")).GitHubAuth()
+	if err != nil {
+		t.Fatalf("GitHubAuth() error = %v", err)
+	}
+	resp, err := client.Client().Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	if got != "Bearer ghp_test" {
+		t.Errorf("Authorization = %q, want %q", got, "Bearer ghp_test")
+	}
+}
+
+func TestResetDefaultGitHubCredential(t *testing.T) {
+	t.Setenv("GH_APP_ID", "")
+	t.Setenv("GITHUB_PAT", "ghp_first")
+	ResetDefaultGitHubCredential()
+	t.Cleanup(ResetDefaultGitHubCredential)
+
+	token := func() string {
+		t.Helper()
+		credential, err := DefaultGitHubCredential()
+		if err != nil {
+			t.Fatalf("DefaultGitHubCredential() error = %v", err)
+		}
+		token, err := credential.Token(context.Background())
+		if err != nil {
+			t.Fatalf("Token() error = %v", err)
+		}
+		return token
+	}
+
+	if got := token(); got != "ghp_first" {
+		t.Fatalf("Token() = %q, want ghp_first", got)
+	}
+	t.Setenv("GITHUB_PAT", "ghp_second")
+	if got := token(); got != "ghp_first" {
+		t.Errorf("Token() = %q, want the shared ghp_first until the credential is reset", got)
+	}
+	ResetDefaultGitHubCredential()
+	if got := token(); got != "ghp_second" {
+		t.Errorf("Token() after reset = %q, want ghp_second", got)
+	}
+}
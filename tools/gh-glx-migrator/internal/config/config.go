@@ -0,0 +1,461 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ps-resources/gh-glx-migrator/internal/secrets"
+	ghlog "github.com/ps-resources/gh-glx-migrator/pkg/logger"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultProfile is used when neither --profile, GH_GLX_PROFILE nor the
+// current-profile of the file select a profile.
+const DefaultProfile = "default"
+
+// File is the configuration file, ~/.config/gh-glx/config.yaml by default.
+type File struct {
+	CurrentProfile string              `yaml:"current-profile,omitempty"`
+	Profiles       map[string]*Profile `yaml:"profiles,omitempty"`
+}
+
+// Profile holds the source GitLab, target GitHub and storage settings of one
+// environment.
+type Profile struct {
+	GitLab  GitLabProfile  `yaml:"gitlab,omitempty"`
+	GitHub  GitHubProfile  `yaml:"github,omitempty"`
+	GHEC    GHECProfile    `yaml:"ghec,omitempty"`
+	Storage StorageProfile `yaml:"storage,omitempty"`
+	// Notifications receive the lifecycle events of exports and migrations.
+	Notifications []Notification `yaml:"notifications,omitempty"`
+}
+
+type GitLabProfile struct {
+	Host          string `yaml:"host,omitempty"`
+	APIEndpoint   string `yaml:"api-endpoint,omitempty"`
+	Username      string `yaml:"username,omitempty"`
+	Token         string `yaml:"token,omitempty"`
+	ExporterImage string `yaml:"exporter-image,omitempty"`
+}
+
+type GitHubProfile struct {
+	APIEndpoint string `yaml:"api-endpoint,omitempty"`
+	// UploadsEndpoint is the host of GitHub-owned storage uploads.
+	UploadsEndpoint string `yaml:"uploads-endpoint,omitempty"`
+	Org             string `yaml:"org,omitempty"`
+	Token           string `yaml:"token,omitempty"`
+	// The GitHub App settings replace Token when AppID is set.
+	AppID             string `yaml:"app-id,omitempty"`
+	AppPrivateKey     string `yaml:"app-private-key,omitempty"`
+	AppInstallationID string `yaml:"app-installation-id,omitempty"`
+	// UserToken is a user-to-server token of the app, passed to GEI as the
+	// githubPat of a migration when Token is not set.
+	UserToken string `yaml:"user-token,omitempty"`
+}
+
+// GHECProfile is the GitHub Enterprise Cloud organization export-ghec exports
+// from. It is read with the GitHub token.
+type GHECProfile struct {
+	APIEndpoint string `yaml:"api-endpoint,omitempty"`
+}
+
+type StorageProfile struct {
+	AWS   AWSProfile   `yaml:"aws,omitempty"`
+	Azure AzureProfile `yaml:"azure,omitempty"`
+}
+
+type AWSProfile struct {
+	AccessKeyID     string `yaml:"access-key-id,omitempty"`
+	SecretAccessKey string `yaml:"secret-access-key,omitempty"`
+	SessionToken    string `yaml:"session-token,omitempty"`
+	Region          string `yaml:"region,omitempty"`
+	Bucket          string `yaml:"bucket,omitempty"`
+	// EndpointURL replaces the AWS S3 endpoint with an S3-compatible one,
+	// such as MinIO, addressed with path-style URLs.
+	EndpointURL string `yaml:"endpoint-url,omitempty"`
+}
+
+type AzureProfile struct {
+	Account   string `yaml:"account,omitempty"`
+	AccessKey string `yaml:"access-key,omitempty"`
+	// BlobEndpoint replaces https://<account>.blob.core.windows.net/, e.g.
+	// with the URL of an Azurite emulator.
+	BlobEndpoint string `yaml:"blob-endpoint,omitempty"`
+}
+
+// Notification is a sink that lifecycle events are sent to.
+type Notification struct {
+	// Type is webhook, slack, teams or file.
+	Type string `yaml:"type"`
+	// URL is the endpoint of a webhook, slack or teams notification. It may
+	// be a secret reference, since incoming webhook URLs embed a token.
+	URL string `yaml:"url,omitempty"`
+	// Secret is the key webhook requests are signed with. It may be a secret
+	// reference.
+	Secret string `yaml:"secret,omitempty"`
+	// Path is the file a file notification appends events to.
+	Path string `yaml:"path,omitempty"`
+	// Events are the types of the events sent, every event when empty.
+	Events []string `yaml:"events,omitempty"`
+}
+
+// Setting is a value that can be set in a profile and overridden by an
+// environment variable.
+type Setting struct {
+	// Key is the dotted path of the setting in a profile, e.g. github.token.
+	Key string
+	Env string
+	// Aliases are other environment variables read, in order, when Env is
+	// not set.
+	Aliases []string
+	Secret  bool
+	field   func(p *Profile) *string
+}
+
+// Settings of a profile, in the order they are listed by gh glx config view.
+var (
+	GitLabHost          = Setting{Key: "gitlab.host", Env: "GITLAB_HOST", field: func(p *Profile) *string { return &p.GitLab.Host }}
+	GitLabAPIEndpoint   = Setting{Key: "gitlab.api-endpoint", Env: "GITLAB_API_ENDPOINT", field: func(p *Profile) *string { return &p.GitLab.APIEndpoint }}
+	GitLabUsername      = Setting{Key: "gitlab.username", Env: "GITLAB_USERNAME", field: func(p *Profile) *string { return &p.GitLab.Username }}
+	GitLabToken         = Setting{Key: "gitlab.token", Env: "GITLAB_PAT", Secret: true, field: func(p *Profile) *string { return &p.GitLab.Token }}
+	GitLabExporterImage = Setting{Key: "gitlab.exporter-image", Env: "GL_EXPORTER_DOCKER_IMAGE", field: func(p *Profile) *string { return &p.GitLab.ExporterImage }}
+
+	GitHubAPIEndpoint     = Setting{Key: "github.api-endpoint", Env: "GITHUB_API_ENDPOINT", field: func(p *Profile) *string { return &p.GitHub.APIEndpoint }}
+	GitHubUploadsEndpoint = Setting{Key: "github.uploads-endpoint", Env: "GITHUB_UPLOADS_ENDPOINT", field: func(p *Profile) *string { return &p.GitHub.UploadsEndpoint }}
+	GitHubOrg             = Setting{Key: "github.org", Env: "GITHUB_ORG", field: func(p *Profile) *string { return &p.GitHub.Org }}
+	GitHubToken           = Setting{Key: "github.token", Env: "GITHUB_PAT", Aliases: []string{"GITHUB_GHEC_PAT", "GITHUB_TOKEN"}, Secret: true, field: func(p *Profile) *string { return &p.GitHub.Token }}
+
+	GitHubAppID             = Setting{Key: "github.app-id", Env: "GH_APP_ID", field: func(p *Profile) *string { return &p.GitHub.AppID }}
+	GitHubAppPrivateKey     = Setting{Key: "github.app-private-key", Env: "GH_APP_PRIVATE_KEY", Secret: true, field: func(p *Profile) *string { return &p.GitHub.AppPrivateKey }}
+	GitHubAppInstallationID = Setting{Key: "github.app-installation-id", Env: "GH_APP_INSTALLATION_ID", field: func(p *Profile) *string { return &p.GitHub.AppInstallationID }}
+	GitHubUserToken         = Setting{Key: "github.user-token", Env: "GH_USER_TOKEN", Secret: true, field: func(p *Profile) *string { return &p.GitHub.UserToken }}
+
+	GHECAPIEndpoint = Setting{Key: "ghec.api-endpoint", Env: "GITHUB_GHEC_API_ENDPOINT", field: func(p *Profile) *string { return &p.GHEC.APIEndpoint }}
+
+	AWSAccessKeyID     = Setting{Key: "storage.aws.access-key-id", Env: "AWS_ACCESS_KEY_ID", field: func(p *Profile) *string { return &p.Storage.AWS.AccessKeyID }}
+	AWSSecretAccessKey = Setting{Key: "storage.aws.secret-access-key", Env: "AWS_SECRET_ACCESS_KEY", Secret: true, field: func(p *Profile) *string { return &p.Storage.AWS.SecretAccessKey }}
+	AWSSessionToken    = Setting{Key: "storage.aws.session-token", Env: "AWS_SESSION_TOKEN", Secret: true, field: func(p *Profile) *string { return &p.Storage.AWS.SessionToken }}
+	AWSRegion          = Setting{Key: "storage.aws.region", Env: "AWS_REGION", field: func(p *Profile) *string { return &p.Storage.AWS.Region }}
+	AWSBucket          = Setting{Key: "storage.aws.bucket", Env: "AWS_BUCKET", field: func(p *Profile) *string { return &p.Storage.AWS.Bucket }}
+	AWSEndpointURL     = Setting{Key: "storage.aws.endpoint-url", Env: "AWS_ENDPOINT_URL_S3", field: func(p *Profile) *string { return &p.Storage.AWS.EndpointURL }}
+
+	AzureStorageAccount   = Setting{Key: "storage.azure.account", Env: "AZURE_STORAGE_ACCOUNT", field: func(p *Profile) *string { return &p.Storage.Azure.Account }}
+	AzureStorageAccessKey = Setting{Key: "storage.azure.access-key", Env: "AZURE_STORAGE_ACCESS_KEY", Secret: true, field: func(p *Profile) *string { return &p.Storage.Azure.AccessKey }}
+	AzureBlobEndpoint     = Setting{Key: "storage.azure.blob-endpoint", Env: "AZURE_STORAGE_BLOB_ENDPOINT", field: func(p *Profile) *string { return &p.Storage.Azure.BlobEndpoint }}
+)
+
+// All lists every Setting.
+var All = []Setting{
+	GitLabHost, GitLabAPIEndpoint, GitLabUsername, GitLabToken, GitLabExporterImage,
+	GitHubAPIEndpoint, GitHubUploadsEndpoint, GitHubOrg, GitHubToken,
+	GitHubAppID, GitHubAppPrivateKey, GitHubAppInstallationID, GitHubUserToken,
+	GHECAPIEndpoint,
+	AWSAccessKeyID, AWSSecretAccessKey, AWSSessionToken, AWSRegion, AWSBucket, AWSEndpointURL,
+	AzureStorageAccount, AzureStorageAccessKey, AzureBlobEndpoint,
+}
+
+// Sources of a resolved value, as reported by Resolve.
+const (
+	SourceEnv     = "env"
+	SourceProfile = "profile"
+	SourceUnset   = "unset"
+)
+
+// active is the profile selected by Use. Lookup falls back to it for settings
+// without an environment variable.
+var active = &Profile{}
+
+// resolved caches the secrets that references in secret settings point to,
+// or the error resolving them, keyed by reference, so each is fetched once
+// per run. References are resolved with resolveCtx, the context given to Use.
+var (
+	resolvedMu sync.Mutex
+	resolved   = map[string]*resolution{}
+	resolveCtx = context.Background()
+)
+
+// resolution is the result of resolving a reference, set before done is
+// closed.
+type resolution struct {
+	done   chan struct{}
+	secret string
+	err    error
+}
+
+// DefaultPath returns $XDG_CONFIG_HOME/gh-glx/config.yaml, or
+// ~/.config/gh-glx/config.yaml when XDG_CONFIG_HOME is not set.
+func DefaultPath() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "gh-glx", "config.yaml")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".config", "gh-glx", "config.yaml")
+	}
+	return filepath.Join(home, ".config", "gh-glx", "config.yaml")
+}
+
+// FindSetting returns the Setting with the given key.
+func FindSetting(key string) (Setting, error) {
+	for _, setting := range All {
+		if setting.Key == key {
+			return setting, nil
+		}
+	}
+	keys := make([]string, len(All))
+	for i, setting := range All {
+		keys[i] = setting.Key
+	}
+	return Setting{}, fmt.Errorf("unknown setting: %s. Available settings: %s", key, strings.Join(keys, ", "))
+}
+
+// Load reads the configuration file at path. A missing file is an empty
+// configuration; unknown keys are an error.
+func Load(path string) (*File, error) {
+	file := &File{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return file, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %v", path, err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse config file %s: %v", path, err)
+	}
+	return file, nil
+}
+
+// Save writes the configuration file to path, readable only by the user as
+// it may hold tokens.
+func (f *File) Save(path string) error {
+	var data bytes.Buffer
+	encoder := yaml.NewEncoder(&data)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(f); err != nil {
+		return fmt.Errorf("failed to marshal config: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create config directory: %v", err)
+	}
+	if err := os.WriteFile(path, data.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("failed to write config file %s: %v", path, err)
+	}
+	return nil
+}
+
+// ProfileName returns name, or the current profile of the file when name is
+// empty, or DefaultProfile.
+func (f *File) ProfileName(name string) string {
+	if name != "" {
+		return name
+	}
+	if f.CurrentProfile != "" {
+		return f.CurrentProfile
+	}
+	return DefaultProfile
+}
+
+// Profile returns the named profile. Only an explicitly requested profile
+// must exist; the default profile may be absent.
+func (f *File) Profile(name string) (*Profile, error) {
+	if profile, ok := f.Profiles[f.ProfileName(name)]; ok && profile != nil {
+		return profile, nil
+	}
+	if name != "" || f.CurrentProfile != "" {
+		names := make([]string, 0, len(f.Profiles))
+		for profileName := range f.Profiles {
+			names = append(names, profileName)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown profile: %s. Available profiles: %s", f.ProfileName(name), strings.Join(names, ", "))
+	}
+	return &Profile{}, nil
+}
+
+// Set stores value for setting in the named profile, creating it if needed.
+func (f *File) Set(name string, setting Setting, value string) {
+	name = f.ProfileName(name)
+	if f.Profiles == nil {
+		f.Profiles = map[string]*Profile{}
+	}
+	if f.Profiles[name] == nil {
+		f.Profiles[name] = &Profile{}
+	}
+	*setting.field(f.Profiles[name]) = value
+}
+
+// Get returns the value of setting in the profile.
+func (p *Profile) Get(setting Setting) string {
+	return *setting.field(p)
+}
+
+// Use loads the configuration file at path and makes the named profile the
+// one Lookup falls back to. Secret settings given as references, e.g.
+// vault://kv/migrations#gitlab_pat, are resolved when they are first looked
+// up, so a command only fetches the secrets it uses, and stop when ctx is
+// done. Secrets are redacted from logs.
+func Use(ctx context.Context, path, name string) error {
+	file, err := Load(path)
+	if err != nil {
+		return err
+	}
+	profile, err := file.Profile(name)
+	if err != nil {
+		return err
+	}
+	for _, setting := range All {
+		if setting.Secret {
+			ghlog.AddSecret(profile.Get(setting))
+		}
+	}
+	for _, notification := range profile.Notifications {
+		ghlog.AddSecret(notification.URL)
+		ghlog.AddSecret(notification.Secret)
+	}
+
+	resolvedMu.Lock()
+	resolveCtx = ctx
+	resolvedMu.Unlock()
+
+	notificationsMu.Lock()
+	defer notificationsMu.Unlock()
+	active = profile
+	notificationsResolved = false
+	return nil
+}
+
+// resolvedNotifications caches the notifications of the active profile once
+// their references are resolved.
+var (
+	notificationsMu       sync.Mutex
+	notificationsResolved bool
+	resolvedNotifications []Notification
+	notificationsErr      error
+)
+
+// Notifications returns the notifications of the active profile with the
+// references in their URLs and secrets resolved. They are resolved on the
+// first call after Use.
+func Notifications() ([]Notification, error) {
+	notificationsMu.Lock()
+	defer notificationsMu.Unlock()
+	if !notificationsResolved {
+		resolvedNotifications, notificationsErr = resolveNotifications(active.Notifications)
+		notificationsResolved = true
+	}
+	return resolvedNotifications, notificationsErr
+}
+
+func resolveNotifications(configured []Notification) ([]Notification, error) {
+	notifications := make([]Notification, 0, len(configured))
+	for i, notification := range configured {
+		var err error
+		if notification.URL, err = resolveReference(notification.URL); err != nil {
+			return nil, fmt.Errorf("notifications[%d].url: %v", i, err)
+		}
+		if notification.Secret, err = resolveReference(notification.Secret); err != nil {
+			return nil, fmt.Errorf("notifications[%d].secret: %v", i, err)
+		}
+		notifications = append(notifications, notification)
+	}
+	return notifications, nil
+}
+
+// Lookup returns the value of setting from its environment variable or, when
+// that is not set, from the active profile. Flags take precedence over both
+// and defaults apply when Lookup returns an empty string. A reference that
+// cannot be resolved is logged and looked up as empty; Resolve returns why.
+func Lookup(setting Setting) string {
+	value, _, err := Resolve(setting)
+	if err != nil {
+		ghlog.Logger.Error("Failed to resolve secret", zap.String("setting", setting.Key), zap.Error(err))
+	}
+	return value
+}
+
+// Resolve returns the value of setting and where it came from. A secret
+// setting holding a reference resolves to the secret it points to, or to the
+// error resolving it.
+func Resolve(setting Setting) (string, string, error) {
+	value, source := raw(setting)
+	secret, err := resolveSecret(setting, value)
+	if err != nil {
+		return "", source, err
+	}
+	return secret, source, nil
+}
+
+// raw returns the value of setting as written in the environment or profile.
+func raw(setting Setting) (string, string) {
+	for _, env := range append([]string{setting.Env}, setting.Aliases...) {
+		if value := os.Getenv(env); value != "" {
+			return value, SourceEnv
+		}
+	}
+	if value := active.Get(setting); value != "" {
+		return value, SourceProfile
+	}
+	return "", SourceUnset
+}
+
+// resolveSecret returns the secret value refers to when setting is a secret
+// and value a reference, and value otherwise.
+func resolveSecret(setting Setting, value string) (string, error) {
+	if !setting.Secret {
+		return value, nil
+	}
+	return resolveReference(value)
+}
+
+// resolveReference returns the secret value refers to when it is a
+// reference, and value otherwise.
+func resolveReference(value string) (string, error) {
+	if !secrets.IsReference(value) {
+		return value, nil
+	}
+
+	// The first lookup of a reference resolves it outside the lock, so a slow
+	// secret store only holds up the lookups of the same reference.
+	resolvedMu.Lock()
+	r, ok := resolved[value]
+	if !ok {
+		r = &resolution{done: make(chan struct{})}
+		resolved[value] = r
+	}
+	ctx := resolveCtx
+	resolvedMu.Unlock()
+
+	if !ok {
+		r.secret, r.err = secrets.Resolve(ctx, value)
+		if r.err == nil {
+			ghlog.AddSecret(r.secret)
+		} else if ctx.Err() != nil {
+			// An interrupted resolution is not cached.
+			resolvedMu.Lock()
+			delete(resolved, value)
+			resolvedMu.Unlock()
+		}
+		close(r.done)
+	}
+
+	select {
+	case <-r.done:
+		return r.secret, r.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
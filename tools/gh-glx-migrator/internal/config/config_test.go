@@ -0,0 +1,349 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ps-resources/gh-glx-migrator/internal/secrets"
+)
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gh-glx", "config.yaml")
+
+	file := &File{CurrentProfile: "prod"}
+	file.Set("prod", GitHubToken, "ghp_profile")
+	file.Set("prod", AWSBucket, "archives")
+	if err := file.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("config file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	profile, err := loaded.Profile("")
+	if err != nil {
+		t.Fatalf("Profile() error = %v", err)
+	}
+	if got := profile.Get(AWSBucket); got != "archives" {
+		t.Errorf("storage.aws.bucket = %q, want %q", got, "archives")
+	}
+
+	if _, err := loaded.Profile("staging"); err == nil {
+		t.Error("Profile(\"staging\") returned no error for a missing profile")
+	}
+}
+
+func TestLoadUnknownKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("profiles:\n  default:\n    github:\n      pat: x\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Load() returned no error for an unknown key")
+	}
+}
+
+func TestLookupPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	file := &File{}
+	file.Set("", GitHubToken, "ghp_profile")
+	file.Set("", GitHubOrg, "profile-org")
+	if err := file.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := Use(context.Background(), path, ""); err != nil {
+		t.Fatalf("Use() error = %v", err)
+	}
+	t.Cleanup(func() { active = &Profile{} })
+
+	t.Setenv("GITHUB_PAT", "ghp_env")
+	t.Setenv("GITHUB_ORG", "")
+
+	if value, source, _ := Resolve(GitHubToken); value != "ghp_env" || source != SourceEnv {
+		t.Errorf("Resolve(github.token) = %q, %q, want ghp_env from env", value, source)
+	}
+	if value, source, _ := Resolve(GitHubOrg); value != "profile-org" || source != SourceProfile {
+		t.Errorf("Resolve(github.org) = %q, %q, want profile-org from profile", value, source)
+	}
+	t.Setenv("AWS_BUCKET", "")
+	if value, source, _ := Resolve(AWSBucket); value != "" || source != SourceUnset {
+		t.Errorf("Resolve(storage.aws.bucket) = %q, %q, want unset", value, source)
+	}
+}
+
+func TestLookupAliases(t *testing.T) {
+	t.Setenv("GITHUB_PAT", "")
+	t.Setenv("GITHUB_GHEC_PAT", "")
+	t.Setenv("GITHUB_TOKEN", "ghp_token")
+	if got := Lookup(GitHubToken); got != "ghp_token" {
+		t.Errorf("Lookup(github.token) = %q, want GITHUB_TOKEN when GITHUB_PAT is not set", got)
+	}
+	t.Setenv("GITHUB_GHEC_PAT", "ghp_ghec")
+	if got := Lookup(GitHubToken); got != "ghp_ghec" {
+		t.Errorf("Lookup(github.token) = %q, want GITHUB_GHEC_PAT before GITHUB_TOKEN", got)
+	}
+	t.Setenv("GITHUB_PAT", "ghp_pat")
+	if value, source, _ := Resolve(GitHubToken); value != "ghp_pat" || source != SourceEnv {
+		t.Errorf("Resolve(github.token) = %q, %q, want GITHUB_PAT before its aliases", value, source)
+	}
+}
+
+func TestLookupSecretReference(t *testing.T) {
+	dir := t.TempDir()
+	secretPath := filepath.Join(dir, "gitlab_pat")
+	if err := os.WriteFile(secretPath, []byte("glpat-from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GITLAB_PAT", "file://"+secretPath)
+	t.Setenv("GITHUB_PAT", "")
+	t.Setenv("GITLAB_HOST", "file://"+secretPath)
+
+	if err := Use(context.Background(), filepath.Join(dir, "config.yaml"), ""); err != nil {
+		t.Fatalf("Use() error = %v", err)
+	}
+	if got := Lookup(GitLabToken); got != "glpat-from-file" {
+		t.Errorf("Lookup(gitlab.token) = %q, want the file contents", got)
+	}
+	// Only secret settings are resolved.
+	if got := Lookup(GitLabHost); got != "file://"+secretPath {
+		t.Errorf("Lookup(gitlab.host) = %q, want the reference unchanged", got)
+	}
+
+	// References are only resolved when they are looked up.
+	laterPath := filepath.Join(dir, "github_pat")
+	t.Setenv("GITHUB_PAT", "file://"+laterPath)
+	if err := Use(context.Background(), filepath.Join(dir, "config.yaml"), ""); err != nil {
+		t.Fatalf("Use() error = %v, want the reference left unresolved", err)
+	}
+	if err := os.WriteFile(laterPath, []byte("ghp_from_file"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got := Lookup(GitHubToken); got != "ghp_from_file" {
+		t.Errorf("Lookup(github.token) = %q, want the file written after Use", got)
+	}
+
+	t.Setenv("GITHUB_PAT", "file://"+filepath.Join(dir, "missing"))
+	if got := Lookup(GitHubToken); got != "" {
+		t.Errorf("Lookup(github.token) = %q, want nothing for an unresolvable reference", got)
+	}
+	if _, _, err := Resolve(GitHubToken); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("Resolve(github.token) error = %v, want the missing file", err)
+	}
+	problems := ValidateReferences()
+	if len(problems) != 1 || problems[0].Key != GitHubToken.Key || !strings.Contains(problems[0].Message, "could not be resolved") {
+		t.Errorf("ValidateReferences() = %v, want the github.token reference", problems)
+	}
+	// Check reports why the token is empty instead of that it is not set.
+	_, problems = Check(Snapshot(), Requirements{GitHub: true, Storage: StorageGitHub})
+	if len(problems) != 1 || problems[0].Key != GitHubToken.Key || !strings.Contains(problems[0].Message, "could not be resolved") {
+		t.Errorf("Check() = %v, want the unresolved github.token reference", problems)
+	}
+	// A flag overrides the unresolved setting.
+	values := Snapshot()
+	values.Set(GitHubToken, "ghp_flag")
+	if _, problems := Check(values, Requirements{GitHub: true, Storage: StorageGitHub}); len(problems) != 0 {
+		t.Errorf("Check() with the token set = %v", problems)
+	}
+}
+
+func TestLookupSlowSecretStore(t *testing.T) {
+	started := make(chan struct{})
+	secrets.Register("slow", secrets.ProviderFunc(func(ctx context.Context, reference string) (string, error) {
+		close(started)
+		<-ctx.Done()
+		return "", ctx.Err()
+	}))
+	dir := t.TempDir()
+	secretPath := filepath.Join(dir, "gitlab_pat")
+	if err := os.WriteFile(secretPath, []byte("glpat-from-file"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GITHUB_PAT", "slow://github")
+	t.Setenv("GITLAB_PAT", "file://"+secretPath)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := Use(ctx, filepath.Join(dir, "config.yaml"), ""); err != nil {
+		t.Fatalf("Use() error = %v", err)
+	}
+	t.Cleanup(func() { _ = Use(context.Background(), filepath.Join(dir, "none.yaml"), "") })
+
+	resolved := make(chan error, 1)
+	go func() {
+		_, _, err := Resolve(GitHubToken)
+		resolved <- err
+	}()
+	<-started
+
+	// Other references resolve while the slow one is pending.
+	if got := Lookup(GitLabToken); got != "glpat-from-file" {
+		t.Errorf("Lookup(gitlab.token) = %q while another reference is resolved", got)
+	}
+
+	cancel()
+	if err := <-resolved; !errors.Is(err, context.Canceled) {
+		t.Errorf("Resolve(github.token) error = %v after the context was canceled, want context.Canceled", err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	aws := Values{
+		GitHubToken.Key:        "ghp_x",
+		AWSAccessKeyID.Key:     "AKIA",
+		AWSSecretAccessKey.Key: "secret",
+		AWSRegion.Key:          "us-east-1",
+	}
+	tests := []struct {
+		name         string
+		values       Values
+		requirements Requirements
+		wantStorage  string
+		wantKeys     []string
+	}{
+		{
+			name:         "auto selects AWS and requires a bucket",
+			values:       aws,
+			requirements: Requirements{GitHub: true, Storage: StorageAuto},
+			wantStorage:  StorageAWS,
+			wantKeys:     []string{AWSBucket.Key},
+		},
+		{
+			name:         "auto without storage credentials",
+			values:       Values{GitHubToken.Key: "ghp_x"},
+			requirements: Requirements{GitHub: true, Storage: StorageAuto},
+			wantStorage:  "",
+			wantKeys:     []string{"storage"},
+		},
+		{
+			name:         "GitHub storage needs no storage credentials",
+			values:       Values{},
+			requirements: Requirements{GitHub: true, Storage: StorageGitHub},
+			wantStorage:  StorageGitHub,
+			wantKeys:     []string{GitHubToken.Key},
+		},
+		{
+			name:         "Azure",
+			values:       Values{AzureStorageAccount.Key: "account"},
+			requirements: Requirements{Storage: StorageAzure},
+			wantStorage:  StorageAzure,
+			wantKeys:     []string{AzureStorageAccessKey.Key},
+		},
+		{
+			name:         "unknown storage",
+			values:       Values{},
+			requirements: Requirements{Storage: "gcs"},
+			wantStorage:  "gcs",
+			wantKeys:     []string{"storage"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage, problems := Check(tt.values, tt.requirements)
+			if storage != tt.wantStorage {
+				t.Errorf("Check() storage = %q, want %q", storage, tt.wantStorage)
+			}
+			var keys []string
+			for _, problem := range problems {
+				keys = append(keys, problem.Key)
+			}
+			if strings.Join(keys, ",") != strings.Join(tt.wantKeys, ",") {
+				t.Errorf("Check() problems = %v, want keys %v", problems, tt.wantKeys)
+			}
+		})
+	}
+}
+
+func TestNotifications(t *testing.T) {
+	dir := t.TempDir()
+	secretPath := filepath.Join(dir, "webhook_secret")
+	if err := os.WriteFile(secretPath, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "config.yaml")
+	data := `profiles:
+  default:
+    notifications:
+      - type: webhook
+        url: https://hooks.example.com/migrations
+        secret: file://` + secretPath + `
+        events: [migration.failed]
+      - type: file
+        path: events.jsonl
+`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := Use(context.Background(), path, ""); err != nil {
+		t.Fatalf("Use() error = %v", err)
+	}
+	t.Cleanup(func() { active = &Profile{} })
+
+	notifications, err := Notifications()
+	if err != nil {
+		t.Fatalf("Notifications() error = %v", err)
+	}
+	if len(notifications) != 2 {
+		t.Fatalf("Notifications() = %+v, want 2", notifications)
+	}
+	if webhook := notifications[0]; webhook.Secret != "s3cret" || webhook.URL != "https://hooks.example.com/migrations" || len(webhook.Events) != 1 {
+		t.Errorf("webhook notification = %+v, want the secret resolved", webhook)
+	}
+	if problems := ValidateNotifications(notifications); len(problems) != 0 {
+		t.Errorf("ValidateNotifications() = %v, want none", problems)
+	}
+}
+
+func TestNotificationsResolvedLazily(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	data := `profiles:
+  default:
+    notifications:
+      - type: slack
+        url: file://` + filepath.Join(dir, "missing") + `
+`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := Use(context.Background(), path, ""); err != nil {
+		t.Fatalf("Use() error = %v, want notifications left unresolved", err)
+	}
+	t.Cleanup(func() { _ = Use(context.Background(), filepath.Join(dir, "none.yaml"), "") })
+
+	if _, err := Notifications(); err == nil || !strings.Contains(err.Error(), "notifications[0].url") {
+		t.Errorf("Notifications() error = %v, want the unresolvable URL", err)
+	}
+	if problems := ValidateReferences(); len(problems) != 1 || problems[0].Key != "notifications" {
+		t.Errorf("ValidateReferences() = %v, want the notification", problems)
+	}
+}
+
+func TestValidateNotifications(t *testing.T) {
+	problems := ValidateNotifications([]Notification{
+		{Type: NotificationSlack, URL: "hooks.slack.com/services/x", Secret: "s3cret"},
+		{Type: NotificationFile},
+		{Type: "email"},
+		{Type: NotificationTeams, URL: "https://example.webhook.office.com/x"},
+	})
+	var keys []string
+	for _, problem := range problems {
+		keys = append(keys, problem.Key)
+	}
+	want := "notifications[0].url,notifications[0].secret,notifications[1].path,notifications[2].type"
+	if got := strings.Join(keys, ","); got != want {
+		t.Errorf("ValidateNotifications() problems = %v, want keys %s", problems, want)
+	}
+}
@@ -0,0 +1,267 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Values is a snapshot of the resolved settings, keyed by Setting.Key. The
+// checks in this file only read a Values, so they never touch the
+// environment or prompt and can be run with any combination of settings.
+type Values map[string]string
+
+// unresolvedSuffix is appended to the key of a setting to hold why its
+// reference could not be resolved. Setting keys never contain it.
+const unresolvedSuffix = "#unresolved"
+
+// Snapshot returns the resolved value of every setting. A setting whose
+// reference cannot be resolved is empty, and the problems reported for it
+// give the cause.
+func Snapshot() Values {
+	values := Values{}
+	for _, setting := range All {
+		value, _, err := Resolve(setting)
+		values[setting.Key] = value
+		if err != nil {
+			values[setting.Key+unresolvedSuffix] = err.Error()
+		}
+	}
+	return values
+}
+
+// Get returns the value of setting.
+func (v Values) Get(setting Setting) string {
+	return v[setting.Key]
+}
+
+// Set overrides the value of setting, e.g. with a command flag. Empty values
+// are ignored so an unset flag keeps the resolved setting.
+func (v Values) Set(setting Setting, value string) {
+	if value != "" {
+		v[setting.Key] = value
+		delete(v, setting.Key+unresolvedSuffix)
+	}
+}
+
+// explain replaces the problems of settings whose references could not be
+// resolved, which would otherwise read as not set, with the cause.
+func (v Values) explain(problems []Problem) []Problem {
+	for i, problem := range problems {
+		if cause, ok := v[problem.Key+unresolvedSuffix]; ok {
+			problems[i] = unresolvedProblem(problem.Key, cause)
+		}
+	}
+	return problems
+}
+
+// unresolvedProblem is the problem of the setting key whose reference could
+// not be resolved because of cause.
+func unresolvedProblem(key, cause string) Problem {
+	return Problem{Key: key, Message: fmt.Sprintf("%s could not be resolved: %s", key, cause)}
+}
+
+// Problem is a setting that is missing or invalid.
+type Problem struct {
+	// Key is the setting at fault, e.g. github.token, or storage when no
+	// storage backend could be selected.
+	Key     string
+	Message string
+}
+
+func (p Problem) String() string {
+	return p.Message
+}
+
+// Storage backends archives can be uploaded to, as selected with --storage.
+const (
+	StorageAuto   = "auto"
+	StorageAWS    = "aws"
+	StorageAzure  = "azure"
+	StorageGitHub = "github"
+)
+
+// Storages lists the valid --storage choices.
+var Storages = []string{StorageAuto, StorageAWS, StorageAzure, StorageGitHub}
+
+// Requirements are what a command needs from the settings.
+type Requirements struct {
+	GitHub bool
+	GitLab bool
+	// Storage is the --storage choice of a command that uploads archives, and
+	// empty for commands that do not.
+	Storage string
+}
+
+// Check returns the problems that prevent a command with the requirements r
+// from running, and the storage backend it should upload to. With
+// StorageAuto, AWS is selected when its keys are set, then Azure. When
+// neither is set the returned storage is empty along with a problem for the
+// key storage, and the caller decides whether GitHub-owned storage may be
+// used instead. A required setting whose reference could not be resolved is
+// reported with the cause.
+func Check(v Values, r Requirements) (string, []Problem) {
+	storage, problems := check(v, r)
+	return storage, v.explain(problems)
+}
+
+func check(v Values, r Requirements) (string, []Problem) {
+	var problems []Problem
+	if r.GitHub {
+		problems = append(problems, CheckGitHub(v)...)
+	}
+	if r.GitLab {
+		problems = append(problems, missing(v, GitLabToken)...)
+	}
+	problems = append(problems, checkEndpoints(v)...)
+
+	storage := r.Storage
+	if storage == StorageAuto {
+		switch {
+		case v.Get(AWSAccessKeyID) != "" || v.Get(AWSSecretAccessKey) != "":
+			storage = StorageAWS
+		case v.Get(AzureStorageAccount) != "" || v.Get(AzureStorageAccessKey) != "":
+			storage = StorageAzure
+		default:
+			return "", append(problems, Problem{
+				Key: "storage",
+				Message: fmt.Sprintf("no AWS or Azure storage credentials are set (%s and %s, or %s and %s); use --storage %s to upload to GitHub-owned storage",
+					AWSAccessKeyID.Env, AWSSecretAccessKey.Env, AzureStorageAccount.Env, AzureStorageAccessKey.Env, StorageGitHub),
+			})
+		}
+	}
+
+	switch storage {
+	case "", StorageGitHub:
+	case StorageAWS:
+		problems = append(problems, missing(v, AWSAccessKeyID, AWSSecretAccessKey, AWSRegion, AWSBucket)...)
+	case StorageAzure:
+		problems = append(problems, missing(v, AzureStorageAccount, AzureStorageAccessKey)...)
+	default:
+		problems = append(problems, Problem{
+			Key:     "storage",
+			Message: fmt.Sprintf("unknown storage %q, must be one of %s", storage, strings.Join(Storages, ", ")),
+		})
+	}
+	return storage, problems
+}
+
+// CheckGitHub returns the problems with the target GitHub credential: a PAT,
+// or a GitHub App with a user token to start migrations with.
+func CheckGitHub(v Values) []Problem {
+	var problems []Problem
+	if v.Get(GitHubAppID) == "" {
+		if v.Get(GitHubToken) == "" {
+			problems = append(problems, Problem{Key: GitHubToken.Key, Message: fmt.Sprintf("%s or %s is not set (%s, %s)", GitHubToken.Key, GitHubAppID.Key, GitHubToken.Env, GitHubAppID.Env)})
+		}
+		return problems
+	}
+
+	if v.Get(GitHubAppPrivateKey) == "" {
+		problems = append(problems, Problem{Key: GitHubAppPrivateKey.Key, Message: fmt.Sprintf("%s is required with %s (%s)", GitHubAppPrivateKey.Key, GitHubAppID.Key, GitHubAppPrivateKey.Env)})
+	}
+	if v.Get(GitHubToken) == "" && v.Get(GitHubUserToken) == "" {
+		problems = append(problems, Problem{Key: GitHubUserToken.Key, Message: fmt.Sprintf("%s is required with %s to start migrations (%s)", GitHubUserToken.Key, GitHubAppID.Key, GitHubUserToken.Env)})
+	}
+	if v.Get(GitHubAppInstallationID) == "" && v.Get(GitHubOrg) == "" {
+		problems = append(problems, Problem{Key: GitHubAppInstallationID.Key, Message: fmt.Sprintf("%s or %s is required with %s", GitHubAppInstallationID.Key, GitHubOrg.Key, GitHubAppID.Key)})
+	}
+	return problems
+}
+
+// Validate checks every setting of a profile, as gh glx config validate does:
+// the GitHub and GitLab credentials, the API endpoints and that storage
+// credentials are not only partly set.
+func Validate(v Values) []Problem {
+	return v.explain(validate(v))
+}
+
+func validate(v Values) []Problem {
+	problems := CheckGitHub(v)
+	problems = append(problems, missing(v, GitLabToken)...)
+	problems = append(problems, checkEndpoints(v)...)
+
+	for _, pair := range [][2]Setting{{AWSAccessKeyID, AWSSecretAccessKey}, {AzureStorageAccount, AzureStorageAccessKey}} {
+		if (v.Get(pair[0]) == "") != (v.Get(pair[1]) == "") {
+			problems = append(problems, Problem{Key: pair[0].Key, Message: fmt.Sprintf("%s and %s must be set together", pair[0].Key, pair[1].Key)})
+		}
+	}
+	if v.Get(AWSAccessKeyID) != "" && v.Get(AWSRegion) == "" {
+		problems = append(problems, Problem{Key: AWSRegion.Key, Message: fmt.Sprintf("%s is required with AWS storage (%s)", AWSRegion.Key, AWSRegion.Env)})
+	}
+	return problems
+}
+
+// checkEndpoints reports API endpoints given as URLs instead of host names.
+func checkEndpoints(v Values) []Problem {
+	var problems []Problem
+	for _, setting := range []Setting{GitHubAPIEndpoint, GitHubUploadsEndpoint, GHECAPIEndpoint} {
+		if value := v.Get(setting); strings.Contains(value, "://") {
+			problems = append(problems, Problem{Key: setting.Key, Message: fmt.Sprintf("%s must be a host name without scheme, e.g. api.github.com, got %s", setting.Key, value)})
+		}
+	}
+	return problems
+}
+
+// missing returns a problem for each of settings that is not set.
+func missing(v Values, settings ...Setting) []Problem {
+	var problems []Problem
+	for _, setting := range settings {
+		if v.Get(setting) == "" {
+			problems = append(problems, Problem{Key: setting.Key, Message: fmt.Sprintf("%s is not set (%s)", setting.Key, setting.Env)})
+		}
+	}
+	return problems
+}
+
+// Types of notifications.
+const (
+	NotificationWebhook = "webhook"
+	NotificationSlack   = "slack"
+	NotificationTeams   = "teams"
+	NotificationFile    = "file"
+)
+
+// NotificationTypes lists the valid notification types.
+var NotificationTypes = []string{NotificationWebhook, NotificationSlack, NotificationTeams, NotificationFile}
+
+// ValidateReferences resolves the secret references of the environment and
+// the active profile, which commands only resolve when they need them, and
+// reports those that cannot be resolved.
+func ValidateReferences() []Problem {
+	var problems []Problem
+	for _, setting := range All {
+		value, _ := raw(setting)
+		if _, err := resolveSecret(setting, value); err != nil {
+			problems = append(problems, unresolvedProblem(setting.Key, err.Error()))
+		}
+	}
+	if _, err := Notifications(); err != nil {
+		problems = append(problems, Problem{Key: "notifications", Message: err.Error()})
+	}
+	return problems
+}
+
+// ValidateNotifications reports notifications of an unknown type or without
+// the URL or path their type needs.
+func ValidateNotifications(notifications []Notification) []Problem {
+	var problems []Problem
+	for i, notification := range notifications {
+		key := fmt.Sprintf("notifications[%d]", i)
+		switch notification.Type {
+		case NotificationWebhook, NotificationSlack, NotificationTeams:
+			if !strings.HasPrefix(notification.URL, "https://") && !strings.HasPrefix(notification.URL, "http://") {
+				problems = append(problems, Problem{Key: key + ".url", Message: fmt.Sprintf("%s.url must be the http or https URL of the %s webhook", key, notification.Type)})
+			}
+			if notification.Secret != "" && notification.Type != NotificationWebhook {
+				problems = append(problems, Problem{Key: key + ".secret", Message: fmt.Sprintf("%s.secret is only used by %s notifications", key, NotificationWebhook)})
+			}
+		case NotificationFile:
+			if notification.Path == "" {
+				problems = append(problems, Problem{Key: key + ".path", Message: fmt.Sprintf("%s.path is required with type %s", key, NotificationFile)})
+			}
+		default:
+			problems = append(problems, Problem{Key: key + ".type", Message: fmt.Sprintf("%s.type %q must be one of %s", key, notification.Type, strings.Join(NotificationTypes, ", "))})
+		}
+	}
+	return problems
+}
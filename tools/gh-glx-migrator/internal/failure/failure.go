@@ -0,0 +1,157 @@
+// Package failure classifies the errors commands return, so the process can
+// exit with a code that tells a workflow why a migration did not succeed.
+package failure
+
+import (
+	"context"
+	"errors"
+)
+
+// Kind is the class of a failure.
+type Kind int
+
+const (
+	// KindUnknown is any error that was not classified.
+	KindUnknown Kind = iota
+	// KindValidation is a missing or invalid flag or setting.
+	KindValidation
+	// KindAuth is a missing, invalid or insufficient credential.
+	KindAuth
+	// KindStorage is a failed upload to or cleanup of archive storage.
+	KindStorage
+	// KindMigrationFailed is a migration that could not be started or that
+	// GitHub reports as failed.
+	KindMigrationFailed
+	// KindTimeout is a wait for a migration or export that ran out of time.
+	KindTimeout
+	// KindWarnings is a migration that succeeded with warnings, reported as a
+	// failure with --exit-on-warnings.
+	KindWarnings
+	// KindCanceled is a command stopped by SIGINT or SIGTERM.
+	KindCanceled
+)
+
+// Exit codes of the gh-glx process, one per Kind.
+const (
+	ExitOK              = 0
+	ExitError           = 1
+	ExitValidation      = 2
+	ExitAuth            = 3
+	ExitStorage         = 4
+	ExitMigrationFailed = 5
+	ExitTimeout         = 6
+	ExitWarnings        = 7
+	// ExitCanceled follows the shell convention of 128 + SIGINT.
+	ExitCanceled = 130
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindValidation:
+		return "validation"
+	case KindAuth:
+		return "auth"
+	case KindStorage:
+		return "storage"
+	case KindMigrationFailed:
+		return "migration failed"
+	case KindTimeout:
+		return "timeout"
+	case KindWarnings:
+		return "warnings"
+	case KindCanceled:
+		return "canceled"
+	default:
+		return "error"
+	}
+}
+
+// ExitCode returns the exit code of the Kind.
+func (k Kind) ExitCode() int {
+	switch k {
+	case KindValidation:
+		return ExitValidation
+	case KindAuth:
+		return ExitAuth
+	case KindStorage:
+		return ExitStorage
+	case KindMigrationFailed:
+		return ExitMigrationFailed
+	case KindTimeout:
+		return ExitTimeout
+	case KindWarnings:
+		return ExitWarnings
+	case KindCanceled:
+		return ExitCanceled
+	default:
+		return ExitError
+	}
+}
+
+// Error is an error of a known Kind.
+type Error struct {
+	Kind Kind
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Validation marks err as a validation failure.
+func Validation(err error) error { return wrap(KindValidation, err) }
+
+// Auth marks err as an authentication or authorization failure.
+func Auth(err error) error { return wrap(KindAuth, err) }
+
+// Storage marks err as an archive storage failure.
+func Storage(err error) error { return wrap(KindStorage, err) }
+
+// MigrationFailed marks err as a failed migration.
+func MigrationFailed(err error) error { return wrap(KindMigrationFailed, err) }
+
+// Timeout marks err as a timeout.
+func Timeout(err error) error { return wrap(KindTimeout, err) }
+
+// Warnings marks err as a migration that succeeded with warnings.
+func Warnings(err error) error { return wrap(KindWarnings, err) }
+
+// Canceled marks err as the result of an interrupted command.
+func Canceled(err error) error { return wrap(KindCanceled, err) }
+
+// wrap classifies err as kind, unless it is nil or already classified: an
+// upload rejected for a bad token stays an auth failure.
+func wrap(kind Kind, err error) error {
+	if err == nil || KindOf(err) != KindUnknown {
+		return err
+	}
+	return &Error{Kind: kind, Err: err}
+}
+
+// KindOf returns the Kind of err. An expired context deadline is a timeout
+// and a cancelled context means the command was interrupted.
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return KindTimeout
+	}
+	if errors.Is(err, context.Canceled) {
+		return KindCanceled
+	}
+	return KindUnknown
+}
+
+// ExitCode returns the exit code for err: ExitOK when err is nil.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	return KindOf(err).ExitCode()
+}
@@ -0,0 +1,33 @@
+package failure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "success", err: nil, want: ExitOK},
+		{name: "unclassified", err: errors.New("boom"), want: ExitError},
+		{name: "validation", err: Validation(errors.New("missing org")), want: ExitValidation},
+		{name: "wrapped auth", err: fmt.Errorf("failed to fetch organization: %w", Auth(errors.New("401"))), want: ExitAuth},
+		{name: "auth inside storage", err: Storage(Auth(errors.New("401"))), want: ExitAuth},
+		{name: "migration failed", err: MigrationFailed(errors.New("FAILED")), want: ExitMigrationFailed},
+		{name: "deadline", err: fmt.Errorf("upload: %w", context.DeadlineExceeded), want: ExitTimeout},
+		{name: "warnings", err: Warnings(errors.New("3 warnings")), want: ExitWarnings},
+		{name: "canceled", err: fmt.Errorf("stopped waiting for migration: %w", context.Canceled), want: ExitCanceled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package github
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,25 +10,27 @@ import (
 	"os"
 	"time"
 
+	"github.com/ps-resources/gh-glx-migrator/internal/config"
+	"github.com/ps-resources/gh-glx-migrator/internal/failure"
 	"github.com/ps-resources/gh-glx-migrator/pkg/logger"
 
 	"go.uber.org/zap"
 )
 
-func ExportRepositories(orgName string, input GHECExportInput) (*GHECExportResponse, error) {
+func ExportRepositories(ctx context.Context, orgName string, input GHECExportInput) (*GHECExportResponse, error) {
 	logger.Logger.Info("Starting GHEC repository export",
 		zap.String("organization", orgName),
 		zap.Strings("repositories", input.Repositories))
 
-	githubToken := os.Getenv("GITHUB_GHEC_PAT")
-	githubHost := os.Getenv("GITHUB_GHEC_API_ENDPOINT")
+	githubToken := config.Lookup(config.GitHubToken)
+	githubHost := config.Lookup(config.GHECAPIEndpoint)
 	if githubHost == "" {
 		githubHost = "api.github.com" // Default to api.github.com if not set
 	}
 	if githubToken == "" {
 		logger.Logger.Error("Missing required environment variable",
-			zap.String("variable", "GITHUB_GHEC_PAT"))
-		return nil, fmt.Errorf("GITHUB_GHEC_PAT environment variable is required")
+			zap.String("variable", "GITHUB_PAT"))
+		return nil, fmt.Errorf("GITHUB_PAT environment variable is required")
 	}
 
 	url := fmt.Sprintf("https://%s/orgs/%s/migrations", githubHost, orgName)
@@ -37,7 +40,7 @@ func ExportRepositories(orgName string, input GHECExportInput) (*GHECExportRespo
 		return nil, fmt.Errorf("failed to marshal request body: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -93,19 +96,19 @@ func ExportRepositories(orgName string, input GHECExportInput) (*GHECExportRespo
 	return &exportResp, nil
 }
 
-func GetExportStatus(orgName string, migrationId int64) (*GHECExportResponse, error) {
-	githubToken := os.Getenv("GITHUB_GHEC_PAT")
+func GetExportStatus(ctx context.Context, orgName string, migrationId int64) (*GHECExportResponse, error) {
+	githubToken := config.Lookup(config.GitHubToken)
 	githubHost := "api.github.com" // GHEC always uses api.github.com
 
 	if githubToken == "" {
 		logger.Logger.Error("Missing required environment variable",
-			zap.String("variable", "GITHUB_GHEC_PAT"))
-		return nil, fmt.Errorf("GITHUB_GHEC_PAT environment variable is required")
+			zap.String("variable", "GITHUB_PAT"))
+		return nil, fmt.Errorf("GITHUB_PAT environment variable is required")
 	}
 
 	url := fmt.Sprintf("https://%s/orgs/%s/migrations/%d", githubHost, orgName, migrationId)
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -156,7 +159,7 @@ func GetExportStatus(orgName string, migrationId int64) (*GHECExportResponse, er
 	return &status, nil
 }
 
-func WaitForExportCompletion(orgName string, migrationId int64, timeout time.Duration, outputPath string) (*GHECExportResponse, error) {
+func WaitForExportCompletion(ctx context.Context, orgName string, migrationId int64, timeout time.Duration, outputPath string) (*GHECExportResponse, error) {
 	logger.Logger.Info("Waiting for export to complete",
 		zap.String("organization", orgName),
 		zap.Int64("migration_id", migrationId))
@@ -168,10 +171,12 @@ func WaitForExportCompletion(orgName string, migrationId int64, timeout time.Dur
 
 	for {
 		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("stopped waiting for export %d: %w", migrationId, ctx.Err())
 		case <-timeoutChan:
-			return nil, fmt.Errorf("timeout waiting for export to complete")
+			return nil, failure.Timeout(fmt.Errorf("timeout waiting for export to complete"))
 		case <-ticker.C:
-			status, err := GetExportStatus(orgName, migrationId)
+			status, err := GetExportStatus(ctx, orgName, migrationId)
 			if err != nil {
 				return nil, err
 			}
@@ -182,7 +187,7 @@ func WaitForExportCompletion(orgName string, migrationId int64, timeout time.Dur
 
 			switch status.State {
 			case "exported":
-				if err := DownloadExportArchive(orgName, migrationId, outputPath); err != nil {
+				if err := DownloadExportArchive(ctx, orgName, migrationId, outputPath); err != nil {
 					return nil, fmt.Errorf("failed to download archive: %w", err)
 				}
 				return status, nil
@@ -194,13 +199,13 @@ func WaitForExportCompletion(orgName string, migrationId int64, timeout time.Dur
 }
 
 // DownloadExportArchive downloads the migration archive to the specified path
-func DownloadExportArchive(orgName string, migrationId int64, outputPath string) error {
-	githubToken := os.Getenv("GITHUB_GHEC_PAT")
+func DownloadExportArchive(ctx context.Context, orgName string, migrationId int64, outputPath string) error {
+	githubToken := config.Lookup(config.GitHubToken)
 	githubHost := "api.github.com"
 
 	url := fmt.Sprintf("https://%s/orgs/%s/migrations/%d/archive", githubHost, orgName, migrationId)
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
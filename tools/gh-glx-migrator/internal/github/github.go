@@ -12,7 +12,9 @@ import (
 	"strings"
 	"time"
 
-	"github.com/ps-resources/gh-glx-migrator/internal/clients"
+	"github.com/ps-resources/gh-glx-migrator/internal/config"
+	"github.com/ps-resources/gh-glx-migrator/internal/failure"
+	"github.com/ps-resources/gh-glx-migrator/internal/telemetry"
 	ghlog "github.com/ps-resources/gh-glx-migrator/pkg/logger"
 
 	"github.com/cheggaaa/pb/v3"
@@ -24,22 +26,26 @@ const (
 	DefaultMultipartThreshold int64 = 5000 * 1024 * 1024 // 5 GB
 )
 
-func GetOrgInfo(orgName string) (interface{}, error) {
-	ghlog.Logger.Info("Getting organization information from GitHub")
-
-	// Get environment variables
-	githubToken := os.Getenv("GITHUB_PAT")
-	githubHost := os.Getenv("GITHUB_API_ENDPOINT")
+// MigrationPollInterval is how often VerifyMigrationStatus polls the state of
+// a migration.
+var MigrationPollInterval = 10 * time.Second
 
-	if githubHost == "" {
-		githubHost = "api.github.com"
+// GetOrgInfo calls Target.GetOrgInfo on the DefaultTarget.
+func GetOrgInfo(ctx context.Context, orgName string) (interface{}, error) {
+	target, err := DefaultTarget()
+	if err != nil {
+		return nil, err
 	}
+	return target.GetOrgInfo(ctx, orgName)
+}
 
-	githubClient := clients.NewGitHubClient(githubToken)
-	client, err := githubClient.GitHubAuth()
+func (t *Target) GetOrgInfo(ctx context.Context, orgName string) (interface{}, error) {
+	ghlog.Logger.Info("Getting organization information from GitHub")
 
+	githubHost := t.apiHost()
+	client, err := t.httpClient()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create GitHub client: %v", err)
+		return nil, err
 	}
 
 	query := `
@@ -66,7 +72,7 @@ func GetOrgInfo(orgName string) (interface{}, error) {
 
 	url := fmt.Sprintf("https://%s/graphql", githubHost)
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create HTTP request: %v", err)
 	}
@@ -74,18 +80,16 @@ func GetOrgInfo(orgName string) (interface{}, error) {
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", "gh-glx-migrator")
 
-	resp, err := client.Client().Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make GraphQL request: %v", err)
+		return nil, fmt.Errorf("failed to make GraphQL request: %w", err)
 	}
 
 	// if the response status is not 200, show error message
 	// and the response body and return an error
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected response status: %d, body: %s", resp.StatusCode, func() string {
-			body, _ := io.ReadAll(resp.Body)
-			return string(body)
-		}())
+		body, _ := io.ReadAll(resp.Body)
+		return nil, statusError(resp.StatusCode, body)
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
@@ -111,7 +115,20 @@ func GetOrgInfo(orgName string) (interface{}, error) {
 	return &response.Data, nil
 }
 
+// UploadArchiveToGitHub uploads an archive to the GitHub-owned storage of the
+// configured GitHub, authenticated with input.Client.
 func UploadArchiveToGitHub(ctx context.Context, input UploadArchiveInput) (string, error) {
+	target := &Target{
+		UploadsHost: config.Lookup(config.GitHubUploadsEndpoint),
+		Client:      input.Client,
+	}
+	return target.UploadArchive(ctx, input)
+}
+
+// UploadArchive uploads an archive to the GitHub-owned storage of t. The
+// upload is authenticated with input.Client, or the client of t when that is
+// not set.
+func (t *Target) UploadArchive(ctx context.Context, input UploadArchiveInput) (string, error) {
 	archiveFilePath := input.ArchiveFilePath
 	orgId := input.OrganizationId
 
@@ -136,27 +153,44 @@ func UploadArchiveToGitHub(ctx context.Context, input UploadArchiveInput) (strin
 		return "", logAndReturnError(archiveFilePath, fmt.Errorf("failed to reset file position: %w", err))
 	}
 
-	if size < DefaultMultipartThreshold {
-		return simpleUpload(ctx, orgId, reader, size)
+	// Both upload paths use the one client, so parts and finalization are
+	// authenticated with the same credential as the start of the upload.
+	githubClient := input.Client
+	if githubClient == nil {
+		githubClient = t.Client
+	}
+	if githubClient == nil {
+		return "", fmt.Errorf("no GitHub client to upload the archive with")
+	}
+	client, err := githubClient.GitHubAuth()
+	if err != nil {
+		return "", fmt.Errorf("failed to create GitHub client: %w", err)
+	}
+
+	threshold := input.MultipartThreshold
+	if threshold <= 0 {
+		threshold = DefaultMultipartThreshold
+	}
+	partSize := input.PartSize
+	if partSize <= 0 {
+		partSize = DefaultPartSize
+	}
+
+	uploadsURL := "https://" + t.uploadsHost()
+	if size < threshold {
+		return simpleUpload(ctx, client.Client(), uploadsURL, orgId, reader, size)
 	}
-	return multipartUpload(ctx, orgId, reader, size)
+	return multipartUpload(ctx, client.Client(), uploadsURL, orgId, reader, size, partSize)
 }
 
-func simpleUpload(ctx context.Context, orgId string, reader io.ReadSeeker, size int64) (string, error) {
+func simpleUpload(ctx context.Context, client *http.Client, uploadsURL, orgId string, reader io.ReadSeeker, size int64) (string, error) {
 	ghlog.Logger.Info("Uploading file to GitHub",
 		zap.String("orgId", fmt.Sprintf("%v", orgId)))
 
 	blobName := filepath.Base(reader.(*os.File).Name())
 
-	// Create a new GitHub client
-	githubClient := clients.NewGitHubClient(os.Getenv("GITHUB_PAT"))
-	client, err := githubClient.GitHubAuth()
-	if err != nil {
-		return "", fmt.Errorf("failed to create GitHub client: %v", err)
-	}
-
 	// Upload the file
-	url := fmt.Sprintf("https://uploads.github.com/organizations/%s/gei/archive?name=%s", orgId, blobName)
+	url := fmt.Sprintf("%s/organizations/%s/gei/archive?name=%s", uploadsURL, orgId, blobName)
 	req, err := http.NewRequestWithContext(ctx, "POST", url, reader)
 	if err != nil {
 		return "", logAndReturnError(blobName, fmt.Errorf("failed to create HTTP request: %w", err))
@@ -164,10 +198,9 @@ func simpleUpload(ctx context.Context, orgId string, reader io.ReadSeeker, size
 
 	req.Header.Set("Content-Type", "application/octet-stream")
 	req.Header.Set("User-Agent", "gh-glx-migrator")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", os.Getenv("GITHUB_PAT")))
 	req.ContentLength = size
 
-	resp, err := client.Client().Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		return "", logAndReturnError(blobName, fmt.Errorf("failed to upload file: %w", err))
 	}
@@ -180,7 +213,7 @@ func simpleUpload(ctx context.Context, orgId string, reader io.ReadSeeker, size
 	if resp.StatusCode != http.StatusCreated {
 		body, err := io.ReadAll(resp.Body)
 		if err != nil {
-			return "", fmt.Errorf("failed to read response body: %v", err)
+			return "", fmt.Errorf("failed to read response body: %w", err)
 		}
 		defer func() {
 			if err := resp.Body.Close(); err != nil {
@@ -193,7 +226,7 @@ func simpleUpload(ctx context.Context, orgId string, reader io.ReadSeeker, size
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		ghlog.Logger.Error("Failed to read response body", zap.Error(err))
-		return "", fmt.Errorf("failed to read response body: %v", err)
+		return "", fmt.Errorf("failed to read response body: %w", err)
 	}
 	var uploadArchiveResponse UploadArchiveResponse
 
@@ -206,19 +239,12 @@ func simpleUpload(ctx context.Context, orgId string, reader io.ReadSeeker, size
 	return uploadArchiveResponse.URI, nil
 }
 
-func multipartUpload(ctx context.Context, orgId string, reader io.ReadSeeker, size int64) (string, error) {
+func multipartUpload(ctx context.Context, client *http.Client, uploadsURL, orgId string, reader io.ReadSeeker, size, partSize int64) (uri string, err error) {
 	ghlog.Logger.Info("Uploading file to GitHub",
 		zap.String("orgId", fmt.Sprintf("%v", orgId)))
 
 	blobName := filepath.Base(reader.(*os.File).Name())
 
-	// Create a new GitHub client
-	githubClient := clients.NewGitHubClient(os.Getenv("GITHUB_TOKEN"))
-	client, err := githubClient.GitHubAuth()
-	if err != nil {
-		return "", fmt.Errorf("failed to create GitHub client: %v", err)
-	}
-
 	// Prepare JSON body
 	bodyData := map[string]interface{}{
 		"content_type": "application/octet-stream",
@@ -228,7 +254,7 @@ func multipartUpload(ctx context.Context, orgId string, reader io.ReadSeeker, si
 	jsonBody, _ := json.Marshal(bodyData)
 
 	// Start the upload
-	url := fmt.Sprintf("https://uploads.github.com/organizations/%s/gei/archive/blobs/uploads", orgId)
+	url := fmt.Sprintf("%s/organizations/%s/gei/archive/blobs/uploads", uploadsURL, orgId)
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonBody))
 	if err != nil {
 		return "", logAndReturnError(blobName, fmt.Errorf("failed to create HTTP request: %w", err))
@@ -237,12 +263,11 @@ func multipartUpload(ctx context.Context, orgId string, reader io.ReadSeeker, si
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", "gh-blob")
 	req.Header.Set("GraphQL-Features", "octoshift_github_owned_storage")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", os.Getenv("GITHUB_TOKEN")))
 
 	if err != nil {
 		return "", logAndReturnError(blobName, fmt.Errorf("failed to marshal JSON body: %w", err))
 	}
-	resp, err := client.Client().Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		return "", logAndReturnError(blobName, fmt.Errorf("failed to upload file: %w", err))
 	}
@@ -255,7 +280,7 @@ func multipartUpload(ctx context.Context, orgId string, reader io.ReadSeeker, si
 	if resp.StatusCode != http.StatusAccepted {
 		body, err := io.ReadAll(resp.Body)
 		if err != nil {
-			return "", fmt.Errorf("failed to read response body: %v", err)
+			return "", fmt.Errorf("failed to read response body: %w", err)
 		}
 		if err := resp.Body.Close(); err != nil {
 			ghlog.Logger.Error("failed to close response body", zap.Error(err))
@@ -264,7 +289,7 @@ func multipartUpload(ctx context.Context, orgId string, reader io.ReadSeeker, si
 	}
 
 	if _, err := io.ReadAll(resp.Body); err != nil {
-		return "", fmt.Errorf("failed to read response body: %v", err)
+		return "", fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	// get the Location header from the response
@@ -294,45 +319,52 @@ func multipartUpload(ctx context.Context, orgId string, reader io.ReadSeeker, si
 	ghlog.Logger.Info("Upload ID: " + uploadId)
 	ghlog.Logger.Info("GUID: " + guid)
 
-	// Upload file in parts of DefaultPartSize (100 MiB)
+	// Upload file in parts of partSize, DefaultPartSize (100 MiB) by default
 	partNumber := 1
 	var lastLocation = location
 	var nextLocation = location
 	var uploadedBytes int64 = 0
 
+	// An upload that is not finalized, e.g. because the command was
+	// interrupted, is aborted so its parts are not left behind.
+	defer func() {
+		if err != nil && nextLocation != "" {
+			abortMultipartUpload(ctx, client, uploadsURL+nextLocation)
+		}
+	}()
+
 	for uploadedBytes < size {
 		ghlog.Logger.Info(fmt.Sprintf("Uploading part %d", partNumber))
 		// Calculate the size of this part
-		partSize := DefaultPartSize
-		if size-uploadedBytes < partSize {
-			partSize = size - uploadedBytes
+		thisPartSize := partSize
+		if size-uploadedBytes < thisPartSize {
+			thisPartSize = size - uploadedBytes
 		}
 
 		// Read the part into memory
-		partBuf := make([]byte, partSize)
+		partBuf := make([]byte, thisPartSize)
 		n, err := reader.Read(partBuf)
 		if err != nil && err != io.EOF {
-			return "", fmt.Errorf("failed to read file part: %v", err)
+			return "", fmt.Errorf("failed to read file part: %w", err)
 		}
-		if int64(n) != partSize {
+		if int64(n) != thisPartSize {
 			partBuf = partBuf[:n]
 		}
 
 		// PATCH request to upload this part
-		uploadURL := "https://uploads.github.com" + nextLocation
+		uploadURL := uploadsURL + nextLocation
 		req, err := http.NewRequestWithContext(ctx, "PATCH", uploadURL, bytes.NewReader(partBuf))
 		if err != nil {
 			return "", fmt.Errorf("failed to create PATCH request: %v", err)
 		}
 		req.Header.Set("Content-Type", "application/octet-stream")
 		req.Header.Set("User-Agent", "gh-blob")
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", os.Getenv("GITHUB_TOKEN")))
 		req.Header.Set("GraphQL-Features", "octoshift_github_owned_storage")
 		req.ContentLength = int64(len(partBuf))
 
-		resp, err := client.Client().Do(req)
+		resp, err := client.Do(req)
 		if err != nil {
-			return "", fmt.Errorf("failed to upload part %d: %v", partNumber, err)
+			return "", fmt.Errorf("failed to upload part %d: %w", partNumber, err)
 		}
 		if resp.StatusCode != http.StatusAccepted {
 			body, _ := io.ReadAll(resp.Body)
@@ -360,19 +392,18 @@ func multipartUpload(ctx context.Context, orgId string, reader io.ReadSeeker, si
 
 	ghlog.Logger.Info("Finalizing upload...")
 	// Finalize the upload by sending a POST to the last location
-	finalizeURL := "https://uploads.github.com" + lastLocation
+	finalizeURL := uploadsURL + lastLocation
 	finalizeReq, err := http.NewRequestWithContext(ctx, "PUT", finalizeURL, nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to create finalize request: %v", err)
 	}
 	finalizeReq.Header.Set("Content-Type", "application/octet-stream")
 	finalizeReq.Header.Set("User-Agent", "gh-blob")
-	finalizeReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", os.Getenv("GITHUB_TOKEN")))
 	finalizeReq.Header.Set("GraphQL-Features", "octoshift_github_owned_storage")
 
-	finalizeResp, err := client.Client().Do(finalizeReq)
+	finalizeResp, err := client.Do(finalizeReq)
 	if err != nil {
-		return "", fmt.Errorf("failed to finalize upload: %v", err)
+		return "", fmt.Errorf("failed to finalize upload: %w", err)
 	}
 	defer func() {
 		if err := finalizeResp.Body.Close(); err != nil {
@@ -387,7 +418,7 @@ func multipartUpload(ctx context.Context, orgId string, reader io.ReadSeeker, si
 
 	body, err := io.ReadAll(finalizeResp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read finalize response body: %v", err)
+		return "", fmt.Errorf("failed to read finalize response body: %w", err)
 	}
 
 	var uploadArchiveResponse UploadArchiveResponse
@@ -406,6 +437,30 @@ func multipartUpload(ctx context.Context, orgId string, reader io.ReadSeeker, si
 	return uploadArchiveResponse.URI, nil
 }
 
+// abortMultipartUpload deletes the unfinished multipart upload to GitHub-owned
+// storage at url. It runs after ctx may have been cancelled, so it uses a
+// context of its own, and failures are only logged.
+func abortMultipartUpload(ctx context.Context, client *http.Client, url string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
+	if err != nil {
+		ghlog.Logger.Error("Failed to abort multipart upload", zap.Error(err))
+		return
+	}
+	req.Header.Set("User-Agent", "gh-blob")
+	req.Header.Set("GraphQL-Features", "octoshift_github_owned_storage")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		ghlog.Logger.Error("Failed to abort multipart upload", zap.Error(err))
+		return
+	}
+	_ = resp.Body.Close()
+	ghlog.Logger.Info("Aborted multipart upload to GitHub-owned storage", zap.Int("status", resp.StatusCode))
+}
+
 func logAndReturnError(blobName string, err error) error {
 	ghlog.Logger.Error("GitHub upload operation failed",
 		zap.String("blobName", blobName),
@@ -414,7 +469,16 @@ func logAndReturnError(blobName string, err error) error {
 	return fmt.Errorf("upload failed: %w", err)
 }
 
-func CreateMigrationSource(input MigrationSourceInput) (*MigrationSourceResponse, error) {
+// CreateMigrationSource calls Target.CreateMigrationSource on the DefaultTarget.
+func CreateMigrationSource(ctx context.Context, input MigrationSourceInput) (*MigrationSourceResponse, error) {
+	target, err := DefaultTarget()
+	if err != nil {
+		return nil, err
+	}
+	return target.CreateMigrationSource(ctx, input)
+}
+
+func (t *Target) CreateMigrationSource(ctx context.Context, input MigrationSourceInput) (*MigrationSourceResponse, error) {
 	// Ensure URL has proper scheme
 	if !strings.HasPrefix(input.URL, "http://") && !strings.HasPrefix(input.URL, "https://") {
 		input.URL = "https://" + input.URL
@@ -425,20 +489,10 @@ func CreateMigrationSource(input MigrationSourceInput) (*MigrationSourceResponse
 		zap.String("url", input.URL),
 		zap.String("ownerId", input.OwnerID))
 
-	// Get environment variables
-	githubToken := os.Getenv("GITHUB_PAT")
-	githubHost := os.Getenv("GITHUB_API_ENDPOINT")
-
-	if githubHost == "" {
-		githubHost = "api.github.com"
-	}
-
-	// Initialize GitHub client with proper headers
-	githubClient := clients.NewGitHubClient(githubToken)
-	client, err := githubClient.GitHubAuth()
-
+	githubHost := t.apiHost()
+	client, err := t.httpClient()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create GitHub client: %v", err)
+		return nil, err
 	}
 
 	mutation := `
@@ -484,31 +538,28 @@ func CreateMigrationSource(input MigrationSourceInput) (*MigrationSourceResponse
 
 	url := fmt.Sprintf("https://%s/graphql", githubHost)
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		ghlog.Logger.Error("Failed to create HTTP request", zap.Error(err))
 		return nil, fmt.Errorf("failed to create HTTP request: %v", err)
 	}
 
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", githubToken))
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", "gh-glx-migrator")
 	req.Header.Set("GraphQL-Features", "octoshift_gl_exporter")
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 
-	resp, err := client.Client().Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		ghlog.Logger.Error("Failed to make GraphQL request", zap.Error(err))
-		return nil, fmt.Errorf("failed to make GraphQL request: %v", err)
+		return nil, fmt.Errorf("failed to make GraphQL request: %w", err)
 	}
 
 	// if the response status is not 200, show error message
 	// and the response body and return an error
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected response status: %d, body: %s", resp.StatusCode, func() string {
-			body, _ := io.ReadAll(resp.Body)
-			return string(body)
-		}())
+		body, _ := io.ReadAll(resp.Body)
+		return nil, statusError(resp.StatusCode, body)
 	}
 
 	defer func() {
@@ -520,7 +571,7 @@ func CreateMigrationSource(input MigrationSourceInput) (*MigrationSourceResponse
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		ghlog.Logger.Error("Failed to read response body", zap.Error(err))
-		return nil, fmt.Errorf("failed to read response body: %v", err)
+		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	ghlog.Logger.Debug("Raw response", zap.String("body", string(body)))
@@ -555,25 +606,24 @@ func CreateMigrationSource(input MigrationSourceInput) (*MigrationSourceResponse
 	return &response.Data, nil
 }
 
-func StartMigration(input MigrationInput) (*MigrationResponse, error) {
+// StartMigration calls Target.StartMigration on the DefaultTarget.
+func StartMigration(ctx context.Context, input MigrationInput) (*MigrationResponse, error) {
+	target, err := DefaultTarget()
+	if err != nil {
+		return nil, err
+	}
+	return target.StartMigration(ctx, input)
+}
+
+func (t *Target) StartMigration(ctx context.Context, input MigrationInput) (*MigrationResponse, error) {
 	ghlog.Logger.Info("Starting repository migration",
 		zap.String("repository", input.RepositoryName),
 		zap.String("source", input.SourceRepositoryURL))
 
-	// Get environment variables
-	githubToken := os.Getenv("GITHUB_PAT")
-	githubHost := os.Getenv("GITHUB_API_ENDPOINT")
-
-	if githubHost == "" {
-		githubHost = "api.github.com"
-	}
-
-	// Initialize GitHub client
-	githubClient := clients.NewGitHubClient(githubToken)
-	client, err := githubClient.GitHubAuth()
-
+	githubHost := t.apiHost()
+	client, err := t.httpClient()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create GitHub client: %v", err)
+		return nil, err
 	}
 
 	mutation := `
@@ -630,31 +680,28 @@ func StartMigration(input MigrationInput) (*MigrationResponse, error) {
 	}
 
 	url := fmt.Sprintf("https://%s/graphql", githubHost)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create HTTP request: %v", err)
 	}
 
 	// Set headers
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", githubToken))
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", "gh-glx-migrator")
 	req.Header.Set("GraphQL-Features", "octoshift_gl_exporter")
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 
 	// Make request
-	resp, err := client.Client().Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make GraphQL request: %v", err)
+		return nil, fmt.Errorf("failed to make GraphQL request: %w", err)
 	}
 
 	// if the response status is not 200, show error message
 	// and the response body and return an error
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected response status: %d, body: %s", resp.StatusCode, func() string {
-			body, _ := io.ReadAll(resp.Body)
-			return string(body)
-		}())
+		body, _ := io.ReadAll(resp.Body)
+		return nil, statusError(resp.StatusCode, body)
 	}
 
 	defer func() {
@@ -666,7 +713,7 @@ func StartMigration(input MigrationInput) (*MigrationResponse, error) {
 	// Read response body
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %v", err)
+		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	// Parse response
@@ -696,20 +743,22 @@ func StartMigration(input MigrationInput) (*MigrationResponse, error) {
 	return &response.Data, nil
 }
 
-func VerifyMigrationStatus(migrationID string, timeout time.Duration) (*MigrationState, error) {
-	// Initialize GitHub client
-
-	// Get environment variables
-	githubToken := os.Getenv("GITHUB_PAT")
-	githubHost := os.Getenv("GITHUB_API_ENDPOINT")
-	if githubHost == "" {
-		githubHost = "api.github.com"
+// VerifyMigrationStatus calls Target.VerifyMigrationStatus on the DefaultTarget.
+func VerifyMigrationStatus(ctx context.Context, migrationID string, timeout time.Duration) (*MigrationState, error) {
+	target, err := DefaultTarget()
+	if err != nil {
+		return nil, err
 	}
+	return target.VerifyMigrationStatus(ctx, migrationID, timeout)
+}
 
-	githubClient := clients.NewGitHubClient(githubToken)
-	client, err := githubClient.GitHubAuth()
+func (t *Target) VerifyMigrationStatus(ctx context.Context, migrationID string, timeout time.Duration) (*MigrationState, error) {
+	// Initialize GitHub client
+
+	githubHost := t.apiHost()
+	client, err := t.httpClient()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create GitHub client: %v", err)
+		return nil, err
 	}
 
 	// Create progress bar
@@ -721,10 +770,10 @@ func VerifyMigrationStatus(migrationID string, timeout time.Duration) (*Migratio
 	bar.Start()
 	defer bar.Finish()
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	ticker := time.NewTicker(10 * time.Second)
+	ticker := time.NewTicker(MigrationPollInterval)
 	defer ticker.Stop()
 
 	query := `query($id: ID!) {
@@ -740,15 +789,21 @@ func VerifyMigrationStatus(migrationID string, timeout time.Duration) (*Migratio
 							state
 							failureReason
 							repositoryName
+							warningsCount
+							migrationLogUrl
 					}
 			}
 	}`
 
 	for {
 		select {
-		case <-ctx.Done():
+		case <-waitCtx.Done():
+			if err := ctx.Err(); err != nil {
+				bar.Set("prefix", "\033[31mCancelled\033[0m")
+				return nil, fmt.Errorf("stopped waiting for migration %s: %w", migrationID, err)
+			}
 			bar.Set("prefix", "\033[31mTimeout\033[0m")
-			return nil, fmt.Errorf("timeout waiting for migration to complete")
+			return nil, failure.Timeout(fmt.Errorf("timeout waiting for migration %s to complete after %s", migrationID, timeout))
 		case <-ticker.C:
 			requestBody := map[string]interface{}{
 				"query": query,
@@ -763,18 +818,17 @@ func VerifyMigrationStatus(migrationID string, timeout time.Duration) (*Migratio
 			}
 
 			url := fmt.Sprintf("https://%s/graphql", githubHost)
-			req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonBody))
+			req, err := http.NewRequestWithContext(waitCtx, "POST", url, bytes.NewBuffer(jsonBody))
 			if err != nil {
 				return nil, fmt.Errorf("failed to create request: %v", err)
 			}
 
-			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", githubToken))
 			req.Header.Set("Content-Type", "application/json")
 			req.Header.Set("User-Agent", "gh-glx-migrator")
 
-			resp, err := client.Client().Do(req)
+			resp, err := client.Do(req)
 			if err != nil {
-				return nil, fmt.Errorf("failed to make request: %v", err)
+				return nil, fmt.Errorf("failed to make request: %w", err)
 			}
 
 			var response struct {
@@ -800,23 +854,33 @@ func VerifyMigrationStatus(migrationID string, timeout time.Duration) (*Migratio
 
 			state := response.Data.Node.State
 			switch state {
-			case "PENDING":
+			case MigrationStateNotStarted:
+				bar.Set("prefix", "Not Started")
+				bar.SetCurrent(10)
+			case "PENDING", MigrationStatePendingValidation:
 				bar.Set("prefix", "Validating")
 				bar.SetCurrent(25)
-			case "QUEUED":
+			case MigrationStateQueued:
 				bar.Set("prefix", "Queued")
 				bar.SetCurrent(50)
-			case "IN_PROGRESS":
+			case MigrationStateInProgress:
 				bar.Set("prefix", "In Progress")
 				bar.SetCurrent(75)
-			case "SUCCEEDED":
+			case MigrationStateSucceeded:
 				bar.Set("prefix", "\033[32mCompleted\033[0m")
 				bar.SetCurrent(100)
+				telemetry.RecordMigration(state)
 				return &response.Data, nil
-			case "FAILED":
+			case MigrationStateFailedValidation:
+				bar.Set("prefix", "\033[31mValidation Failed\033[0m")
+				bar.SetCurrent(100)
+				telemetry.RecordMigration(state)
+				return &response.Data, failure.MigrationFailed(fmt.Errorf("migration failed validation: %s", response.Data.Node.FailureReason))
+			case MigrationStateFailed:
 				bar.Set("prefix", "\033[31mFailed\033[0m")
 				bar.SetCurrent(100)
-				return &response.Data, fmt.Errorf("migration failed: %s", response.Data.Node.FailureReason)
+				telemetry.RecordMigration(state)
+				return &response.Data, failure.MigrationFailed(fmt.Errorf("migration failed: %s", response.Data.Node.FailureReason))
 			}
 		}
 	}
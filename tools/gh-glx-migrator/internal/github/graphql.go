@@ -0,0 +1,127 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/ps-resources/gh-glx-migrator/internal/failure"
+	ghlog "github.com/ps-resources/gh-glx-migrator/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// graphQLPageSize is the number of nodes requested per page of a connection.
+const graphQLPageSize = 100
+
+// graphQLError is a single entry of the "errors" array in a GraphQL response.
+type graphQLError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+}
+
+// doGraphQL sends a query or mutation to the GraphQL API of the
+// DefaultTarget.
+func doGraphQL(ctx context.Context, operationName, query string, variables map[string]interface{}, out interface{}) error {
+	target, err := DefaultTarget()
+	if err != nil {
+		return err
+	}
+	return target.graphQL(ctx, operationName, query, variables, out)
+}
+
+// graphQL sends a query or mutation to the GitHub GraphQL API using the same
+// credentials and headers as CreateMigrationSource, and decodes the "data"
+// member of the response into out.
+func (t *Target) graphQL(ctx context.Context, operationName, query string, variables map[string]interface{}, out interface{}) error {
+	githubHost := t.apiHost()
+	client, err := t.httpClient()
+	if err != nil {
+		return err
+	}
+
+	requestBody := map[string]interface{}{
+		"query":         query,
+		"variables":     variables,
+		"operationName": operationName,
+	}
+
+	jsonBody, err := json.Marshal(requestBody)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request body: %v", err)
+	}
+
+	url := fmt.Sprintf("https://%s/graphql", githubHost)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gh-glx-migrator")
+	req.Header.Set("GraphQL-Features", "octoshift_gl_exporter")
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make GraphQL request: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			ghlog.Logger.Error("failed to close response body", zap.Error(err))
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return statusError(resp.StatusCode, body)
+	}
+
+	var response struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []graphQLError  `json:"errors,omitempty"`
+	}
+
+	if err := json.Unmarshal(body, &response); err != nil {
+		return fmt.Errorf("failed to decode response: %v", err)
+	}
+
+	if len(response.Errors) > 0 {
+		ghlog.Logger.Error("GraphQL request returned an error",
+			zap.String("operation", operationName),
+			zap.String("error", response.Errors[0].Message),
+			zap.String("type", response.Errors[0].Type))
+		err := fmt.Errorf("GraphQL error: %s", response.Errors[0].Message)
+		if response.Errors[0].Type == "FORBIDDEN" {
+			return failure.Auth(err)
+		}
+		return err
+	}
+
+	if out == nil || len(response.Data) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(response.Data, out); err != nil {
+		return fmt.Errorf("failed to decode response data: %v", err)
+	}
+
+	return nil
+}
+
+// statusError describes an unexpected response status. A 401 or 403 is an
+// auth failure: the token is invalid or lacks a permission.
+func statusError(status int, body []byte) error {
+	err := fmt.Errorf("unexpected response status: %d, body: %s", status, string(body))
+	if status == http.StatusUnauthorized || status == http.StatusForbidden {
+		return failure.Auth(err)
+	}
+	return err
+}
@@ -0,0 +1,152 @@
+package github
+
+import (
+	"context"
+	"fmt"
+
+	ghlog "github.com/ps-resources/gh-glx-migrator/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// ListMannequins returns every mannequin of an organization. Mannequins are the
+// placeholder users GitHub creates for source authors during a migration.
+func ListMannequins(ctx context.Context, orgName string) ([]Mannequin, error) {
+	ghlog.Logger.Info("Listing mannequins",
+		zap.String("organization", orgName))
+
+	query := `
+	query listMannequins($login: String!, $first: Int!, $after: String) {
+			organization(login: $login) {
+					mannequins(first: $first, after: $after) {
+							pageInfo {
+									hasNextPage
+									endCursor
+							}
+							nodes {
+									id
+									login
+									claimant {
+											id
+											login
+									}
+							}
+					}
+			}
+	}`
+
+	variables := map[string]interface{}{
+		"login": orgName,
+		"first": graphQLPageSize,
+	}
+
+	var mannequins []Mannequin
+	for {
+		var response MannequinsResponse
+		if err := doGraphQL(ctx, "listMannequins", query, variables, &response); err != nil {
+			return nil, fmt.Errorf("failed to list mannequins: %w", err)
+		}
+
+		connection := response.Organization.Mannequins
+		mannequins = append(mannequins, connection.Nodes...)
+
+		if !connection.PageInfo.HasNextPage {
+			break
+		}
+		variables["after"] = connection.PageInfo.EndCursor
+	}
+
+	ghlog.Logger.Info("Listed mannequins",
+		zap.String("organization", orgName),
+		zap.Int("count", len(mannequins)))
+
+	return mannequins, nil
+}
+
+// GetUserID resolves a GitHub login to its GraphQL node ID.
+func GetUserID(ctx context.Context, login string) (string, error) {
+	query := `
+	query getUserId($login: String!) {
+			user(login: $login) {
+					id
+			}
+	}`
+
+	var response struct {
+		User *struct {
+			ID string `json:"id"`
+		} `json:"user"`
+	}
+
+	if err := doGraphQL(ctx, "getUserId", query, map[string]interface{}{"login": login}, &response); err != nil {
+		return "", fmt.Errorf("failed to look up user %s: %w", login, err)
+	}
+	if response.User == nil || response.User.ID == "" {
+		return "", fmt.Errorf("user %s not found", login)
+	}
+
+	return response.User.ID, nil
+}
+
+// ReclaimMannequin attributes the contributions of a mannequin to a GitHub user.
+// By default the user receives an attribution invitation they must accept.
+// With SkipInvitation the contributions are reattributed immediately, which is
+// only allowed in organizations owned by an Enterprise Managed Users enterprise.
+func ReclaimMannequin(ctx context.Context, input ReclaimMannequinInput) error {
+	ghlog.Logger.Info("Reclaiming mannequin",
+		zap.String("mannequin_id", input.MannequinID),
+		zap.String("target_id", input.TargetUserID),
+		zap.Bool("skip_invitation", input.SkipInvitation))
+
+	operationName := "createAttributionInvitation"
+	mutation := `
+	mutation createAttributionInvitation($ownerId: ID!, $sourceId: ID!, $targetId: ID!) {
+			createAttributionInvitation(input: { ownerId: $ownerId, sourceId: $sourceId, targetId: $targetId }) {
+					source {
+							... on Mannequin {
+									id
+									login
+							}
+					}
+					target {
+							... on User {
+									id
+									login
+							}
+					}
+			}
+	}`
+
+	if input.SkipInvitation {
+		operationName = "reattributeMannequinToUser"
+		mutation = `
+	mutation reattributeMannequinToUser($ownerId: ID!, $sourceId: ID!, $targetId: ID!) {
+			reattributeMannequinToUser(input: { ownerId: $ownerId, sourceId: $sourceId, targetId: $targetId }) {
+					source {
+							... on Mannequin {
+									id
+									login
+							}
+					}
+					target {
+							... on User {
+									id
+									login
+							}
+					}
+			}
+	}`
+	}
+
+	variables := map[string]interface{}{
+		"ownerId":  input.OwnerID,
+		"sourceId": input.MannequinID,
+		"targetId": input.TargetUserID,
+	}
+
+	if err := doGraphQL(ctx, operationName, mutation, variables, nil); err != nil {
+		return fmt.Errorf("failed to reclaim mannequin %s: %w", input.MannequinID, err)
+	}
+
+	return nil
+}
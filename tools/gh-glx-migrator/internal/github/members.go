@@ -0,0 +1,193 @@
+package github
+
+import (
+	"context"
+	"fmt"
+
+	ghlog "github.com/ps-resources/gh-glx-migrator/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// ListOrgMembers returns the members of an organization together with their
+// email addresses on the organization's verified domains.
+func ListOrgMembers(ctx context.Context, orgName string) ([]OrgMember, error) {
+	ghlog.Logger.Info("Listing organization members",
+		zap.String("organization", orgName))
+
+	query := `
+	query listOrgMembers($login: String!, $first: Int!, $after: String) {
+			organization(login: $login) {
+					membersWithRole(first: $first, after: $after) {
+							pageInfo {
+									hasNextPage
+									endCursor
+							}
+							nodes {
+									login
+									name
+									organizationVerifiedDomainEmails(login: $login)
+							}
+					}
+			}
+	}`
+
+	variables := map[string]interface{}{
+		"login": orgName,
+		"first": graphQLPageSize,
+	}
+
+	var members []OrgMember
+	for {
+		var response struct {
+			Organization struct {
+				MembersWithRole struct {
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+					Nodes []OrgMember `json:"nodes"`
+				} `json:"membersWithRole"`
+			} `json:"organization"`
+		}
+		if err := doGraphQL(ctx, "listOrgMembers", query, variables, &response); err != nil {
+			return nil, fmt.Errorf("failed to list organization members: %w", err)
+		}
+
+		connection := response.Organization.MembersWithRole
+		members = append(members, connection.Nodes...)
+
+		if !connection.PageInfo.HasNextPage {
+			break
+		}
+		variables["after"] = connection.PageInfo.EndCursor
+	}
+
+	ghlog.Logger.Info("Listed organization members",
+		zap.String("organization", orgName),
+		zap.Int("count", len(members)))
+
+	return members, nil
+}
+
+// ListExternalIdentities returns the SAML and SCIM identities linked to members
+// of an organization. It returns no identities when the organization does not
+// use SAML single sign-on.
+func ListExternalIdentities(ctx context.Context, orgName string) ([]ExternalIdentity, error) {
+	ghlog.Logger.Info("Listing organization external identities",
+		zap.String("organization", orgName))
+
+	query := `
+	query listExternalIdentities($login: String!, $first: Int!, $after: String) {
+			organization(login: $login) {
+					samlIdentityProvider {
+							externalIdentities(first: $first, after: $after) {
+									pageInfo {
+											hasNextPage
+											endCursor
+									}
+									nodes {
+											user {
+													login
+											}
+											samlIdentity {
+													nameId
+													username
+													emails {
+															value
+													}
+											}
+											scimIdentity {
+													username
+													emails {
+															value
+													}
+											}
+									}
+							}
+					}
+			}
+	}`
+
+	type identityEmail struct {
+		Value string `json:"value"`
+	}
+
+	variables := map[string]interface{}{
+		"login": orgName,
+		"first": graphQLPageSize,
+	}
+
+	var identities []ExternalIdentity
+	for {
+		var response struct {
+			Organization struct {
+				SAMLIdentityProvider *struct {
+					ExternalIdentities struct {
+						PageInfo struct {
+							HasNextPage bool   `json:"hasNextPage"`
+							EndCursor   string `json:"endCursor"`
+						} `json:"pageInfo"`
+						Nodes []struct {
+							User *struct {
+								Login string `json:"login"`
+							} `json:"user"`
+							SAMLIdentity *struct {
+								NameID   string          `json:"nameId"`
+								Username string          `json:"username"`
+								Emails   []identityEmail `json:"emails"`
+							} `json:"samlIdentity"`
+							SCIMIdentity *struct {
+								Username string          `json:"username"`
+								Emails   []identityEmail `json:"emails"`
+							} `json:"scimIdentity"`
+						} `json:"nodes"`
+					} `json:"externalIdentities"`
+				} `json:"samlIdentityProvider"`
+			} `json:"organization"`
+		}
+		if err := doGraphQL(ctx, "listExternalIdentities", query, variables, &response); err != nil {
+			return nil, fmt.Errorf("failed to list external identities: %w", err)
+		}
+
+		provider := response.Organization.SAMLIdentityProvider
+		if provider == nil {
+			ghlog.Logger.Info("Organization does not use SAML single sign-on",
+				zap.String("organization", orgName))
+			return nil, nil
+		}
+
+		for _, node := range provider.ExternalIdentities.Nodes {
+			// Identities that are not linked to a GitHub account cannot be mapped.
+			if node.User == nil {
+				continue
+			}
+			identity := ExternalIdentity{Login: node.User.Login}
+			if node.SAMLIdentity != nil {
+				identity.SAMLNameID = node.SAMLIdentity.NameID
+				identity.SAMLUsername = node.SAMLIdentity.Username
+				for _, email := range node.SAMLIdentity.Emails {
+					identity.SAMLEmails = append(identity.SAMLEmails, email.Value)
+				}
+			}
+			if node.SCIMIdentity != nil {
+				identity.SCIMUsername = node.SCIMIdentity.Username
+				for _, email := range node.SCIMIdentity.Emails {
+					identity.SCIMEmails = append(identity.SCIMEmails, email.Value)
+				}
+			}
+			identities = append(identities, identity)
+		}
+
+		if !provider.ExternalIdentities.PageInfo.HasNextPage {
+			break
+		}
+		variables["after"] = provider.ExternalIdentities.PageInfo.EndCursor
+	}
+
+	ghlog.Logger.Info("Listed organization external identities",
+		zap.String("organization", orgName),
+		zap.Int("count", len(identities)))
+
+	return identities, nil
+}
@@ -0,0 +1,143 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	ghlog "github.com/ps-resources/gh-glx-migrator/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// Values of the GraphQL MigrationState enum.
+const (
+	MigrationStateNotStarted        = "NOT_STARTED"
+	MigrationStatePendingValidation = "PENDING_VALIDATION"
+	MigrationStateFailedValidation  = "FAILED_VALIDATION"
+	MigrationStateQueued            = "QUEUED"
+	MigrationStateInProgress        = "IN_PROGRESS"
+	MigrationStateSucceeded         = "SUCCEEDED"
+	MigrationStateFailed            = "FAILED"
+)
+
+// IsTerminalMigrationState reports whether a migration in the given state has
+// finished and will not change state again.
+func IsTerminalMigrationState(state string) bool {
+	switch state {
+	case MigrationStateSucceeded, MigrationStateFailed, MigrationStateFailedValidation:
+		return true
+	}
+	return false
+}
+
+// ListRepositoryMigrations returns the repository migrations of an organization,
+// newest first. Results are optionally filtered by state and by creation time.
+func ListRepositoryMigrations(ctx context.Context, input ListMigrationsInput) ([]RepositoryMigration, error) {
+	ghlog.Logger.Info("Listing repository migrations",
+		zap.String("organization", input.Organization),
+		zap.String("state", input.State))
+
+	query := `
+	query listRepositoryMigrations(
+			$login: String!
+			$first: Int!
+			$after: String
+			$state: MigrationState
+	) {
+			organization(login: $login) {
+					repositoryMigrations(
+							first: $first
+							after: $after
+							state: $state
+							orderBy: { field: CREATED_AT, direction: DESC }
+					) {
+							totalCount
+							pageInfo {
+									hasNextPage
+									endCursor
+							}
+							nodes {
+									id
+									databaseId
+									sourceUrl
+									repositoryName
+									state
+									failureReason
+									warningsCount
+									migrationLogUrl
+									createdAt
+							}
+					}
+			}
+	}`
+
+	variables := map[string]interface{}{
+		"login": input.Organization,
+		"first": graphQLPageSize,
+	}
+	if input.State != "" {
+		variables["state"] = strings.ToUpper(input.State)
+	}
+
+	var migrations []RepositoryMigration
+	for {
+		var response RepositoryMigrationsResponse
+		if err := doGraphQL(ctx, "listRepositoryMigrations", query, variables, &response); err != nil {
+			return nil, fmt.Errorf("failed to list repository migrations: %w", err)
+		}
+
+		connection := response.Organization.RepositoryMigrations
+		for _, migration := range connection.Nodes {
+			// Migrations are ordered newest first, so the first one older than
+			// the cut-off means every following page is older too.
+			if !input.Since.IsZero() && migration.CreatedAt.Before(input.Since) {
+				return migrations, nil
+			}
+			migrations = append(migrations, migration)
+		}
+
+		if !connection.PageInfo.HasNextPage {
+			break
+		}
+		variables["after"] = connection.PageInfo.EndCursor
+	}
+
+	ghlog.Logger.Debug("Listed repository migrations",
+		zap.String("organization", input.Organization),
+		zap.Int("count", len(migrations)))
+
+	return migrations, nil
+}
+
+// AbortRepositoryMigration asks GitHub to stop a repository migration that has
+// not finished yet.
+func AbortRepositoryMigration(ctx context.Context, migrationID string) error {
+	ghlog.Logger.Info("Aborting repository migration",
+		zap.String("migration_id", migrationID))
+
+	mutation := `
+	mutation abortRepositoryMigration($migrationId: ID!) {
+			abortRepositoryMigration(input: { migrationId: $migrationId }) {
+					success
+			}
+	}`
+
+	variables := map[string]interface{}{
+		"migrationId": migrationID,
+	}
+
+	var response AbortMigrationResponse
+	if err := doGraphQL(ctx, "abortRepositoryMigration", mutation, variables, &response); err != nil {
+		return fmt.Errorf("failed to abort migration %s: %w", migrationID, err)
+	}
+
+	if !response.AbortRepositoryMigration.Success {
+		return fmt.Errorf("GitHub did not abort migration %s", migrationID)
+	}
+
+	ghlog.Logger.Info("Aborted repository migration",
+		zap.String("migration_id", migrationID))
+
+	return nil
+}
@@ -0,0 +1,174 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	ghlog "github.com/ps-resources/gh-glx-migrator/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// MaxRepositoryNameLength is the longest repository name GitHub accepts.
+const MaxRepositoryNameLength = 100
+
+var repositoryNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// ValidateRepositoryName reports why GitHub would reject or rewrite a
+// repository name, or returns nil if the name can be used as is.
+func ValidateRepositoryName(name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("repository name is empty")
+	case len(name) > MaxRepositoryNameLength:
+		return fmt.Errorf("repository name %q is longer than %d characters", name, MaxRepositoryNameLength)
+	case name == "." || name == "..":
+		return fmt.Errorf("repository name %q is reserved", name)
+	case !repositoryNamePattern.MatchString(name):
+		return fmt.Errorf("repository name %q may only contain letters, digits, '.', '-' and '_'", name)
+	}
+	return nil
+}
+
+// Organization returns the login, node ID and database ID of an organization.
+func (t *Target) Organization(ctx context.Context, login string) (*OrgResponse, error) {
+	query := `
+	query getOrganization($login: String!) {
+			organization(login: $login) {
+					login
+					id
+					name
+					databaseId
+			}
+	}`
+
+	var response OrgResponse
+	if err := t.graphQL(ctx, "getOrganization", query, map[string]interface{}{"login": login}, &response); err != nil {
+		return nil, fmt.Errorf("failed to get organization %s: %w", login, err)
+	}
+	if response.Organization.ID == "" {
+		return nil, fmt.Errorf("organization %s not found", login)
+	}
+	return &response, nil
+}
+
+// OrganizationLogin returns the login of the organization with the given
+// node ID.
+func (t *Target) OrganizationLogin(ctx context.Context, id string) (string, error) {
+	query := `
+	query getOrganizationLogin($id: ID!) {
+			node(id: $id) {
+					... on Organization {
+							login
+					}
+			}
+	}`
+
+	var response struct {
+		Node *struct {
+			Login string `json:"login"`
+		} `json:"node"`
+	}
+	if err := t.graphQL(ctx, "getOrganizationLogin", query, map[string]interface{}{"id": id}, &response); err != nil {
+		return "", fmt.Errorf("failed to get organization %s: %w", id, err)
+	}
+	if response.Node == nil || response.Node.Login == "" {
+		return "", fmt.Errorf("organization %s not found", id)
+	}
+	return response.Node.Login, nil
+}
+
+// RepositoryExists reports whether org has a repository with the given name.
+// A renamed repository redirects to its new name, which does not take the old
+// one.
+func (t *Target) RepositoryExists(ctx context.Context, org, name string) (bool, error) {
+	client, err := t.httpClient()
+	if err != nil {
+		return false, err
+	}
+
+	var repository struct {
+		Name string `json:"name"`
+	}
+	repoURL := fmt.Sprintf("https://%s/repos/%s/%s", t.apiHost(), url.PathEscape(org), url.PathEscape(name))
+	_, status, err := getREST(ctx, client, repoURL, &repository)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up repository %s/%s: %w", org, name, err)
+	}
+	switch status {
+	case http.StatusOK:
+		return strings.EqualFold(repository.Name, name), nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to look up repository %s/%s: unexpected response status %d", org, name, status)
+}
+
+// OrgRepositories are the repositories of an organization of a Target, whose
+// names target repository names can collide with.
+type OrgRepositories struct {
+	Target *Target
+	Org    string
+}
+
+// Exists reports whether the organization has a repository with the name.
+func (r OrgRepositories) Exists(ctx context.Context, name string) (bool, error) {
+	return r.Target.RepositoryExists(ctx, r.Org, name)
+}
+
+// Names returns the names of all repositories of the organization.
+func (r OrgRepositories) Names(ctx context.Context) ([]string, error) {
+	return r.Target.ListOrgRepositoryNames(ctx, r.Org)
+}
+
+// ListOrgRepositoryNames returns the names of all repositories of an
+// organization.
+func (t *Target) ListOrgRepositoryNames(ctx context.Context, org string) ([]string, error) {
+	query := `
+	query listOrgRepositories($login: String!, $first: Int!, $after: String) {
+			organization(login: $login) {
+					repositories(first: $first, after: $after) {
+							pageInfo {
+									hasNextPage
+									endCursor
+							}
+							nodes {
+									name
+							}
+					}
+			}
+	}`
+
+	variables := map[string]interface{}{
+		"login": org,
+		"first": graphQLPageSize,
+	}
+
+	var names []string
+	for {
+		var response OrgRepositoriesResponse
+		if err := t.graphQL(ctx, "listOrgRepositories", query, variables, &response); err != nil {
+			return nil, fmt.Errorf("failed to list repositories of %s: %w", org, err)
+		}
+
+		connection := response.Organization.Repositories
+		for _, repo := range connection.Nodes {
+			names = append(names, repo.Name)
+		}
+
+		if !connection.PageInfo.HasNextPage {
+			break
+		}
+		variables["after"] = connection.PageInfo.EndCursor
+	}
+
+	ghlog.Logger.Debug("Listed organization repositories",
+		zap.String("organization", org),
+		zap.Int("count", len(names)))
+
+	return names, nil
+}
@@ -0,0 +1,73 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	ghlog "github.com/ps-resources/gh-glx-migrator/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// MigratorRoleInput identifies the user or team whose migrator role is changed.
+type MigratorRoleInput struct {
+	OrganizationID string
+	Actor          string
+	// ActorType is USER or TEAM.
+	ActorType string
+}
+
+// GrantMigratorRole allows a user or team to run migrations into an
+// organization without being an owner.
+func GrantMigratorRole(ctx context.Context, input MigratorRoleInput) error {
+	return setMigratorRole(ctx, "grantMigratorRole", input)
+}
+
+// RevokeMigratorRole removes the migrator role from a user or team.
+func RevokeMigratorRole(ctx context.Context, input MigratorRoleInput) error {
+	return setMigratorRole(ctx, "revokeMigratorRole", input)
+}
+
+func setMigratorRole(ctx context.Context, operationName string, input MigratorRoleInput) error {
+	actorType := strings.ToUpper(input.ActorType)
+	if actorType != "USER" && actorType != "TEAM" {
+		return fmt.Errorf("invalid actor type %q, expected USER or TEAM", input.ActorType)
+	}
+
+	ghlog.Logger.Info("Changing migrator role",
+		zap.String("operation", operationName),
+		zap.String("actor", input.Actor),
+		zap.String("actor_type", actorType),
+		zap.String("organization_id", input.OrganizationID))
+
+	mutation := fmt.Sprintf(`
+	mutation %[1]s($organizationId: ID!, $actor: String!, $actorType: ActorType!) {
+			%[1]s(input: { organizationId: $organizationId, actor: $actor, actorType: $actorType }) {
+					success
+			}
+	}`, operationName)
+
+	variables := map[string]interface{}{
+		"organizationId": input.OrganizationID,
+		"actor":          input.Actor,
+		"actorType":      actorType,
+	}
+
+	var response map[string]struct {
+		Success bool `json:"success"`
+	}
+	if err := doGraphQL(ctx, operationName, mutation, variables, &response); err != nil {
+		return fmt.Errorf("failed to change migrator role for %s: %w", input.Actor, err)
+	}
+
+	if !response[operationName].Success {
+		return fmt.Errorf("GitHub did not change the migrator role for %s", input.Actor)
+	}
+
+	ghlog.Logger.Info("Migrator role changed",
+		zap.String("operation", operationName),
+		zap.String("actor", input.Actor))
+
+	return nil
+}
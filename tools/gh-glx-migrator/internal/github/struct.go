@@ -1,5 +1,11 @@
 package github
 
+import (
+	"time"
+
+	"github.com/ps-resources/gh-glx-migrator/internal/clients"
+)
+
 type GraphQLResponse struct {
 	Data   interface{} `json:"data"`
 	Errors []struct {
@@ -62,9 +68,11 @@ type MigrationState struct {
 			Name string `json:"name"`
 			URL  string `json:"url"`
 		} `json:"migrationSource"`
-		State          string `json:"state"`
-		FailureReason  string `json:"failureReason"`
-		RepositoryName string `json:"repositoryName"`
+		State           string `json:"state"`
+		FailureReason   string `json:"failureReason"`
+		RepositoryName  string `json:"repositoryName"`
+		WarningsCount   int    `json:"warningsCount"`
+		MigrationLogURL string `json:"migrationLogUrl"`
 	} `json:"node"`
 }
 
@@ -115,6 +123,12 @@ type GHEMigratorOptions struct {
 type UploadArchiveInput struct {
 	ArchiveFilePath string
 	OrganizationId  string
+	// Client authenticates every request of the upload.
+	Client clients.GitHubClient
+	// PartSize and MultipartThreshold default to DefaultPartSize and
+	// DefaultMultipartThreshold.
+	PartSize           int64
+	MultipartThreshold int64
 }
 
 type UploadArchiveResponse struct {
@@ -125,3 +139,111 @@ type UploadArchiveResponse struct {
 	URI       string `json:"uri"`
 	CreatedAt string `json:"created_at"`
 }
+
+type ListMigrationsInput struct {
+	Organization string
+	State        string
+	Since        time.Time
+}
+
+type RepositoryMigration struct {
+	ID              string    `json:"id"`
+	DatabaseID      string    `json:"databaseId"`
+	SourceURL       string    `json:"sourceUrl"`
+	RepositoryName  string    `json:"repositoryName"`
+	State           string    `json:"state"`
+	FailureReason   string    `json:"failureReason"`
+	WarningsCount   int       `json:"warningsCount"`
+	MigrationLogURL string    `json:"migrationLogUrl"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+type RepositoryMigrationsResponse struct {
+	Organization struct {
+		RepositoryMigrations struct {
+			TotalCount int `json:"totalCount"`
+			PageInfo   struct {
+				HasNextPage bool   `json:"hasNextPage"`
+				EndCursor   string `json:"endCursor"`
+			} `json:"pageInfo"`
+			Nodes []RepositoryMigration `json:"nodes"`
+		} `json:"repositoryMigrations"`
+	} `json:"organization"`
+}
+
+type AbortMigrationResponse struct {
+	AbortRepositoryMigration struct {
+		Success bool `json:"success"`
+	} `json:"abortRepositoryMigration"`
+}
+
+type Mannequin struct {
+	ID       string `json:"id"`
+	Login    string `json:"login"`
+	Claimant *struct {
+		ID    string `json:"id"`
+		Login string `json:"login"`
+	} `json:"claimant"`
+}
+
+type MannequinsResponse struct {
+	Organization struct {
+		Mannequins struct {
+			PageInfo struct {
+				HasNextPage bool   `json:"hasNextPage"`
+				EndCursor   string `json:"endCursor"`
+			} `json:"pageInfo"`
+			Nodes []Mannequin `json:"nodes"`
+		} `json:"mannequins"`
+	} `json:"organization"`
+}
+
+type ReclaimMannequinInput struct {
+	OwnerID        string
+	MannequinID    string
+	TargetUserID   string
+	SkipInvitation bool
+}
+
+type OrgMember struct {
+	Login          string   `json:"login"`
+	Name           string   `json:"name"`
+	VerifiedEmails []string `json:"organizationVerifiedDomainEmails"`
+}
+
+type ExternalIdentity struct {
+	Login        string
+	SAMLNameID   string
+	SAMLUsername string
+	SAMLEmails   []string
+	SCIMUsername string
+	SCIMEmails   []string
+}
+
+type TokenPermissions struct {
+	Login string
+	// Scopes lists the OAuth scopes of a classic PAT. It is nil for fine-grained
+	// PATs and GitHub App tokens, which do not report scopes.
+	Scopes      []string
+	FineGrained bool
+	// App is set for GitHub App installation tokens, which act as the app.
+	App bool
+	// OrgRole is the user's role in the organization ("admin" for owners,
+	// "member"), or empty when the user is not a member.
+	OrgRole    string
+	CanMigrate bool
+}
+
+type OrgRepositoriesResponse struct {
+	Organization struct {
+		Repositories struct {
+			PageInfo struct {
+				HasNextPage bool   `json:"hasNextPage"`
+				EndCursor   string `json:"endCursor"`
+			} `json:"pageInfo"`
+			Nodes []struct {
+				Name string `json:"name"`
+			} `json:"nodes"`
+		} `json:"repositories"`
+	} `json:"organization"`
+}
@@ -0,0 +1,62 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/ps-resources/gh-glx-migrator/internal/clients"
+	"github.com/ps-resources/gh-glx-migrator/internal/config"
+)
+
+// Target is a GitHub instance that migrations are imported into.
+type Target struct {
+	// APIHost is the host of the REST and GraphQL API, api.github.com by
+	// default.
+	APIHost string
+	// UploadsHost is the host of GitHub-owned storage uploads,
+	// uploads.github.com by default.
+	UploadsHost string
+	// Client authenticates every request to the target.
+	Client clients.GitHubClient
+}
+
+// DefaultTarget returns the target configured with GITHUB_API_ENDPOINT and
+// GITHUB_UPLOADS_ENDPOINT, authenticated with clients.DefaultGitHubClient.
+func DefaultTarget() (*Target, error) {
+	client, err := clients.DefaultGitHubClient()
+	if err != nil {
+		return nil, err
+	}
+	return &Target{
+		APIHost:     config.Lookup(config.GitHubAPIEndpoint),
+		UploadsHost: config.Lookup(config.GitHubUploadsEndpoint),
+		Client:      client,
+	}, nil
+}
+
+func (t *Target) apiHost() string {
+	if t.APIHost != "" {
+		return t.APIHost
+	}
+	return "api.github.com"
+}
+
+func (t *Target) uploadsHost() string {
+	if t.UploadsHost != "" {
+		return t.UploadsHost
+	}
+	return "uploads.github.com"
+}
+
+// httpClient returns an HTTP client that authenticates its requests with the
+// client of t.
+func (t *Target) httpClient() (*http.Client, error) {
+	if t.Client == nil {
+		return nil, fmt.Errorf("no GitHub client for %s", t.apiHost())
+	}
+	client, err := t.Client.GitHubAuth()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GitHub client: %w", err)
+	}
+	return client.Client(), nil
+}
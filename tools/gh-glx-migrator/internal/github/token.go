@@ -0,0 +1,170 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/ps-resources/gh-glx-migrator/internal/clients"
+	"github.com/ps-resources/gh-glx-migrator/internal/config"
+	"github.com/ps-resources/gh-glx-migrator/internal/failure"
+	ghlog "github.com/ps-resources/gh-glx-migrator/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// RequiredScopes are the classic PAT scopes needed to run migrations.
+var RequiredScopes = []string{"admin:org", "repo", "workflow"}
+
+// InspectToken calls the GitHub API with GITHUB_PAT to find out who the token
+// belongs to, which scopes it carries and, when org is set, whether the user
+// may run migrations in that organization. When authenticating as a GitHub App
+// only the last is checked, as installation tokens have no user or scopes.
+func InspectToken(ctx context.Context, org string) (*TokenPermissions, error) {
+	githubHost := config.Lookup(config.GitHubAPIEndpoint)
+
+	if githubHost == "" {
+		githubHost = "api.github.com"
+	}
+
+	githubClient, err := clients.DefaultGitHubClient()
+	if err != nil {
+		return nil, err
+	}
+	client, err := githubClient.GitHubAuth()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GitHub client: %w", err)
+	}
+
+	if appID := config.Lookup(config.GitHubAppID); appID != "" {
+		permissions := &TokenPermissions{Login: "GitHub App " + appID, App: true}
+		if org != "" {
+			permissions.CanMigrate = canMigrate(ctx, org)
+		}
+		return permissions, nil
+	}
+
+	var user struct {
+		Login string `json:"login"`
+	}
+	header, status, err := getREST(ctx, client.Client(), fmt.Sprintf("https://%s/user", githubHost), &user)
+	if err != nil {
+		return nil, err
+	}
+	if status == http.StatusUnauthorized {
+		return nil, failure.Auth(fmt.Errorf("GitHub token is invalid or expired"))
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response status from /user: %d", status)
+	}
+
+	permissions := &TokenPermissions{Login: user.Login}
+	if scopes, ok := header["X-Oauth-Scopes"]; ok {
+		for _, scope := range strings.Split(strings.Join(scopes, ","), ",") {
+			if scope = strings.TrimSpace(scope); scope != "" {
+				permissions.Scopes = append(permissions.Scopes, scope)
+			}
+		}
+	} else {
+		permissions.FineGrained = true
+	}
+
+	if org == "" {
+		return permissions, nil
+	}
+
+	var membership struct {
+		Role  string `json:"role"`
+		State string `json:"state"`
+	}
+	_, status, err = getREST(ctx, client.Client(), fmt.Sprintf("https://%s/user/memberships/orgs/%s", githubHost, org), &membership)
+	if err != nil {
+		return nil, err
+	}
+	if status == http.StatusOK && membership.State == "active" {
+		permissions.OrgRole = membership.Role
+	}
+
+	permissions.CanMigrate = canMigrate(ctx, org)
+	return permissions, nil
+}
+
+// canMigrate reports whether the credential may run migrations in org. There
+// is no API to read the migrator role directly. Listing the organization's
+// migrations is only allowed for owners and migrators.
+func canMigrate(ctx context.Context, org string) bool {
+	probe := `
+	query probeMigratorRole($login: String!) {
+			organization(login: $login) {
+					repositoryMigrations(first: 1) {
+							totalCount
+					}
+			}
+	}`
+	if err := doGraphQL(ctx, "probeMigratorRole", probe, map[string]interface{}{"login": org}, nil); err != nil {
+		ghlog.Logger.Debug("Migrator role probe failed", zap.String("organization", org), zap.Error(err))
+		return false
+	}
+	return true
+}
+
+// MissingScopes returns the RequiredScopes the token does not carry. GitHub
+// App installation tokens have no scopes, so none are missing.
+func (p *TokenPermissions) MissingScopes() []string {
+	if p.App {
+		return nil
+	}
+	var missing []string
+	for _, required := range RequiredScopes {
+		found := false
+		for _, scope := range p.Scopes {
+			if scope == required {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, required)
+		}
+	}
+	return missing
+}
+
+// getREST performs an authenticated GET request against the GitHub REST API and
+// decodes a successful JSON response into out.
+func getREST(ctx context.Context, client *http.Client, url string, out interface{}) (http.Header, int, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create HTTP request: %v", err)
+	}
+
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	req.Header.Set("User-Agent", "gh-glx-migrator")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			ghlog.Logger.Error("failed to close response body", zap.Error(err))
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read response body: %v", err)
+	}
+
+	if resp.StatusCode == http.StatusOK && out != nil {
+		if err := json.Unmarshal(body, out); err != nil {
+			return nil, 0, fmt.Errorf("failed to decode response: %v", err)
+		}
+	}
+
+	return resp.Header, resp.StatusCode, nil
+}
@@ -0,0 +1,101 @@
+package github
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/ps-resources/gh-glx-migrator/internal/clients"
+	"github.com/ps-resources/gh-glx-migrator/internal/testutil"
+)
+
+func TestInspectToken(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(gh *testutil.GitHub)
+		want  TokenPermissions
+	}{
+		{
+			name: "owner with a classic PAT",
+			want: TokenPermissions{Login: "octocat", Scopes: []string{"admin:org", "repo", "workflow"}, OrgRole: "admin", CanMigrate: true},
+		},
+		{
+			name:  "fine-grained PAT",
+			setup: func(gh *testutil.GitHub) { gh.Scopes = nil },
+			want:  TokenPermissions{Login: "octocat", FineGrained: true, OrgRole: "admin", CanMigrate: true},
+		},
+		{
+			name:  "member without the migrator role",
+			setup: func(gh *testutil.GitHub) { gh.OrgRole, gh.Scopes = "member", []string{"repo"} },
+			want:  TokenPermissions{Login: "octocat", Scopes: []string{"repo"}, OrgRole: "member"},
+		},
+		{
+			name:  "migrator outside the organization",
+			setup: func(gh *testutil.GitHub) { gh.OrgRole, gh.Migrators = "", []string{"octocat"} },
+			want:  TokenPermissions{Login: "octocat", Scopes: []string{"admin:org", "repo", "workflow"}, CanMigrate: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testutil.SetEnv(t, map[string]string{"GH_APP_ID": ""})
+			gh := testutil.NewGitHub(t)
+			testutil.SetEnv(t, gh.Env())
+			if tt.setup != nil {
+				tt.setup(gh)
+			}
+
+			got, err := InspectToken(context.Background(), gh.Org.Login)
+			if err != nil {
+				t.Fatalf("InspectToken() error = %v", err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("InspectToken() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInspectTokenApp(t *testing.T) {
+	tests := []struct {
+		name    string
+		orgRole string
+		want    TokenPermissions
+	}{
+		{name: "installation with organization administration", orgRole: "admin", want: TokenPermissions{Login: "GitHub App 1234", App: true, CanMigrate: true}},
+		{name: "installation without it", orgRole: "", want: TokenPermissions{Login: "GitHub App 1234", App: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gh := testutil.NewGitHub(t)
+			gh.OrgRole = tt.orgRole
+			testutil.SetEnv(t, gh.AppEnv(t, ""))
+			clients.ResetDefaultGitHubCredential()
+			t.Cleanup(clients.ResetDefaultGitHubCredential)
+
+			got, err := InspectToken(context.Background(), gh.Org.Login)
+			if err != nil {
+				t.Fatalf("InspectToken() error = %v", err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("InspectToken() = %+v, want %+v", *got, tt.want)
+			}
+			if got.MissingScopes() != nil {
+				t.Errorf("MissingScopes() = %v, want none for an installation", got.MissingScopes())
+			}
+			if gh.InstallationTokens() != 1 {
+				t.Errorf("minted %d installation tokens, want 1", gh.InstallationTokens())
+			}
+		})
+	}
+}
+
+func TestMissingScopes(t *testing.T) {
+	permissions := &TokenPermissions{Scopes: []string{"repo", "admin:org"}}
+	if got := permissions.MissingScopes(); !reflect.DeepEqual(got, []string{"workflow"}) {
+		t.Errorf("MissingScopes() = %v, want [workflow]", got)
+	}
+	app := &TokenPermissions{App: true}
+	if got := app.MissingScopes(); got != nil {
+		t.Errorf("MissingScopes() of an app = %v, want none", got)
+	}
+}
@@ -0,0 +1,78 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ps-resources/gh-glx-migrator/internal/clients"
+	"github.com/ps-resources/gh-glx-migrator/internal/testutil"
+	ghlog "github.com/ps-resources/gh-glx-migrator/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	ghlog.InitLogger()
+	os.Exit(m.Run())
+}
+
+func TestUploadArchiveToGitHub(t *testing.T) {
+	gh := testutil.NewGitHub(t)
+	testutil.SetEnv(t, gh.Env())
+
+	archive := []byte("0123456789")
+	path := filepath.Join(t.TempDir(), "archive.tar.gz")
+	if err := os.WriteFile(path, archive, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		threshold int64
+	}{
+		{name: "simple", threshold: 0},
+		// Parts of 4 bytes: 4, 4 and 2.
+		{name: "multipart", threshold: 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uri, err := UploadArchiveToGitHub(context.Background(), UploadArchiveInput{
+				ArchiveFilePath:    path,
+				OrganizationId:     "1001",
+				Client:             clients.NewGitHubClient(gh.Token),
+				PartSize:           4,
+				MultipartThreshold: tt.threshold,
+			})
+			if err != nil {
+				t.Fatalf("UploadArchiveToGitHub() error = %v", err)
+			}
+			uploaded, ok := gh.Archive(uri)
+			if !ok {
+				t.Fatalf("UploadArchiveToGitHub() = %q, not the URI of an uploaded archive", uri)
+			}
+			if !bytes.Equal(uploaded, archive) {
+				t.Errorf("uploaded archive = %q, want %q", uploaded, archive)
+			}
+		})
+	}
+}
+
+func TestUploadArchiveToGitHubUnauthorized(t *testing.T) {
+	gh := testutil.NewGitHub(t)
+	testutil.SetEnv(t, gh.Env())
+
+	path := filepath.Join(t.TempDir(), "archive.tar.gz")
+	if err := os.WriteFile(path, []byte("archive"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := UploadArchiveToGitHub(context.Background(), UploadArchiveInput{
+		ArchiveFilePath: path,
+		OrganizationId:  "1001",
+		Client:          clients.NewGitHubClient("ghp_wrong"),
+	})
+	if err == nil {
+		t.Error("UploadArchiveToGitHub() returned no error for a rejected token")
+	}
+}
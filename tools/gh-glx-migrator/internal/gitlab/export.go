@@ -1,10 +1,17 @@
 package gitlab
 
 import (
+  "context"
   "fmt"
   "os"
   "os/exec"
   "path/filepath"
+  "strings"
+  "time"
+
+  ghlog "github.com/ps-resources/gh-glx-migrator/pkg/logger"
+
+  "go.uber.org/zap"
 )
 
 // GLExporterOptions holds configuration to run the gl-exporter.
@@ -20,7 +27,7 @@ type GLExporterOptions struct {
 }
 
 // ExportFromGitLab runs the gl-exporter Docker image to export repositories from GitLab.
-func ExportFromGitLab(opts *GLExporterOptions) error {
+func ExportFromGitLab(ctx context.Context, opts *GLExporterOptions) error {
   // Validate parameters.
   if opts.OutputFile == "" {
     return fmt.Errorf("output file is required")
@@ -40,51 +47,87 @@ func ExportFromGitLab(opts *GLExporterOptions) error {
     return fmt.Errorf("failed to get absolute path of output directory: %w", err)
   }
 
-  // Prepare docker arguments common to both commands.
-  commonDockerArgs := []string{
-    "run", "--rm",
-    "-v", fmt.Sprintf("%s:/workspace", outputDirAbs),
-    "-e", fmt.Sprintf("GITLAB_API_ENDPOINT=%s", opts.GitLabAPIEndpoint),
-    "-e", fmt.Sprintf("GITLAB_USERNAME=%s", opts.GitLabUsername),
-    "-e", fmt.Sprintf("GITLAB_API_PRIVATE_TOKEN=%s", opts.GitLabAPIToken),
-    opts.DockerImage,
+  // Secrets are passed through an env file rather than the argv, which is
+  // visible to other users of the host and was echoed to the logs.
+  envFile, err := writeExporterEnvFile(opts)
+  if err != nil {
+    return err
   }
-  var dockerArgs []string
+  defer func() {
+    if err := os.Remove(envFile); err != nil {
+      ghlog.Logger.Error("failed to remove env file", zap.String("file", envFile), zap.Error(err))
+    }
+  }()
+
+  dockerArgs := []string{"run", "--rm", "--env-file", envFile}
 
   // Check if CSV file exists.
   if _, err := os.Stat(opts.CsvFile); os.IsNotExist(err) {
-    dockerArgs = append(commonDockerArgs,
+    dockerArgs = append(dockerArgs,
+      "-v", fmt.Sprintf("%s:/workspace", outputDirAbs),
+      opts.DockerImage,
       "gl_exporter",
       "--namespace", opts.GitLabNamespace,
       "--project", opts.GitLabProject,
       "-o", outputFileName,
     )
-    fmt.Printf("CSV file not found, using namespace '%s' and project '%s' for export\n", opts.GitLabNamespace, opts.GitLabProject)
+    ghlog.Logger.Info("CSV file not found, exporting a single project",
+      zap.String("namespace", opts.GitLabNamespace),
+      zap.String("project", opts.GitLabProject))
   } else {
     csvAbs, err := filepath.Abs(opts.CsvFile)
     if err != nil {
       return fmt.Errorf("failed to get absolute path of CSV file: %w", err)
     }
-    dockerArgs = append([]string{
-      "run", "--rm",
+    dockerArgs = append(dockerArgs,
       "-v", fmt.Sprintf("%s:/workspace/export.csv", csvAbs),
       "-v", fmt.Sprintf("%s:/workspace", outputDirAbs),
-      "-e", fmt.Sprintf("GITLAB_API_ENDPOINT=%s", opts.GitLabAPIEndpoint),
-      "-e", fmt.Sprintf("GITLAB_USERNAME=%s", opts.GitLabUsername),
-      "-e", fmt.Sprintf("GITLAB_API_PRIVATE_TOKEN=%s", opts.GitLabAPIToken),
       opts.DockerImage,
       "gl_exporter", "-f", "export.csv", "-o", outputFileName,
-    }, []string{}...)
+    )
   }
 
-  cmd := exec.Command("docker", dockerArgs...)
+  cmd := exec.CommandContext(ctx, "docker", dockerArgs...)
   cmd.Stdout = os.Stdout
   cmd.Stderr = os.Stderr
+  // On cancellation, interrupt the docker client so it forwards the signal to
+  // the container instead of leaving the export running.
+  cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+  cmd.WaitDelay = 30 * time.Second
 
-  fmt.Printf("Executing docker command: docker %v\n", dockerArgs)
+  ghlog.Logger.Info("Executing docker command", zap.Strings("args", dockerArgs))
   if err := cmd.Run(); err != nil {
     return fmt.Errorf("gl-exporter docker command failed: %w", err)
   }
 
   return nil
 }
+
+// writeExporterEnvFile writes the GitLab settings of gl-exporter to a file only
+// the current user can read, in the format of docker run --env-file.
+func writeExporterEnvFile(opts *GLExporterOptions) (string, error) {
+  for _, value := range []string{opts.GitLabAPIEndpoint, opts.GitLabUsername, opts.GitLabAPIToken} {
+    if strings.ContainsAny(value, "\r\n") {
+      return "", fmt.Errorf("GitLab settings must not contain line breaks")
+    }
+  }
+
+  file, err := os.CreateTemp("", "gl-exporter-*.env")
+  if err != nil {
+    return "", fmt.Errorf("failed to create env file: %w", err)
+  }
+
+  content := fmt.Sprintf("GITLAB_API_ENDPOINT=%s\nGITLAB_USERNAME=%s\nGITLAB_API_PRIVATE_TOKEN=%s\n",
+    opts.GitLabAPIEndpoint, opts.GitLabUsername, opts.GitLabAPIToken)
+  if _, err := file.WriteString(content); err != nil {
+    _ = file.Close()
+    _ = os.Remove(file.Name())
+    return "", fmt.Errorf("failed to write env file: %w", err)
+  }
+  if err := file.Close(); err != nil {
+    _ = os.Remove(file.Name())
+    return "", fmt.Errorf("failed to write env file: %w", err)
+  }
+
+  return file.Name(), nil
+}
@@ -0,0 +1,176 @@
+package gitlab
+
+import (
+  "context"
+  "fmt"
+  "sync"
+  "time"
+
+  gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// CountUnavailable is reported for counts and sizes the token is not allowed
+// to read, and for counts GitLab does not report, such as lists of more than
+// 10,000 items.
+const CountUnavailable = -1
+
+// ProjectInventory describes the size and contents of a GitLab project for
+// migration planning. GLNamespace and GLProject match the namespace/project
+// pair gl-exporter expects, and SourceURL the --source-repo of import-archive.
+type ProjectInventory struct {
+  ID                   int    `json:"id"`
+  GLNamespace          string `json:"gl_namespace"`
+  GLProject            string `json:"gl_project"`
+  PathWithNamespace    string `json:"path_with_namespace"`
+  SourceURL            string `json:"source_url"`
+  RepositorySize       int64  `json:"repository_size"`
+  LFSSize              int64  `json:"lfs_size"`
+  HasWiki              bool   `json:"has_wiki"`
+  Issues               int    `json:"issues"`
+  MergeRequests        int    `json:"merge_requests"`
+  Pipelines            int    `json:"pipelines"`
+  LastActivityAt       string `json:"last_activity_at"`
+  Archived             bool   `json:"archived"`
+  HasContainerRegistry bool   `json:"has_container_registry"`
+  HasPackages          bool   `json:"has_packages"`
+  CIVariables          int    `json:"ci_variables"`
+}
+
+// InventoryOptions selects the projects to inventory.
+type InventoryOptions struct {
+  Group       string
+  Recursive   bool
+  Concurrency int
+}
+
+// Inventory lists the projects of a group, optionally including its subgroups,
+// and collects the statistics of each one. Counts the token cannot read are
+// reported as CountUnavailable.
+func Inventory(ctx context.Context, client *gitlab.Client, opts InventoryOptions) ([]ProjectInventory, error) {
+  listOpts := &gitlab.ListGroupProjectsOptions{
+    ListOptions:      gitlab.ListOptions{PerPage: 100, Page: 1},
+    IncludeSubGroups: &opts.Recursive,
+  }
+
+  var projects []*gitlab.Project
+  for {
+    page, resp, err := client.Groups.ListGroupProjects(opts.Group, listOpts, gitlab.WithContext(ctx))
+    if err != nil {
+      return nil, fmt.Errorf("failed to list projects of group %s: %w", opts.Group, err)
+    }
+    projects = append(projects, page...)
+
+    if resp.NextPage == 0 {
+      break
+    }
+    listOpts.Page = resp.NextPage
+  }
+
+  concurrency := opts.Concurrency
+  if concurrency < 1 {
+    concurrency = 1
+  }
+
+  inventory := make([]ProjectInventory, len(projects))
+  sem := make(chan struct{}, concurrency)
+  var wg sync.WaitGroup
+  for i, project := range projects {
+    if ctx.Err() != nil {
+      break
+    }
+    wg.Add(1)
+    sem <- struct{}{}
+    go func(i int, project *gitlab.Project) {
+      defer wg.Done()
+      defer func() { <-sem }()
+      inventory[i] = inventoryProject(ctx, client, project)
+    }(i, project)
+  }
+  wg.Wait()
+
+  if err := ctx.Err(); err != nil {
+    return nil, err
+  }
+  return inventory, nil
+}
+
+func inventoryProject(ctx context.Context, client *gitlab.Client, project *gitlab.Project) ProjectInventory {
+  item := ProjectInventory{
+    ID:                project.ID,
+    GLProject:         project.Path,
+    PathWithNamespace: project.PathWithNamespace,
+    SourceURL:         project.WebURL,
+    Archived:          project.Archived,
+  }
+  if project.Namespace != nil {
+    item.GLNamespace = project.Namespace.FullPath
+  }
+  if project.LastActivityAt != nil {
+    item.LastActivityAt = project.LastActivityAt.UTC().Format(time.RFC3339)
+  }
+  // Group project listings do not include statistics, so fetch them per
+  // project. Statistics are only shown to members with at least the Reporter
+  // role; without them the sizes are unavailable rather than zero.
+  item.RepositorySize = CountUnavailable
+  item.LFSSize = CountUnavailable
+  statistics := true
+  detailed, _, err := client.Projects.GetProject(project.ID, &gitlab.GetProjectOptions{Statistics: &statistics}, gitlab.WithContext(ctx))
+  if err == nil && detailed.Statistics != nil {
+    item.RepositorySize = detailed.Statistics.RepositorySize
+    item.LFSSize = detailed.Statistics.LFSObjectsSize
+    item.HasWiki = detailed.WikiEnabled && detailed.Statistics.WikiSize > 0
+  }
+
+  first := gitlab.ListOptions{PerPage: 1, Page: 1}
+
+  item.Issues = CountUnavailable
+  if stats, _, err := client.IssuesStatistics.GetProjectIssuesStatistics(project.ID, &gitlab.GetProjectIssuesStatisticsOptions{}, gitlab.WithContext(ctx)); err == nil {
+    item.Issues = stats.Statistics.Counts.All
+  }
+
+  item.MergeRequests = countItems(func() (*gitlab.Response, error) {
+    _, resp, err := client.MergeRequests.ListProjectMergeRequests(project.ID, &gitlab.ListProjectMergeRequestsOptions{ListOptions: first}, gitlab.WithContext(ctx))
+    return resp, err
+  })
+
+  item.Pipelines = countItems(func() (*gitlab.Response, error) {
+    _, resp, err := client.Pipelines.ListProjectPipelines(project.ID, &gitlab.ListProjectPipelinesOptions{ListOptions: first}, gitlab.WithContext(ctx))
+    return resp, err
+  })
+
+  item.CIVariables = countItems(func() (*gitlab.Response, error) {
+    opts := gitlab.ListProjectVariablesOptions(first)
+    _, resp, err := client.ProjectVariables.ListVariables(project.ID, &opts, gitlab.WithContext(ctx))
+    return resp, err
+  })
+
+  if project.ContainerRegistryEnabled {
+    repositories := countItems(func() (*gitlab.Response, error) {
+      _, resp, err := client.ContainerRegistry.ListProjectRegistryRepositories(project.ID, &gitlab.ListRegistryRepositoriesOptions{ListOptions: first}, gitlab.WithContext(ctx))
+      return resp, err
+    })
+    item.HasContainerRegistry = repositories > 0
+  }
+
+  if project.PackagesEnabled {
+    packages := countItems(func() (*gitlab.Response, error) {
+      _, resp, err := client.Packages.ListProjectPackages(project.ID, &gitlab.ListProjectPackagesOptions{ListOptions: first}, gitlab.WithContext(ctx))
+      return resp, err
+    })
+    item.HasPackages = packages > 0
+  }
+
+  return item
+}
+
+// countItems runs a list request for a single item and returns the total
+// reported in the pagination headers, or CountUnavailable on error. GitLab
+// leaves out X-Total for lists of more than 10,000 items, in which case the
+// total is unavailable rather than zero.
+func countItems(list func() (*gitlab.Response, error)) int {
+  resp, err := list()
+  if err != nil || resp == nil || resp.Header.Get("X-Total") == "" {
+    return CountUnavailable
+  }
+  return resp.TotalItems
+}
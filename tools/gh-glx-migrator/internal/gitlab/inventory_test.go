@@ -0,0 +1,60 @@
+package gitlab
+
+import (
+  "context"
+  "testing"
+
+  gitlab "gitlab.com/gitlab-org/api/client-go"
+
+  "github.com/ps-resources/gh-glx-migrator/internal/testutil"
+)
+
+func TestInventory(t *testing.T) {
+  tests := []struct {
+    name              string
+    setup             func(gl *testutil.GitLab)
+    wantSize          int64
+    wantMergeRequests int
+  }{
+    {name: "statistics and totals", wantSize: 2048, wantMergeRequests: 3},
+    {name: "statistics forbidden", setup: func(gl *testutil.GitLab) { gl.HideStatistics = true }, wantSize: CountUnavailable, wantMergeRequests: 3},
+    {name: "more than 10,000 items", setup: func(gl *testutil.GitLab) { gl.OmitTotals = true }, wantSize: 2048, wantMergeRequests: CountUnavailable},
+  }
+  for _, tt := range tests {
+    t.Run(tt.name, func(t *testing.T) {
+      gl := testutil.NewGitLab(t)
+      gl.Projects = []string{"group/api", "group/sub/web", "other/tool"}
+      gl.RepositorySize = 2048
+      gl.MergeRequests = 3
+      if tt.setup != nil {
+        tt.setup(gl)
+      }
+
+      client, err := gitlab.NewClient(gl.Token, gitlab.WithBaseURL(gl.APIEndpoint()))
+      if err != nil {
+        t.Fatal(err)
+      }
+      inventory, err := Inventory(context.Background(), client, InventoryOptions{Group: "group", Recursive: true})
+      if err != nil {
+        t.Fatalf("Inventory() error = %v", err)
+      }
+      if len(inventory) != 2 {
+        t.Fatalf("Inventory() returned %d projects, want the 2 of the group", len(inventory))
+      }
+
+      api := inventory[0]
+      if api.PathWithNamespace != "group/api" || api.GLNamespace != "group" || api.GLProject != "api" {
+        t.Errorf("project = %+v", api)
+      }
+      if api.RepositorySize != tt.wantSize || (tt.wantSize == CountUnavailable && api.LFSSize != CountUnavailable) {
+        t.Errorf("repository size = %d, LFS size = %d, want %d", api.RepositorySize, api.LFSSize, tt.wantSize)
+      }
+      if api.MergeRequests != tt.wantMergeRequests {
+        t.Errorf("merge requests = %d, want %d", api.MergeRequests, tt.wantMergeRequests)
+      }
+      if api.Pipelines != CountUnavailable {
+        t.Errorf("pipelines = %d, want unavailable for a failed request", api.Pipelines)
+      }
+    })
+  }
+}
@@ -0,0 +1,46 @@
+package gitlab
+
+import (
+  "context"
+  "fmt"
+
+  gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// ListRefs returns the full names of the branches and tags of a project, as
+// refs/heads/<branch> and refs/tags/<tag>.
+func ListRefs(ctx context.Context, client *gitlab.Client, project string) ([]string, error) {
+  var refs []string
+
+  branchOpts := &gitlab.ListBranchesOptions{ListOptions: gitlab.ListOptions{PerPage: 100, Page: 1}}
+  for {
+    branches, resp, err := client.Branches.ListBranches(project, branchOpts, gitlab.WithContext(ctx))
+    if err != nil {
+      return nil, fmt.Errorf("failed to list branches of %s: %w", project, err)
+    }
+    for _, b := range branches {
+      refs = append(refs, "refs/heads/"+b.Name)
+    }
+    if resp.NextPage == 0 {
+      break
+    }
+    branchOpts.Page = resp.NextPage
+  }
+
+  tagOpts := &gitlab.ListTagsOptions{ListOptions: gitlab.ListOptions{PerPage: 100, Page: 1}}
+  for {
+    tags, resp, err := client.Tags.ListTags(project, tagOpts, gitlab.WithContext(ctx))
+    if err != nil {
+      return nil, fmt.Errorf("failed to list tags of %s: %w", project, err)
+    }
+    for _, t := range tags {
+      refs = append(refs, "refs/tags/"+t.Name)
+    }
+    if resp.NextPage == 0 {
+      break
+    }
+    tagOpts.Page = resp.NextPage
+  }
+
+  return refs, nil
+}
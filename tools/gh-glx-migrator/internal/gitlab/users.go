@@ -0,0 +1,68 @@
+package gitlab
+
+import (
+  "context"
+  "fmt"
+  "net/http"
+
+  gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// User is a GitLab user with the email addresses used to match it to a GitHub
+// account. Email and CommitEmail are only returned to administrators.
+type User struct {
+  ID          int    `json:"id"`
+  Username    string `json:"username"`
+  Name        string `json:"name"`
+  State       string `json:"state"`
+  Bot         bool   `json:"bot"`
+  Email       string `json:"email"`
+  PublicEmail string `json:"public_email"`
+  CommitEmail string `json:"commit_email"`
+}
+
+// Emails returns the distinct, non-empty email addresses of the user.
+func (u User) Emails() []string {
+  var emails []string
+  seen := make(map[string]bool)
+  for _, email := range []string{u.Email, u.PublicEmail, u.CommitEmail} {
+    if email == "" || seen[email] {
+      continue
+    }
+    seen[email] = true
+    emails = append(emails, email)
+  }
+  return emails
+}
+
+// ListUsers returns every human user of the GitLab instance. The users API is
+// called directly because the client library does not expose commit_email.
+func ListUsers(ctx context.Context, client *gitlab.Client) ([]User, error) {
+  humans := true
+  opts := &gitlab.ListUsersOptions{
+    ListOptions: gitlab.ListOptions{PerPage: 100, Page: 1},
+    Humans:      &humans,
+  }
+
+  var users []User
+  for {
+    req, err := client.NewRequest(http.MethodGet, "users", opts, []gitlab.RequestOptionFunc{gitlab.WithContext(ctx)})
+    if err != nil {
+      return nil, fmt.Errorf("failed to create users request: %w", err)
+    }
+
+    var page []User
+    resp, err := client.Do(req, &page)
+    if err != nil {
+      return nil, fmt.Errorf("failed to list GitLab users: %w", err)
+    }
+    users = append(users, page...)
+
+    if resp.NextPage == 0 {
+      break
+    }
+    opts.Page = resp.NextPage
+  }
+
+  return users, nil
+}
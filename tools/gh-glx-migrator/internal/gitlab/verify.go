@@ -0,0 +1,77 @@
+package gitlab
+
+import (
+  "context"
+  "fmt"
+  "net/http"
+
+  gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// RequiredScopes are the token scopes a non-admin user needs to export projects.
+var RequiredScopes = []string{"api", "read_repository"}
+
+// TokenInfo describes the user and scopes behind a GitLab token.
+type TokenInfo struct {
+  Username string
+  IsAdmin  bool
+  Scopes   []string
+}
+
+// VerifyToken checks that the client's token is valid, that it belongs to an
+// administrator or carries the RequiredScopes, and that it can see each of the
+// given projects (full paths such as group/project).
+func VerifyToken(ctx context.Context, client *gitlab.Client, projects []string) (*TokenInfo, error) {
+  user, _, err := client.Users.CurrentUser(gitlab.WithContext(ctx))
+  if err != nil {
+    return nil, fmt.Errorf("GitLab token is invalid or expired: %w", err)
+  }
+
+  info := &TokenInfo{
+    Username: user.Username,
+    IsAdmin:  user.IsAdmin,
+  }
+
+  token, resp, err := client.PersonalAccessTokens.GetSinglePersonalAccessToken(gitlab.WithContext(ctx))
+  switch {
+  case err == nil:
+    if !token.Active || token.Revoked {
+      return info, fmt.Errorf("GitLab token %q is not active", token.Name)
+    }
+    info.Scopes = token.Scopes
+  case resp != nil && resp.StatusCode == http.StatusNotFound && info.IsAdmin:
+    // Older GitLab versions cannot describe the current token; an
+    // administrator's token is accepted without checking its scopes.
+  default:
+    return info, fmt.Errorf("failed to read GitLab token scopes: %w", err)
+  }
+
+  if !info.IsAdmin {
+    var missing []string
+    for _, required := range RequiredScopes {
+      if !contains(info.Scopes, required) {
+        missing = append(missing, required)
+      }
+    }
+    if len(missing) > 0 {
+      return info, fmt.Errorf("GitLab user %s is not an administrator and the token is missing scopes %v", info.Username, missing)
+    }
+  }
+
+  for _, project := range projects {
+    if _, _, err := client.Projects.GetProject(project, nil, gitlab.WithContext(ctx)); err != nil {
+      return info, fmt.Errorf("GitLab user %s cannot access project %s: %w", info.Username, project, err)
+    }
+  }
+
+  return info, nil
+}
+
+func contains(values []string, value string) bool {
+  for _, v := range values {
+    if v == value {
+      return true
+    }
+  }
+  return false
+}
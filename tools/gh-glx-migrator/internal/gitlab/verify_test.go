@@ -0,0 +1,52 @@
+package gitlab
+
+import (
+  "context"
+  "strings"
+  "testing"
+
+  gitlab "gitlab.com/gitlab-org/api/client-go"
+
+  "github.com/ps-resources/gh-glx-migrator/internal/testutil"
+)
+
+func TestVerifyToken(t *testing.T) {
+  tests := []struct {
+    name    string
+    scopes  []string
+    isAdmin bool
+    project string
+    wantErr string
+  }{
+    {name: "scoped token", scopes: []string{"api", "read_repository"}, project: "group/api"},
+    {name: "missing scope", scopes: []string{"read_api"}, project: "group/api", wantErr: "missing scopes [api read_repository]"},
+    {name: "administrator", scopes: []string{"read_api"}, isAdmin: true, project: "group/api"},
+    {name: "inaccessible project", scopes: []string{"api", "read_repository"}, project: "group/secret", wantErr: "cannot access project group/secret"},
+  }
+  for _, tt := range tests {
+    t.Run(tt.name, func(t *testing.T) {
+      gl := testutil.NewGitLab(t)
+      gl.Scopes = tt.scopes
+      gl.IsAdmin = tt.isAdmin
+      gl.Projects = []string{"group/api"}
+
+      client, err := gitlab.NewClient(gl.Token, gitlab.WithBaseURL(gl.APIEndpoint()))
+      if err != nil {
+        t.Fatal(err)
+      }
+      info, err := VerifyToken(context.Background(), client, []string{tt.project})
+      if tt.wantErr != "" {
+        if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+          t.Fatalf("VerifyToken() error = %v, want %q", err, tt.wantErr)
+        }
+        return
+      }
+      if err != nil {
+        t.Fatalf("VerifyToken() error = %v", err)
+      }
+      if info.Username != gl.Username {
+        t.Errorf("VerifyToken() username = %q, want %q", info.Username, gl.Username)
+      }
+    })
+  }
+}
@@ -0,0 +1,230 @@
+package naming
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/ps-resources/gh-glx-migrator/internal/github"
+)
+
+// Strategy derives a GitHub repository name from a GitLab project path.
+type Strategy string
+
+const (
+	// StrategyLastSegment uses the project path: group/sub/api becomes api.
+	StrategyLastSegment Strategy = "last-segment"
+	// StrategyFlattenPath joins the full path: group/sub/api becomes group-sub-api.
+	StrategyFlattenPath Strategy = "flatten-path"
+	// StrategyPrefixGroup prefixes the project with its parent group:
+	// group/sub/api becomes sub-api.
+	StrategyPrefixGroup Strategy = "prefix-group"
+)
+
+// CollisionMode decides what happens when two projects, or a project and an
+// existing repository, resolve to the same name.
+type CollisionMode string
+
+const (
+	// CollisionFail reports the collision as a conflict.
+	CollisionFail CollisionMode = "fail"
+	// CollisionSuffix appends -2, -3, ... until the name is free.
+	CollisionSuffix CollisionMode = "suffix"
+	// CollisionSkip leaves the project out of the migration.
+	CollisionSkip CollisionMode = "skip"
+)
+
+// Policy combines a naming strategy with a collision mode.
+type Policy struct {
+	Strategy  Strategy
+	Collision CollisionMode
+}
+
+// DefaultPolicy matches the historical behaviour of import-archive.
+var DefaultPolicy = Policy{Strategy: StrategyLastSegment, Collision: CollisionFail}
+
+// NewPolicy validates the strategy and collision mode names.
+func NewPolicy(strategy, collision string) (Policy, error) {
+	p := Policy{Strategy: Strategy(strategy), Collision: CollisionMode(collision)}
+	switch p.Strategy {
+	case StrategyLastSegment, StrategyFlattenPath, StrategyPrefixGroup:
+	default:
+		return Policy{}, fmt.Errorf("unknown name strategy: %s. Available strategies: last-segment, flatten-path, prefix-group", strategy)
+	}
+	switch p.Collision {
+	case CollisionFail, CollisionSuffix, CollisionSkip:
+	default:
+		return Policy{}, fmt.Errorf("unknown collision mode: %s. Available modes: fail, suffix, skip", collision)
+	}
+	return p, nil
+}
+
+// Source is a GitLab project to name.
+type Source struct {
+	// Path is the namespace/project path of the project.
+	Path string
+	// Target is an explicit repository name that overrides the strategy.
+	Target string
+}
+
+// Mapping is the resolved GitHub repository name of a GitLab project.
+type Mapping struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	// Skipped is set when the collision mode left the project out.
+	Skipped bool `json:"skipped,omitempty"`
+	// Conflict describes the collision when the collision mode is fail.
+	Conflict string `json:"conflict,omitempty"`
+}
+
+var invalidNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Name returns the repository name the strategy gives a project path. Characters
+// GitHub does not accept are replaced with '-', the way GitHub itself does.
+func (p Policy) Name(projectPath string) string {
+	segments := strings.Split(strings.Trim(projectPath, "/"), "/")
+	var name string
+	switch p.Strategy {
+	case StrategyFlattenPath:
+		name = strings.Join(segments, "-")
+	case StrategyPrefixGroup:
+		name = segments[len(segments)-1]
+		if len(segments) > 1 {
+			name = segments[len(segments)-2] + "-" + name
+		}
+	default:
+		name = path.Base(projectPath)
+	}
+	return sanitize(name)
+}
+
+func sanitize(name string) string {
+	name = invalidNameChars.ReplaceAllString(name, "-")
+	if len(name) > github.MaxRepositoryNameLength {
+		name = name[:github.MaxRepositoryNameLength]
+	}
+	return name
+}
+
+// Organization is the target organization, whose existing repositories
+// target names can collide with.
+type Organization interface {
+	// Exists reports whether the organization has a repository with the name.
+	Exists(ctx context.Context, name string) (bool, error)
+	// Names returns the names of all repositories of the organization.
+	Names(ctx context.Context) ([]string, error)
+}
+
+// ResolveIn is Resolve against the existing repositories of org, or of none
+// when org is nil. Only the suffix mode, which needs every taken name to pick
+// a free one, lists the repositories of the organization. The other modes
+// check each resolved name on its own, so importing one repository into a
+// large organization does not page through all of its repositories.
+func (p Policy) ResolveIn(ctx context.Context, sources []Source, org Organization) ([]Mapping, error) {
+	if org == nil {
+		return p.Resolve(sources, nil), nil
+	}
+	if p.Collision == CollisionSuffix {
+		existing, err := org.Names(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return p.Resolve(sources, existing), nil
+	}
+
+	mappings := p.Resolve(sources, nil)
+	for i := range mappings {
+		m := &mappings[i]
+		if m.Skipped || m.Conflict != "" {
+			continue
+		}
+		exists, err := org.Exists(ctx, m.Target)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			continue
+		}
+		if p.Collision == CollisionSkip {
+			m.Skipped = true
+		} else {
+			m.Conflict = conflictDetail(m.Target, "")
+		}
+	}
+	return mappings, nil
+}
+
+// Resolve names every source in order. Names are compared case-insensitively,
+// with each other and with the existing repositories of the target
+// organization, and collisions are handled according to the collision mode.
+// An explicit Target is never given a suffix: it is in conflict instead.
+func (p Policy) Resolve(sources []Source, existing []string) []Mapping {
+	taken := make(map[string]string)
+	for _, name := range existing {
+		taken[strings.ToLower(name)] = ""
+	}
+
+	mappings := make([]Mapping, 0, len(sources))
+	for _, source := range sources {
+		m := Mapping{Source: source.Path, Target: source.Target}
+		if m.Target == "" {
+			m.Target = p.Name(source.Path)
+		}
+
+		if owner, ok := taken[strings.ToLower(m.Target)]; ok {
+			switch {
+			case p.Collision == CollisionSuffix && source.Target == "":
+				base := m.Target
+				for i := 2; ; i++ {
+					suffix := fmt.Sprintf("-%d", i)
+					if len(base)+len(suffix) > github.MaxRepositoryNameLength {
+						base = base[:github.MaxRepositoryNameLength-len(suffix)]
+					}
+					candidate := base + suffix
+					if _, ok := taken[strings.ToLower(candidate)]; !ok {
+						m.Target = candidate
+						break
+					}
+				}
+			case p.Collision == CollisionSkip:
+				m.Skipped = true
+			default:
+				m.Conflict = conflictDetail(m.Target, owner)
+			}
+		}
+
+		if !m.Skipped {
+			if _, ok := taken[strings.ToLower(m.Target)]; !ok {
+				taken[strings.ToLower(m.Target)] = source.Path
+			}
+		}
+		mappings = append(mappings, m)
+	}
+
+	// With the fail mode the first project to claim a name is in conflict too.
+	if p.Collision != CollisionSuffix && p.Collision != CollisionSkip {
+		for i := range mappings {
+			if mappings[i].Conflict != "" {
+				continue
+			}
+			for _, other := range mappings {
+				if other.Source != mappings[i].Source && other.Conflict != "" &&
+					strings.EqualFold(other.Target, mappings[i].Target) {
+					mappings[i].Conflict = conflictDetail(mappings[i].Target, other.Source)
+					break
+				}
+			}
+		}
+	}
+
+	return mappings
+}
+
+func conflictDetail(target, owner string) string {
+	if owner == "" {
+		return fmt.Sprintf("repository %s already exists", target)
+	}
+	return fmt.Sprintf("%s also migrates to %s", owner, target)
+}
@@ -0,0 +1,142 @@
+package naming
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPolicyName(t *testing.T) {
+	tests := []struct {
+		strategy Strategy
+		path     string
+		want     string
+	}{
+		{StrategyLastSegment, "group/sub/api", "api"},
+		{StrategyFlattenPath, "group/sub/api", "group-sub-api"},
+		{StrategyPrefixGroup, "group/sub/api", "sub-api"},
+		{StrategyPrefixGroup, "api", "api"},
+		{StrategyLastSegment, "group/my project+", "my-project-"},
+	}
+
+	for _, tt := range tests {
+		got := Policy{Strategy: tt.strategy}.Name(tt.path)
+		if got != tt.want {
+			t.Errorf("%s: Name(%q) = %q, want %q", tt.strategy, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestPolicyResolve(t *testing.T) {
+	sources := []Source{
+		{Path: "group/a/api"},
+		{Path: "group/b/api"},
+		{Path: "group/web"},
+		{Path: "group/c/tools", Target: "platform-tools"},
+	}
+	existing := []string{"Web"}
+
+	tests := []struct {
+		collision CollisionMode
+		want      []Mapping
+	}{
+		{CollisionFail, []Mapping{
+			{Source: "group/a/api", Target: "api", Conflict: "group/b/api also migrates to api"},
+			{Source: "group/b/api", Target: "api", Conflict: "group/a/api also migrates to api"},
+			{Source: "group/web", Target: "web", Conflict: "repository web already exists"},
+			{Source: "group/c/tools", Target: "platform-tools"},
+		}},
+		{CollisionSuffix, []Mapping{
+			{Source: "group/a/api", Target: "api"},
+			{Source: "group/b/api", Target: "api-2"},
+			{Source: "group/web", Target: "web-2"},
+			{Source: "group/c/tools", Target: "platform-tools"},
+		}},
+		{CollisionSkip, []Mapping{
+			{Source: "group/a/api", Target: "api"},
+			{Source: "group/b/api", Target: "api", Skipped: true},
+			{Source: "group/web", Target: "web", Skipped: true},
+			{Source: "group/c/tools", Target: "platform-tools"},
+		}},
+	}
+
+	for _, tt := range tests {
+		policy := Policy{Strategy: StrategyLastSegment, Collision: tt.collision}
+		got := policy.Resolve(sources, existing)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Resolve() = %+v, want %+v", tt.collision, got, tt.want)
+		}
+	}
+}
+
+func TestPolicyResolveExplicitTarget(t *testing.T) {
+	policy := Policy{Strategy: StrategyLastSegment, Collision: CollisionSuffix}
+	got := policy.Resolve([]Source{{Path: "group/api", Target: "Tools"}}, []string{"tools"})
+	want := []Mapping{{Source: "group/api", Target: "Tools", Conflict: "repository Tools already exists"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Resolve() = %+v, want the explicit target in conflict instead of suffixed", got)
+	}
+}
+
+// organization is an Organization with the given repositories that counts
+// how often they are listed.
+type organization struct {
+	names    []string
+	listings int
+}
+
+func (o *organization) Exists(_ context.Context, name string) (bool, error) {
+	for _, existing := range o.names {
+		if strings.EqualFold(existing, name) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (o *organization) Names(context.Context) ([]string, error) {
+	o.listings++
+	return o.names, nil
+}
+
+func TestPolicyResolveIn(t *testing.T) {
+	sources := []Source{{Path: "group/a/api"}, {Path: "group/b/api"}, {Path: "group/web"}}
+
+	tests := []struct {
+		collision    CollisionMode
+		want         []Mapping
+		wantListings int
+	}{
+		{CollisionFail, []Mapping{
+			{Source: "group/a/api", Target: "api", Conflict: "group/b/api also migrates to api"},
+			{Source: "group/b/api", Target: "api", Conflict: "group/a/api also migrates to api"},
+			{Source: "group/web", Target: "web", Conflict: "repository web already exists"},
+		}, 0},
+		{CollisionSkip, []Mapping{
+			{Source: "group/a/api", Target: "api"},
+			{Source: "group/b/api", Target: "api", Skipped: true},
+			{Source: "group/web", Target: "web", Skipped: true},
+		}, 0},
+		{CollisionSuffix, []Mapping{
+			{Source: "group/a/api", Target: "api"},
+			{Source: "group/b/api", Target: "api-2"},
+			{Source: "group/web", Target: "web-2"},
+		}, 1},
+	}
+
+	for _, tt := range tests {
+		org := &organization{names: []string{"Web"}}
+		policy := Policy{Strategy: StrategyLastSegment, Collision: tt.collision}
+		got, err := policy.ResolveIn(context.Background(), sources, org)
+		if err != nil {
+			t.Fatalf("%s: ResolveIn() error = %v", tt.collision, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: ResolveIn() = %+v, want %+v", tt.collision, got, tt.want)
+		}
+		if org.listings != tt.wantListings {
+			t.Errorf("%s: listed the organization %d times, want %d", tt.collision, org.listings, tt.wantListings)
+		}
+	}
+}
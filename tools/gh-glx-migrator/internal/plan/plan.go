@@ -0,0 +1,128 @@
+package plan
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Entry is a single GitLab project of a migration plan.
+type Entry struct {
+	// Line is the line of the plan file the entry was read from.
+	Line              int
+	GLNamespace       string
+	GLProject         string
+	PathWithNamespace string
+	SourceURL         string
+	// TargetRepo is the GitHub repository name, when the plan sets one.
+	TargetRepo     string
+	RepositorySize int64
+	LFSSize        int64
+}
+
+// Read parses a migration plan CSV. The columns are matched by header name, so
+// the CSV written by the inventory command can be used as is. Every row must
+// identify its project with path_with_namespace, gl_namespace and gl_project,
+// or source_url. The target_repo, repository_size and lfs_size columns are
+// optional.
+func Read(path string) ([]Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open plan: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan header: %v", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	var entries []Entry
+	line := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("failed to read plan line %d: %v", line, err)
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		entry := Entry{
+			Line:              line,
+			GLNamespace:       field("gl_namespace"),
+			GLProject:         field("gl_project"),
+			PathWithNamespace: field("path_with_namespace"),
+			SourceURL:         field("source_url"),
+			TargetRepo:        field("target_repo"),
+		}
+		if entry.PathWithNamespace == "" && entry.GLNamespace != "" && entry.GLProject != "" {
+			entry.PathWithNamespace = entry.GLNamespace + "/" + entry.GLProject
+		}
+		if entry.PathWithNamespace == "" && entry.SourceURL == "" {
+			return nil, fmt.Errorf("plan line %d does not identify a project: set path_with_namespace, gl_namespace and gl_project, or source_url", line)
+		}
+
+		if entry.RepositorySize, err = parseSize(field("repository_size")); err != nil {
+			return nil, fmt.Errorf("plan line %d: invalid repository_size: %v", line, err)
+		}
+		if entry.LFSSize, err = parseSize(field("lfs_size")); err != nil {
+			return nil, fmt.Errorf("plan line %d: invalid lfs_size: %v", line, err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// Project returns the GitLab path of the project, derived from the source URL
+// when the plan does not set it.
+func (e Entry) Project() string {
+	if e.PathWithNamespace != "" {
+		return e.PathWithNamespace
+	}
+	return ProjectPath(e.SourceURL)
+}
+
+// ProjectPath returns the namespace/project path of a GitLab project URL, such
+// as group/subgroup/project for https://gitlab.com/group/subgroup/project.git.
+func ProjectPath(sourceURL string) string {
+	path := sourceURL
+	if i := strings.Index(path, "://"); i >= 0 {
+		path = path[i+3:]
+	}
+	if i := strings.Index(path, "/"); i >= 0 {
+		path = path[i+1:]
+	}
+	return strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+}
+
+func parseSize(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
@@ -0,0 +1,100 @@
+package preflight
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	ghlog "github.com/ps-resources/gh-glx-migrator/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// gitObject is a blob found in a repository.
+type gitObject struct {
+	Name string
+	Size int64
+}
+
+// largeObjects mirrors the repository into a temporary directory and returns
+// the blobs larger than limit.
+func largeObjects(ctx context.Context, repoURL, token string, limit int64) ([]gitObject, error) {
+	dir, err := os.MkdirTemp("", "gh-glx-preflight-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			ghlog.Logger.Error("failed to remove temporary directory", zap.String("dir", dir), zap.Error(err))
+		}
+	}()
+
+	clone, err := cloneCommand(ctx, repoURL, token, dir)
+	if err != nil {
+		return nil, err
+	}
+	if output, err := clone.CombinedOutput(); err != nil {
+		// Keep the token out of errors, should git ever echo its configuration.
+		message := strings.TrimSpace(string(output))
+		if token != "" {
+			message = strings.ReplaceAll(message, token, "***")
+		}
+		return nil, fmt.Errorf("failed to clone %s: %s", repoURL, message)
+	}
+
+	list := exec.CommandContext(ctx, "git", "-C", dir, "cat-file", "--batch-all-objects", "--batch-check=%(objecttype) %(objectname) %(objectsize)")
+	output, err := list.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects of %s: %v", repoURL, err)
+	}
+
+	var objects []gitObject
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 || fields[0] != "blob" {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			continue
+		}
+		if size > limit {
+			objects = append(objects, gitObject{Name: fields[1], Size: size})
+		}
+	}
+
+	return objects, scanner.Err()
+}
+
+// cloneCommand returns the git command that mirrors repoURL into dir. The
+// token is sent as an Authorization header configured through the GIT_CONFIG_*
+// environment, scoped to the host of the repository, so that it appears
+// neither in the clone URL nor on the command line, where ps and /proc would
+// show it to other users.
+func cloneCommand(ctx context.Context, repoURL, token, dir string) (*exec.Cmd, error) {
+	cloneURL, err := url.Parse(repoURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid repository URL %q: %v", repoURL, err)
+	}
+	cloneURL.User = nil
+
+	clone := exec.CommandContext(ctx, "git", "clone", "--quiet", "--mirror", cloneURL.String(), dir)
+	clone.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if token != "" {
+		credentials := base64.StdEncoding.EncodeToString([]byte("oauth2:" + token))
+		clone.Env = append(clone.Env,
+			"GIT_CONFIG_COUNT=1",
+			fmt.Sprintf("GIT_CONFIG_KEY_0=http.%s://%s/.extraHeader", cloneURL.Scheme, cloneURL.Host),
+			"GIT_CONFIG_VALUE_0=Authorization: Basic "+credentials,
+		)
+	}
+	return clone, nil
+}
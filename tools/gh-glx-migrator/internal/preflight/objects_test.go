@@ -0,0 +1,45 @@
+package preflight
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCloneCommandKeepsTokenOffCommandLine(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	var mu sync.Mutex
+	var authorization []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		authorization = append(authorization, r.Header.Get("Authorization"))
+		mu.Unlock()
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	token := "glpat-secret"
+	clone, err := cloneCommand(context.Background(), server.URL+"/group/api.git", token, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args := strings.Join(clone.Args, " "); strings.Contains(args, token) {
+		t.Errorf("clone command line %q contains the token", args)
+	}
+
+	// The server is not a git server, so only the request headers matter.
+	_ = clone.Run()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(authorization) == 0 || authorization[0] != "Basic b2F1dGgyOmdscGF0LXNlY3JldA==" {
+		t.Errorf("Authorization headers = %q, want the token as oauth2 basic credentials", authorization)
+	}
+}
@@ -0,0 +1,259 @@
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ps-resources/gh-glx-migrator/internal/github"
+	gl "github.com/ps-resources/gh-glx-migrator/internal/gitlab"
+	"github.com/ps-resources/gh-glx-migrator/internal/naming"
+	"github.com/ps-resources/gh-glx-migrator/internal/plan"
+	ghlog "github.com/ps-resources/gh-glx-migrator/pkg/logger"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+	"go.uber.org/zap"
+)
+
+// Severity tells whether a finding stops the migration of a project.
+type Severity string
+
+const (
+	// SeverityBlocker is a finding that makes the GEI migration fail.
+	SeverityBlocker Severity = "blocker"
+	// SeverityWarning is a finding that loses data or needs manual follow-up
+	// but does not stop the migration.
+	SeverityWarning Severity = "warning"
+)
+
+// Names of the checks reported in findings.
+const (
+	CheckProject      = "project"
+	CheckTargetName   = "target-name"
+	CheckNameConflict = "name-collision"
+	CheckArchiveSize  = "archive-size"
+	CheckLargeObject  = "large-object"
+	CheckLFS          = "lfs-objects"
+	CheckRefName      = "ref-name"
+	CheckRefCount     = "ref-count"
+)
+
+// MaxRefNameLength is the longest ref name, in bytes, GEI migrates.
+const MaxRefNameLength = 255
+
+// Finding is a single problem found in a project of the plan.
+type Finding struct {
+	Check    string   `json:"check"`
+	Severity Severity `json:"severity"`
+	Detail   string   `json:"detail"`
+}
+
+// Result holds the findings for one project of the plan.
+type Result struct {
+	Line       int       `json:"line"`
+	Project    string    `json:"project"`
+	TargetRepo string    `json:"target_repo"`
+	Findings   []Finding `json:"findings"`
+}
+
+// Blockers returns the number of blocker findings of the result.
+func (r Result) Blockers() int {
+	count := 0
+	for _, f := range r.Findings {
+		if f.Severity == SeverityBlocker {
+			count++
+		}
+	}
+	return count
+}
+
+func (r *Result) add(check string, severity Severity, format string, args ...interface{}) {
+	r.Findings = append(r.Findings, Finding{
+		Check:    check,
+		Severity: severity,
+		Detail:   fmt.Sprintf(format, args...),
+	})
+}
+
+// Options configures the limits checked by Run.
+type Options struct {
+	// Org is the target GitHub organization. Existing repositories are only
+	// checked for name collisions when it is set.
+	Org               string
+	MaxRepositorySize int64
+	MaxObjectSize     int64
+	MaxRefs           int
+	// InspectObjects clones each repository to find objects larger than
+	// MaxObjectSize. GitLabToken is used to authenticate the clone.
+	InspectObjects bool
+	GitLabToken    string
+	Concurrency    int
+	// Naming resolves the target repository names.
+	Naming naming.Policy
+}
+
+// Run checks every entry of the plan for known GitHub Enterprise Importer
+// blockers and returns one result per entry, in plan order. Target names are
+// resolved with the naming policy of opts.
+func Run(ctx context.Context, client *gitlab.Client, entries []plan.Entry, opts Options) ([]Result, error) {
+	var repositories naming.Organization
+	if opts.Org != "" {
+		target, err := github.DefaultTarget()
+		if err != nil {
+			return nil, err
+		}
+		repositories = github.OrgRepositories{Target: target, Org: opts.Org}
+	}
+
+	mappings, err := opts.Naming.ResolveIn(ctx, NamingSources(entries), repositories)
+	if err != nil {
+		return nil, err
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]Result, len(entries))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, entry := range entries {
+		result := &results[i]
+		result.Line = entry.Line
+		result.Project = entry.Project()
+		result.TargetRepo = mappings[i].Target
+
+		if err := github.ValidateRepositoryName(result.TargetRepo); err != nil {
+			result.add(CheckTargetName, SeverityBlocker, "%v", err)
+		}
+		if mappings[i].Conflict != "" {
+			result.add(CheckNameConflict, SeverityBlocker, "%s", mappings[i].Conflict)
+		}
+		if mappings[i].Skipped {
+			result.add(CheckNameConflict, SeverityWarning, "skipped, %s is already taken", result.TargetRepo)
+			continue
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(entry plan.Entry, result *Result) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			checkProject(ctx, client, entry, opts, result)
+		}(entry, result)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// NamingSources converts plan entries to naming sources, keeping the
+// target_repo column as an explicit name.
+func NamingSources(entries []plan.Entry) []naming.Source {
+	sources := make([]naming.Source, 0, len(entries))
+	for _, entry := range entries {
+		sources = append(sources, naming.Source{Path: entry.Project(), Target: entry.TargetRepo})
+	}
+	return sources
+}
+
+// checkProject runs the checks that need the GitLab API or the repository.
+func checkProject(ctx context.Context, client *gitlab.Client, entry plan.Entry, opts Options, result *Result) {
+	ghlog.Logger.Debug("Checking project", zap.String("project", result.Project))
+
+	statistics := true
+	project, _, err := client.Projects.GetProject(result.Project, &gitlab.GetProjectOptions{Statistics: &statistics}, gitlab.WithContext(ctx))
+	if err != nil {
+		result.add(CheckProject, SeverityBlocker, "failed to read project: %v", err)
+		return
+	}
+
+	repositorySize, lfsSize := entry.RepositorySize, entry.LFSSize
+	if project.Statistics != nil {
+		repositorySize = project.Statistics.RepositorySize
+		lfsSize = project.Statistics.LFSObjectsSize
+	}
+
+	if opts.MaxRepositorySize > 0 && repositorySize > opts.MaxRepositorySize {
+		result.add(CheckArchiveSize, SeverityBlocker, "repository is %s, the limit is %s",
+			formatBytes(repositorySize), formatBytes(opts.MaxRepositorySize))
+	}
+
+	if lfsSize > 0 {
+		result.add(CheckLFS, SeverityWarning, "%s of LFS objects are not migrated and must be pushed separately",
+			formatBytes(lfsSize))
+	}
+
+	refs, err := gl.ListRefs(ctx, client, result.Project)
+	if err != nil {
+		result.add(CheckRefName, SeverityWarning, "refs not checked: %v", err)
+	} else {
+		if opts.MaxRefs > 0 && len(refs) > opts.MaxRefs {
+			result.add(CheckRefCount, SeverityWarning, "%d branches and tags, more than %d", len(refs), opts.MaxRefs)
+		}
+		for _, ref := range refs {
+			if err := ValidateRefName(ref); err != nil {
+				result.add(CheckRefName, SeverityBlocker, "%v", err)
+			}
+		}
+	}
+
+	if opts.InspectObjects {
+		objects, err := largeObjects(ctx, project.HTTPURLToRepo, opts.GitLabToken, opts.MaxObjectSize)
+		if err != nil {
+			result.add(CheckLargeObject, SeverityWarning, "objects not checked: %v", err)
+		}
+		for _, object := range objects {
+			result.add(CheckLargeObject, SeverityBlocker, "blob %s is %s, the limit is %s",
+				object.Name, formatBytes(object.Size), formatBytes(opts.MaxObjectSize))
+		}
+	}
+}
+
+// ValidateRefName reports why a ref name cannot be migrated, following the
+// rules of git check-ref-format and the GEI ref length limit.
+func ValidateRefName(ref string) error {
+	if len(ref) > MaxRefNameLength {
+		return fmt.Errorf("ref %s is longer than %d bytes", ref, MaxRefNameLength)
+	}
+	if strings.HasSuffix(ref, "/") || strings.HasSuffix(ref, ".") {
+		return fmt.Errorf("ref %s ends with %q", ref, ref[len(ref)-1:])
+	}
+	for _, seq := range []string{"..", "@{", "//"} {
+		if strings.Contains(ref, seq) {
+			return fmt.Errorf("ref %s contains %q", ref, seq)
+		}
+	}
+	for _, r := range ref {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(" ~^:?*[\\", r) {
+			return fmt.Errorf("ref %s contains invalid character %q", ref, r)
+		}
+	}
+	for _, component := range strings.Split(ref, "/") {
+		if strings.HasPrefix(component, ".") || strings.HasSuffix(component, ".lock") {
+			return fmt.Errorf("ref %s has invalid component %q", ref, component)
+		}
+	}
+	return nil
+}
+
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
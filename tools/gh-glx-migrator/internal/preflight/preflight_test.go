@@ -0,0 +1,35 @@
+package preflight
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateRefName(t *testing.T) {
+	tests := []struct {
+		ref   string
+		valid bool
+	}{
+		{"refs/heads/main", true},
+		{"refs/heads/feature/login-v2", true},
+		{"refs/tags/v1.0.0", true},
+		{"refs/heads/" + strings.Repeat("a", MaxRefNameLength), false},
+		{"refs/heads/a..b", false},
+		{"refs/heads/a b", false},
+		{"refs/heads/fix:bug", false},
+		{"refs/heads/topic.lock", false},
+		{"refs/heads/.hidden", false},
+		{"refs/heads/branch.", false},
+		{"refs/heads/a@{1}", false},
+	}
+
+	for _, tt := range tests {
+		err := ValidateRefName(tt.ref)
+		if tt.valid && err != nil {
+			t.Errorf("ValidateRefName(%q) = %v, want nil", tt.ref, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("ValidateRefName(%q) = nil, want error", tt.ref)
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StateError is the state of a repository whose migration failed before
+// GitHub reported a state, for example because the upload failed.
+const StateError = "ERROR"
+
+// StateSkipped is the state of a repository that was not migrated because the
+// target repository already exists.
+const StateSkipped = "SKIPPED"
+
+// Phase is the time spent in one phase of a repository migration.
+type Phase struct {
+	Name    string  `json:"name"`
+	Seconds float64 `json:"seconds"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// Repository is the outcome of the migration of one repository.
+type Repository struct {
+	SourceURL       string    `json:"source_url"`
+	TargetRepo      string    `json:"target_repo"`
+	MigrationID     string    `json:"migration_id,omitempty"`
+	ArchiveSize     int64     `json:"archive_size"`
+	Storage         string    `json:"storage"`
+	State           string    `json:"state"`
+	FailureReason   string    `json:"failure_reason,omitempty"`
+	Warnings        int       `json:"warnings"`
+	MigrationLogURL string    `json:"migration_log_url,omitempty"`
+	StartedAt       time.Time `json:"started_at"`
+	FinishedAt      time.Time `json:"finished_at"`
+	Phases          []Phase   `json:"phases"`
+}
+
+// AddPhase records the duration and error of a finished phase.
+func (r *Repository) AddPhase(name string, duration time.Duration, err error) {
+	phase := Phase{Name: name, Seconds: duration.Round(time.Millisecond).Seconds()}
+	if err != nil {
+		phase.Error = err.Error()
+	}
+	r.Phases = append(r.Phases, phase)
+}
+
+// Report lists the repositories of one or more migration runs.
+type Report struct {
+	Repositories []Repository `json:"repositories"`
+}
+
+// Load reads a JSON report. A missing file is an empty report.
+func Load(path string) (*Report, error) {
+	report := &Report{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return report, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report %s: %v", path, err)
+	}
+	if err := json.Unmarshal(data, report); err != nil {
+		return nil, fmt.Errorf("failed to parse report %s: %v", path, err)
+	}
+	return report, nil
+}
+
+// Append adds repository to the JSON report at jsonPath, so the imports of a
+// batch end up in one report, and renders the resulting report as Markdown to
+// markdownPath. Either path may be empty to skip that format.
+//
+// Concurrent imports may share a report: the JSON report is locked while it is
+// read and rewritten, and both files are replaced atomically, so readers never
+// see a partial report.
+func Append(jsonPath, markdownPath string, repository Repository) error {
+	appendMu.Lock()
+	defer appendMu.Unlock()
+
+	report := &Report{}
+	if jsonPath != "" {
+		unlock, err := lock(jsonPath)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		if report, err = Load(jsonPath); err != nil {
+			return err
+		}
+	}
+	report.Repositories = append(report.Repositories, repository)
+
+	if jsonPath != "" {
+		jsonData, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal report: %v", err)
+		}
+		if err := writeFile(jsonPath, jsonData); err != nil {
+			return err
+		}
+	}
+
+	if markdownPath != "" {
+		if err := writeFile(markdownPath, []byte(report.Markdown())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Update rewrites the file at path with what update returns for its current
+// contents, which are nil when it does not exist. Like Append, it holds the
+// lock of path while the file is read and rewritten, and replaces it
+// atomically, so other run reports can be shared by concurrent runs.
+func Update(path string, update func(data []byte) ([]byte, error)) error {
+	appendMu.Lock()
+	defer appendMu.Unlock()
+
+	unlock, err := lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read report %s: %v", path, err)
+	}
+	if data, err = update(data); err != nil {
+		return err
+	}
+	return writeFile(path, data)
+}
+
+// appendMu serializes the appends and updates of one process; lock
+// serializes those of separate processes.
+var appendMu sync.Mutex
+
+var (
+	// lockTimeout is how long Append and Update wait for another run to
+	// release the report.
+	lockTimeout = 2 * time.Minute
+	// staleLockAge is the age at which a lock is assumed to be left behind
+	// by a run that was killed, and is removed.
+	staleLockAge = time.Minute
+)
+
+// lock creates <path>.lock, waiting while another run holds it, and returns
+// the function that removes it.
+func lock(path string) (func(), error) {
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		file, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err == nil {
+			_ = file.Close()
+			return func() { _ = os.Remove(lockPath) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to lock report %s: %v", path, err)
+		}
+
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			_ = os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for another run to release %s", lockPath)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// writeFile replaces the file at path with data through a temporary file in
+// the same directory, so the file is never left half written.
+func writeFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write report %s: %v", path, err)
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0o644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write report %s: %v", path, err)
+	}
+	return nil
+}
+
+// Markdown renders the report as GitHub-flavored Markdown, suitable for
+// $GITHUB_STEP_SUMMARY or an issue comment.
+func (r *Report) Markdown() string {
+	var b strings.Builder
+
+	counts := map[string]int{}
+	var states []string
+	for _, repository := range r.Repositories {
+		if counts[repository.State] == 0 {
+			states = append(states, repository.State)
+		}
+		counts[repository.State]++
+	}
+
+	// Phases are columns, in the order they first ran.
+	var phases []string
+	seen := map[string]bool{}
+	for _, repository := range r.Repositories {
+		for _, phase := range repository.Phases {
+			if !seen[phase.Name] {
+				seen[phase.Name] = true
+				phases = append(phases, phase.Name)
+			}
+		}
+	}
+
+	b.WriteString("## GitLab to GitHub migration report\n\n")
+	summary := make([]string, 0, len(states))
+	for _, state := range states {
+		summary = append(summary, fmt.Sprintf("%d %s", counts[state], state))
+	}
+	fmt.Fprintf(&b, "%d repositories: %s\n\n", len(r.Repositories), strings.Join(summary, ", "))
+
+	header := []string{"Source", "Target", "State", "Migration ID", "Storage", "Archive size"}
+	header = append(header, phases...)
+	header = append(header, "Warnings")
+	writeRow(&b, header)
+	separator := make([]string, len(header))
+	for i := range separator {
+		separator[i] = "---"
+	}
+	writeRow(&b, separator)
+
+	for _, repository := range r.Repositories {
+		row := []string{
+			repository.SourceURL,
+			repository.TargetRepo,
+			repository.State,
+			repository.MigrationID,
+			repository.Storage,
+			fmt.Sprintf("%.1f MiB", float64(repository.ArchiveSize)/(1024*1024)),
+		}
+		for _, name := range phases {
+			row = append(row, phaseDuration(repository.Phases, name))
+		}
+		warnings := fmt.Sprintf("%d", repository.Warnings)
+		if repository.MigrationLogURL != "" {
+			warnings = fmt.Sprintf("[%d](%s)", repository.Warnings, repository.MigrationLogURL)
+		}
+		row = append(row, warnings)
+		writeRow(&b, row)
+	}
+
+	var failures []Repository
+	for _, repository := range r.Repositories {
+		if repository.FailureReason != "" {
+			failures = append(failures, repository)
+		}
+	}
+	if len(failures) > 0 {
+		b.WriteString("\n### Failures\n\n")
+		for _, repository := range failures {
+			fmt.Fprintf(&b, "- **%s** (%s): %s\n", repository.TargetRepo, repository.SourceURL,
+				strings.ReplaceAll(repository.FailureReason, "\n", " "))
+		}
+	}
+
+	return b.String()
+}
+
+// phaseDuration formats the total time spent in the named phase, or "-" if the
+// phase did not run.
+func phaseDuration(phases []Phase, name string) string {
+	var seconds float64
+	found := false
+	for _, phase := range phases {
+		if phase.Name == name {
+			seconds += phase.Seconds
+			found = true
+		}
+	}
+	if !found {
+		return "-"
+	}
+	return time.Duration(seconds * float64(time.Second)).Round(time.Second).String()
+}
+
+func writeRow(b *strings.Builder, cells []string) {
+	b.WriteString("|")
+	for _, cell := range cells {
+		cell = strings.ReplaceAll(cell, "|", "\\|")
+		cell = strings.ReplaceAll(cell, "\n", " ")
+		fmt.Fprintf(b, " %s |", cell)
+	}
+	b.WriteString("\n")
+}
@@ -0,0 +1,210 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAppend(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "report.json")
+	markdownPath := filepath.Join(dir, "report.md")
+
+	succeeded := Repository{
+		SourceURL:       "https://gitlab.com/group/api",
+		TargetRepo:      "api",
+		MigrationID:     "RM_1",
+		ArchiveSize:     3 * 1024 * 1024,
+		Storage:         "aws",
+		State:           "SUCCEEDED",
+		Warnings:        2,
+		MigrationLogURL: "https://example.com/log",
+	}
+	succeeded.AddPhase("upload", 90*time.Second, nil)
+	succeeded.AddPhase("import", 5*time.Minute, nil)
+
+	failed := Repository{
+		SourceURL:     "https://gitlab.com/group/web",
+		TargetRepo:    "web",
+		Storage:       "github",
+		State:         StateError,
+		FailureReason: "failed to upload | retry\nlater",
+	}
+	failed.AddPhase("upload", time.Second, errors.New("failed to upload"))
+
+	if err := Append(jsonPath, markdownPath, succeeded); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	if err := Append(jsonPath, markdownPath, failed); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	report, err := Load(jsonPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(report.Repositories) != 2 {
+		t.Fatalf("Load() returned %d repositories, want 2", len(report.Repositories))
+	}
+	if got := report.Repositories[1].Phases[0].Error; got != "failed to upload" {
+		t.Errorf("phase error = %q, want %q", got, "failed to upload")
+	}
+
+	data, err := os.ReadFile(markdownPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	markdown := string(data)
+	for _, want := range []string{
+		"2 repositories: 1 SUCCEEDED, 1 ERROR",
+		"| Source | Target | State | Migration ID | Storage | Archive size | upload | import | Warnings |",
+		"| https://gitlab.com/group/api | api | SUCCEEDED | RM_1 | aws | 3.0 MiB | 1m30s | 5m0s | [2](https://example.com/log) |",
+		"| https://gitlab.com/group/web | web | ERROR |  | github | 0.0 MiB | 1s | - | 0 |",
+		"- **web** (https://gitlab.com/group/web): failed to upload | retry later",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("Markdown() does not contain %q:\n%s", want, markdown)
+		}
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	report, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(report.Repositories) != 0 {
+		t.Errorf("Load() returned %d repositories, want 0", len(report.Repositories))
+	}
+}
+
+func TestAppendConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "report.json")
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- Append(jsonPath, filepath.Join(dir, "report.md"), Repository{TargetRepo: fmt.Sprintf("repo-%d", i), State: "SUCCEEDED"})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	report, err := Load(jsonPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(report.Repositories) != 20 {
+		t.Errorf("report has %d repositories, want all 20 appends", len(report.Repositories))
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		t.Errorf("report directory holds %v, want no temporary or lock files left", names)
+	}
+}
+
+func TestAppendWaitsForLock(t *testing.T) {
+	jsonPath := filepath.Join(t.TempDir(), "report.json")
+	lockPath := jsonPath + ".lock"
+	if err := os.WriteFile(lockPath, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- Append(jsonPath, "", Repository{TargetRepo: "api"}) }()
+
+	select {
+	case err := <-done:
+		t.Fatalf("Append() = %v while another run held the lock", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+	if err := os.Remove(lockPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	// A lock left behind by a killed run is removed.
+	if err := os.WriteFile(lockPath, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * staleLockAge)
+	if err := os.Chtimes(lockPath, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := Append(jsonPath, "", Repository{TargetRepo: "web"}); err != nil {
+		t.Fatalf("Append() with a stale lock error = %v", err)
+	}
+	if report, err := Load(jsonPath); err != nil || len(report.Repositories) != 2 {
+		t.Errorf("Load() = %+v, %v, want both repositories", report, err)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "abort-report.json")
+	lockPath := path + ".lock"
+	if err := os.WriteFile(lockPath, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var got [][]byte
+	update := func(data []byte) ([]byte, error) {
+		got = append(got, data)
+		return append(data, 'x'), nil
+	}
+	done := make(chan error, 1)
+	go func() { done <- Update(path, update) }()
+
+	select {
+	case err := <-done:
+		t.Fatalf("Update() = %v while another run held the lock", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+	if err := os.Remove(lockPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if err := Update(path, update); err != nil {
+		t.Fatalf("Update() of an existing file error = %v", err)
+	}
+	if len(got) != 2 || got[0] != nil || string(got[1]) != "x" {
+		t.Errorf("update was called with %q, want nil for a missing file and then its contents", got)
+	}
+
+	failed := errors.New("invalid report")
+	if err := Update(path, func([]byte) ([]byte, error) { return nil, failed }); !errors.Is(err, failed) {
+		t.Errorf("Update() with a failing update error = %v, want %v", err, failed)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "xx" {
+		t.Errorf("file holds %q, %v after a failed update, want it unchanged", data, err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("directory holds %d files, want no temporary or lock files left", len(entries))
+	}
+}
@@ -0,0 +1,113 @@
+// Package secrets resolves references to secrets kept outside the
+// environment, such as vault://kv/migrations#gitlab_pat,
+// file:///run/secrets/gitlab_pat or env://GITLAB_TOKEN.
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Provider returns the secret a reference points to. The reference is passed
+// without its scheme, e.g. kv/migrations#gitlab_pat for
+// vault://kv/migrations#gitlab_pat.
+type Provider interface {
+	Resolve(ctx context.Context, reference string) (string, error)
+}
+
+// ProviderFunc adapts a function to a Provider.
+type ProviderFunc func(ctx context.Context, reference string) (string, error)
+
+func (f ProviderFunc) Resolve(ctx context.Context, reference string) (string, error) {
+	return f(ctx, reference)
+}
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]Provider{
+		"env":   ProviderFunc(resolveEnv),
+		"file":  ProviderFunc(resolveFile),
+		"vault": NewVaultFromEnv(),
+	}
+)
+
+// Register makes provider resolve the references with the given scheme,
+// replacing any provider registered for it before.
+func Register(scheme string, provider Provider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[scheme] = provider
+}
+
+// IsReference reports whether value is a reference to a registered provider
+// rather than a secret itself.
+func IsReference(value string) bool {
+	_, _, ok := split(value)
+	return ok
+}
+
+// Resolve returns the secret value refers to, or value itself when it is not
+// a reference.
+func Resolve(ctx context.Context, value string) (string, error) {
+	provider, reference, ok := split(value)
+	if !ok {
+		return value, nil
+	}
+	secret, err := provider.Resolve(ctx, reference)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve secret %s: %w", value, err)
+	}
+	if secret == "" {
+		return "", fmt.Errorf("secret %s is empty", value)
+	}
+	return secret, nil
+}
+
+// Schemes returns the schemes of the registered providers.
+func Schemes() []string {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	schemes := make([]string, 0, len(providers))
+	for scheme := range providers {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// split returns the provider and the reference without its scheme when value
+// starts with the scheme of a registered provider.
+func split(value string) (Provider, string, bool) {
+	scheme, reference, ok := strings.Cut(value, "://")
+	if !ok {
+		return nil, "", false
+	}
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	provider, ok := providers[scheme]
+	return provider, reference, ok
+}
+
+// resolveEnv reads the environment variable named by reference, so a setting
+// can be taken from a variable with another name, e.g. env://CI_GITLAB_TOKEN.
+func resolveEnv(_ context.Context, reference string) (string, error) {
+	value, ok := os.LookupEnv(reference)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", reference)
+	}
+	return value, nil
+}
+
+// resolveFile reads the file at the absolute path reference, as mounted by
+// Docker and Kubernetes secrets. A trailing newline is removed.
+func resolveFile(_ context.Context, reference string) (string, error) {
+	data, err := os.ReadFile(reference)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
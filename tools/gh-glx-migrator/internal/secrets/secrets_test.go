@@ -0,0 +1,123 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gitlab_pat")
+	if err := os.WriteFile(path, []byte("glpat-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CI_GITLAB_TOKEN", "glpat-env")
+
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "glpat-plain", want: "glpat-plain"},
+		{value: "https://gitlab.example.com", want: "https://gitlab.example.com"},
+		{value: "file://" + path, want: "glpat-file"},
+		{value: "env://CI_GITLAB_TOKEN", want: "glpat-env"},
+		{value: "env://GH_GLX_UNSET_VARIABLE", wantErr: true},
+		{value: "file://" + path + ".missing", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := Resolve(context.Background(), tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Resolve(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Resolve(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestVault(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/migrations":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{"data": map[string]string{"gitlab_pat": "glpat-v2", "github_pat": "ghp_v2"}},
+			})
+		case "/v1/kv/migrations":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]string{"gitlab_pat": "glpat-v1"},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	vault := &Vault{Address: server.URL, Token: "root"}
+	tests := []struct {
+		reference string
+		want      string
+		wantErr   bool
+	}{
+		{reference: "secret/migrations#gitlab_pat", want: "glpat-v2"},
+		{reference: "kv/migrations#gitlab_pat", want: "glpat-v1"},
+		{reference: "kv/migrations", want: "glpat-v1"},
+		{reference: "secret/migrations", wantErr: true},
+		{reference: "secret/migrations#azure_key", wantErr: true},
+		{reference: "secret/missing#gitlab_pat", wantErr: true},
+		{reference: "secret", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := vault.Resolve(context.Background(), tt.reference)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Resolve(%q) error = %v, wantErr %v", tt.reference, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Resolve(%q) = %q, want %q", tt.reference, got, tt.want)
+		}
+	}
+}
+
+// TestVaultDevServer runs against a real Vault, e.g. one started with
+// `make vault-dev`. It is skipped unless VAULT_ADDR and VAULT_TOKEN are set.
+func TestVaultDevServer(t *testing.T) {
+	address, token := os.Getenv("VAULT_ADDR"), os.Getenv("VAULT_TOKEN")
+	if address == "" || token == "" {
+		t.Skip("VAULT_ADDR and VAULT_TOKEN are not set")
+	}
+
+	// The dev server mounts a KV version 2 engine at secret/.
+	body, _ := json.Marshal(map[string]interface{}{"data": map[string]string{"gitlab_pat": "glpat-dev"}})
+	req, err := http.NewRequest("POST", address+"/v1/secret/data/gh-glx-test", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to write test secret: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("failed to write test secret: status %d", resp.StatusCode)
+	}
+
+	got, err := Resolve(context.Background(), "vault://secret/gh-glx-test#gitlab_pat")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if got != "glpat-dev" {
+		t.Errorf("Resolve() = %q, want %q", got, "glpat-dev")
+	}
+}
@@ -0,0 +1,160 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Vault resolves references to HashiCorp Vault KV secrets, written as
+// vault://<mount>/<path>#<key>. Both version 1 and version 2 of the KV
+// secrets engine are supported. The key may be omitted when the secret holds
+// a single value.
+type Vault struct {
+	// Address, Token and Namespace default to VAULT_ADDR, VAULT_TOKEN (or
+	// ~/.vault-token) and VAULT_NAMESPACE when empty.
+	Address    string
+	Token      string
+	Namespace  string
+	HTTPClient *http.Client
+}
+
+// NewVaultFromEnv returns a Vault provider configured from the environment
+// variables read by the vault CLI, at the time a reference is resolved.
+func NewVaultFromEnv() *Vault {
+	return &Vault{HTTPClient: &http.Client{Timeout: 30 * time.Second}}
+}
+
+func (v *Vault) Resolve(ctx context.Context, reference string) (string, error) {
+	path, key, _ := strings.Cut(reference, "#")
+	mount, secretPath, ok := strings.Cut(strings.Trim(path, "/"), "/")
+	if !ok || secretPath == "" {
+		return "", fmt.Errorf("vault reference must be vault://<mount>/<path>#<key>")
+	}
+
+	// KV version 2 nests the secret under data.data; fall back to version 1
+	// when the mount does not know the data/ prefix.
+	var v2 struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	status, err := v.read(ctx, fmt.Sprintf("%s/data/%s", mount, secretPath), &v2)
+	if err != nil {
+		return "", err
+	}
+	data := v2.Data.Data
+	if status == http.StatusNotFound {
+		var v1 struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		if status, err = v.read(ctx, fmt.Sprintf("%s/%s", mount, secretPath), &v1); err != nil {
+			return "", err
+		}
+		data = v1.Data
+	}
+	if status == http.StatusNotFound {
+		return "", fmt.Errorf("secret %s/%s not found", mount, secretPath)
+	}
+
+	if key == "" {
+		if len(data) != 1 {
+			keys := make([]string, 0, len(data))
+			for k := range data {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			return "", fmt.Errorf("secret %s/%s has keys %s, select one with #<key>", mount, secretPath, strings.Join(keys, ", "))
+		}
+		for k := range data {
+			key = k
+		}
+	}
+	value, ok := data[key]
+	if !ok {
+		return "", fmt.Errorf("secret %s/%s has no key %s", mount, secretPath, key)
+	}
+	secret, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("key %s of secret %s/%s is not a string", key, mount, secretPath)
+	}
+	return secret, nil
+}
+
+// read performs a GET request for path below /v1/ and decodes a successful
+// response into out. A 404 response is returned as status without error.
+func (v *Vault) read(ctx context.Context, path string, out interface{}) (int, error) {
+	address := v.Address
+	if address == "" {
+		address = os.Getenv("VAULT_ADDR")
+	}
+	if address == "" {
+		return 0, fmt.Errorf("VAULT_ADDR environment variable is not set")
+	}
+	token, err := v.token()
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/v1/%s", strings.TrimSuffix(address, "/"), path), nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create HTTP request: %v", err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+	namespace := v.Namespace
+	if namespace == "" {
+		namespace = os.Getenv("VAULT_NAMESPACE")
+	}
+	if namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	client := v.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read response body: %v", err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if err := json.Unmarshal(body, out); err != nil {
+			return 0, fmt.Errorf("failed to decode response: %v", err)
+		}
+	case http.StatusNotFound:
+	default:
+		return 0, fmt.Errorf("unexpected response status: %d, body: %s", resp.StatusCode, string(body))
+	}
+	return resp.StatusCode, nil
+}
+
+// token returns the Vault token, falling back to the one `vault login` saves
+// in ~/.vault-token.
+func (v *Vault) token() (string, error) {
+	if v.Token != "" {
+		return v.Token, nil
+	}
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		return token, nil
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		if data, err := os.ReadFile(filepath.Join(home, ".vault-token")); err == nil {
+			return strings.TrimSpace(string(data)), nil
+		}
+	}
+	return "", fmt.Errorf("VAULT_TOKEN environment variable is not set")
+}
@@ -0,0 +1,101 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	ghlog "github.com/ps-resources/gh-glx-migrator/pkg/logger"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+)
+
+// Phases of a repository migration.
+const (
+	PhaseExport  = "export"
+	PhaseUpload  = "upload"
+	PhaseImport  = "import"
+	PhaseCleanup = "cleanup"
+)
+
+// Registry holds the metrics of the migrator. It is separate from the default
+// registry so only migration metrics are exposed.
+var Registry = prometheus.NewRegistry()
+
+var (
+	bytesUploaded = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "glx_upload_bytes_total",
+		Help: "Bytes of migration archives uploaded, by storage backend.",
+	}, []string{"backend"})
+
+	uploadThroughput = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "glx_upload_throughput_bytes_per_second",
+		Help:    "Throughput of migration archive uploads, by storage backend.",
+		Buckets: prometheus.ExponentialBuckets(256*1024, 2, 12),
+	}, []string{"backend"})
+
+	phaseDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "glx_phase_duration_seconds",
+		Help:    "Duration of the phases of a repository migration.",
+		Buckets: prometheus.ExponentialBuckets(1, 2, 15),
+	}, []string{"phase", "result"})
+
+	apiRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "glx_api_retries_total",
+		Help: "Requests retried after a rate limit or server error, by API.",
+	}, []string{"api"})
+
+	migrations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "glx_migrations_total",
+		Help: "Repository migrations that reached a terminal state, by state.",
+	}, []string{"state"})
+)
+
+func init() {
+	Registry.MustRegister(bytesUploaded, uploadThroughput, phaseDuration, apiRetries, migrations)
+}
+
+// RecordUpload records a completed archive upload.
+func RecordUpload(backend string, bytes int64, duration time.Duration) {
+	bytesUploaded.WithLabelValues(backend).Add(float64(bytes))
+	if duration > 0 {
+		uploadThroughput.WithLabelValues(backend).Observe(float64(bytes) / duration.Seconds())
+	}
+}
+
+// RecordRetry records a retried request to the given API.
+func RecordRetry(api string) {
+	apiRetries.WithLabelValues(api).Inc()
+}
+
+// RecordMigration records a migration that reached a terminal state.
+func RecordMigration(state string) {
+	migrations.WithLabelValues(state).Inc()
+}
+
+// ServeMetrics exposes the metrics on http://addr/metrics until the returned
+// function is called.
+func ServeMetrics(addr string) (func(ctx context.Context) error, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on metrics address %s: %w", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(Registry, promhttp.HandlerOpts{EnableOpenMetrics: true}))
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			ghlog.Logger.Error("metrics server stopped", zap.Error(err))
+		}
+	}()
+
+	ghlog.Logger.Info("Serving metrics", zap.String("address", listener.Addr().String()))
+	return server.Shutdown, nil
+}
@@ -0,0 +1,99 @@
+package telemetry
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// phaseCount returns how many phases ended with result were observed.
+func phaseCount(t *testing.T, phase, result string) uint64 {
+	t.Helper()
+	return histogramCount(t, phaseDuration, phase, result)
+}
+
+func histogramCount(t *testing.T, vec *prometheus.HistogramVec, labels ...string) uint64 {
+	t.Helper()
+	var metric dto.Metric
+	if err := vec.WithLabelValues(labels...).(prometheus.Histogram).Write(&metric); err != nil {
+		t.Fatal(err)
+	}
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func TestRecordUpload(t *testing.T) {
+	bytes := testutil.ToFloat64(bytesUploaded.WithLabelValues("aws"))
+	observed := histogramCount(t, uploadThroughput, "aws")
+
+	RecordUpload("aws", 4096, 2*time.Second)
+	// An upload too fast to time adds its bytes without a throughput.
+	RecordUpload("aws", 1024, 0)
+
+	if got := testutil.ToFloat64(bytesUploaded.WithLabelValues("aws")) - bytes; got != 5120 {
+		t.Errorf("uploaded bytes grew by %v, want 5120", got)
+	}
+	if got := histogramCount(t, uploadThroughput, "aws") - observed; got != 1 {
+		t.Errorf("observed %d throughputs, want 1", got)
+	}
+}
+
+func TestRecordRetryAndMigration(t *testing.T) {
+	retries := testutil.ToFloat64(apiRetries.WithLabelValues("github"))
+	succeeded := testutil.ToFloat64(migrations.WithLabelValues("SUCCEEDED"))
+
+	RecordRetry("github")
+	RecordRetry("github")
+	RecordMigration("SUCCEEDED")
+
+	if got := testutil.ToFloat64(apiRetries.WithLabelValues("github")) - retries; got != 2 {
+		t.Errorf("github retries grew by %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(migrations.WithLabelValues("SUCCEEDED")) - succeeded; got != 1 {
+		t.Errorf("succeeded migrations grew by %v, want 1", got)
+	}
+}
+
+func TestServeMetrics(t *testing.T) {
+	// Reserve a free port for the metrics server.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	_ = listener.Close()
+
+	shutdown, err := ServeMetrics(addr)
+	if err != nil {
+		t.Fatalf("ServeMetrics() error = %v", err)
+	}
+	defer func() { _ = shutdown(context.Background()) }()
+	RecordMigration("FAILED")
+
+	resp, err := http.Get("http://" + addr + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(body), `glx_migrations_total{state="FAILED"}`) {
+		t.Errorf("metrics do not expose the failed migration:\n%s", body)
+	}
+	if strings.Contains(string(body), "go_goroutines") {
+		t.Error("metrics expose the Go runtime metrics of the default registry")
+	}
+
+	if _, err := ServeMetrics(addr); err == nil {
+		t.Error("ServeMetrics() on a used address returned no error")
+	}
+}
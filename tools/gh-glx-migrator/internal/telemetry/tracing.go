@@ -0,0 +1,77 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	ghlog "github.com/ps-resources/gh-glx-migrator/pkg/logger"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/ps-resources/gh-glx-migrator"
+
+// StartTracing exports spans over OTLP/HTTP to endpoint, such as
+// localhost:4318, until the returned function is called. Without a call to
+// StartTracing spans are discarded.
+func StartTracing(ctx context.Context, endpoint string, insecure bool) (func(ctx context.Context) error, error) {
+	options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(endpoint)}
+	if insecure {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+
+	exporter, err := otlptracehttp.New(ctx, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceName("gh-glx-migrator"),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// StartRepository starts the span that parents the phases of one repository
+// migration.
+func StartRepository(ctx context.Context, sourceURL, targetRepo string) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, "migrate-repository", trace.WithAttributes(
+		attribute.String("glx.source_url", sourceURL),
+		attribute.String("glx.target_repo", targetRepo),
+	))
+}
+
+// StartPhase starts a child span for a phase of a repository migration. The
+// returned function ends the span and records the phase duration; pass it the
+// error the phase ended with.
+func StartPhase(ctx context.Context, phase string) (context.Context, func(err error)) {
+	start := time.Now()
+	ctx, span := otel.Tracer(tracerName).Start(ctx, phase)
+
+	return ctx, func(err error) {
+		result := "success"
+		if err != nil {
+			result = "failure"
+			// Errors can quote URLs and commands, so keep secrets out of traces.
+			message := ghlog.Redact(err.Error())
+			span.RecordError(errors.New(message))
+			span.SetStatus(codes.Error, message)
+		}
+		phaseDuration.WithLabelValues(phase, result).Observe(time.Since(start).Seconds())
+		span.End()
+	}
+}
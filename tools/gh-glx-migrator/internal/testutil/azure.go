@@ -0,0 +1,176 @@
+package testutil
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Azure is a fake of Azure Blob Storage addressed like the Azurite emulator,
+// with the account name as the first path segment. It implements the list
+// containers, container and block blob requests of this module and only
+// checks that requests are signed for the account, not the signatures.
+type Azure struct {
+	Server *httptest.Server
+	// Account and AccessKey are the shared key credential of the account.
+	Account   string
+	AccessKey string
+
+	mu         sync.Mutex
+	containers map[string]map[string][]byte
+	blocks     map[string][]byte
+}
+
+// NewAzure starts a fake Azure storage account with the given containers.
+func NewAzure(t testing.TB, containers ...string) *Azure {
+	t.Helper()
+	a := &Azure{
+		Account:    "devstoreaccount1",
+		AccessKey:  base64.StdEncoding.EncodeToString([]byte("test-access-key")),
+		containers: make(map[string]map[string][]byte),
+		blocks:     make(map[string][]byte),
+	}
+	for _, container := range containers {
+		a.containers[container] = make(map[string][]byte)
+	}
+	a.Server = httptest.NewServer(http.HandlerFunc(a.serveHTTP))
+	t.Cleanup(a.Server.Close)
+	return a
+}
+
+// Env returns the environment that points the Azure client at the fake.
+func (a *Azure) Env() map[string]string {
+	return map[string]string{
+		"AZURE_STORAGE_BLOB_ENDPOINT": a.Server.URL + "/" + a.Account,
+		"AZURE_STORAGE_ACCOUNT":       a.Account,
+		"AZURE_STORAGE_ACCESS_KEY":    a.AccessKey,
+	}
+}
+
+// Blob returns the content of the blob name in container.
+func (a *Azure) Blob(container, name string) ([]byte, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	data, ok := a.containers[container][name]
+	return data, ok
+}
+
+func (a *Azure) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "SharedKey "+a.Account+":") {
+		writeAzureError(w, http.StatusForbidden, "AuthenticationFailed")
+		return
+	}
+	path, ok := strings.CutPrefix(r.URL.Path, "/"+a.Account+"/")
+	if !ok {
+		writeAzureError(w, http.StatusBadRequest, "InvalidUri")
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if path == "" && r.URL.Query().Get("comp") == "list" {
+		a.listContainers(w)
+		return
+	}
+
+	container, name, _ := strings.Cut(path, "/")
+	blobs, ok := a.containers[container]
+	if !ok {
+		writeAzureError(w, http.StatusNotFound, "ContainerNotFound")
+		return
+	}
+
+	query := r.URL.Query()
+	switch {
+	case name == "" && query.Get("restype") == "container":
+		// Get Container Properties
+		w.WriteHeader(http.StatusOK)
+
+	case r.Method == http.MethodPut && query.Get("comp") == "block":
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeAzureError(w, http.StatusBadRequest, "InvalidInput")
+			return
+		}
+		a.blocks[path+"#"+query.Get("blockid")] = data
+		w.WriteHeader(http.StatusCreated)
+
+	case r.Method == http.MethodPut && query.Get("comp") == "blocklist":
+		var blockList struct {
+			Blocks []string `xml:",any"`
+		}
+		if err := xml.NewDecoder(r.Body).Decode(&blockList); err != nil {
+			writeAzureError(w, http.StatusBadRequest, "InvalidXmlDocument")
+			return
+		}
+		var data []byte
+		for _, id := range blockList.Blocks {
+			data = append(data, a.blocks[path+"#"+id]...)
+			delete(a.blocks, path+"#"+id)
+		}
+		blobs[name] = data
+		w.WriteHeader(http.StatusCreated)
+
+	case r.Method == http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeAzureError(w, http.StatusBadRequest, "InvalidInput")
+			return
+		}
+		blobs[name] = data
+		w.WriteHeader(http.StatusCreated)
+
+	case r.Method == http.MethodGet:
+		data, ok := blobs[name]
+		if !ok {
+			writeAzureError(w, http.StatusNotFound, "BlobNotFound")
+			return
+		}
+		_, _ = w.Write(data)
+
+	case r.Method == http.MethodDelete:
+		if _, ok := blobs[name]; !ok {
+			writeAzureError(w, http.StatusNotFound, "BlobNotFound")
+			return
+		}
+		delete(blobs, name)
+		w.WriteHeader(http.StatusAccepted)
+
+	default:
+		writeAzureError(w, http.StatusMethodNotAllowed, "UnsupportedHttpVerb")
+	}
+}
+
+// listContainers answers List Containers with every container of the account.
+func (a *Azure) listContainers(w http.ResponseWriter) {
+	type container struct {
+		Name string
+	}
+	result := struct {
+		XMLName    xml.Name    `xml:"EnumerationResults"`
+		Containers []container `xml:"Containers>Container"`
+		NextMarker string
+	}{}
+	for name := range a.containers {
+		result.Containers = append(result.Containers, container{Name: name})
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(http.StatusOK)
+	_ = xml.NewEncoder(w).Encode(result)
+}
+
+func writeAzureError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.Header().Set("x-ms-error-code", code)
+	w.WriteHeader(status)
+	_ = xml.NewEncoder(w).Encode(struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string
+	}{Code: code})
+}
@@ -0,0 +1,706 @@
+package testutil
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Installation is the ID of the installation of the GitHub App of a fake
+// GitHub in its organization.
+const Installation = 42
+
+// Organization is the organization a fake GitHub migrates to.
+type Organization struct {
+	Login      string
+	ID         string
+	Name       string
+	DatabaseID int
+}
+
+// MigrationSource is a migration source created on a fake GitHub.
+type MigrationSource struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	URL     string `json:"url"`
+	OwnerID string `json:"ownerId"`
+	Type    string `json:"type"`
+}
+
+// Migration is a repository migration started on a fake GitHub, with the
+// variables of its startRepositoryMigration mutation.
+type Migration struct {
+	ID                   string `json:"-"`
+	SourceID             string `json:"sourceId"`
+	OwnerID              string `json:"ownerId"`
+	SourceRepositoryURL  string `json:"sourceRepositoryUrl"`
+	RepositoryName       string `json:"repositoryName"`
+	ContinueOnError      bool   `json:"continueOnError"`
+	GitArchiveURL        string `json:"gitArchiveUrl"`
+	MetadataArchiveURL   string `json:"metadataArchiveUrl"`
+	GithubPat            string `json:"githubPat"`
+	TargetRepoVisibility string `json:"targetRepoVisibility"`
+	LockSource           bool   `json:"lockSource"`
+}
+
+// Mannequin is a mannequin of the organization of a fake GitHub. Claimant is
+// the login of the user it was reclaimed by, if any.
+type Mannequin struct {
+	ID       string
+	Login    string
+	Claimant string
+}
+
+// Attribution is a createAttributionInvitation or reattributeMannequinToUser
+// mutation received by a fake GitHub.
+type Attribution struct {
+	OwnerID        string `json:"ownerId"`
+	SourceID       string `json:"sourceId"`
+	TargetID       string `json:"targetId"`
+	SkipInvitation bool   `json:"-"`
+}
+
+// GitHub is a fake of the GitHub GraphQL API and of GitHub-owned storage
+// uploads. It serves both from one TLS server, whose Host is set as
+// GITHUB_API_ENDPOINT and GITHUB_UPLOADS_ENDPOINT.
+type GitHub struct {
+	Server *httptest.Server
+	// Host is the host name and port of Server.
+	Host string
+
+	// Token is the token every request must carry, and Login the user it
+	// belongs to. Scopes are sent as X-OAuth-Scopes; when nil the header is
+	// left out, as for fine-grained tokens.
+	Token  string
+	Login  string
+	Scopes []string
+	Org    Organization
+	// OrgRole is the role of Login in Org: admin, member, or empty when the
+	// user is not a member. Migrators are the users and teams with the
+	// migrator role; only they and admins may list migrations.
+	OrgRole   string
+	Migrators []string
+	// Repositories are the names of the repositories of Org.
+	Repositories []string
+	// Mannequins are the mannequins of Org, and Users maps the logins of the
+	// users of the fake to their node IDs.
+	Mannequins []Mannequin
+	Users      map[string]string
+	// States are returned by the successive status queries of a migration;
+	// the last state is repeated. FailureReason, WarningsCount and
+	// MigrationLogURL are returned with every state.
+	States          []string
+	FailureReason   string
+	WarningsCount   int
+	MigrationLogURL string
+	// OnStatus, when set, is called before each status query is answered.
+	OnStatus func()
+	// OnUpload, when set, is called with each request that uploads an archive
+	// or a part of one once its body is read, before it is answered.
+	OnUpload func(r *http.Request)
+
+	mu               sync.Mutex
+	migrationSources []MigrationSource
+	migrations       []Migration
+	polls            map[string]int
+	archives         map[string][]byte
+	uploads          map[string]*multipartArchive
+	aborted          int
+	abortedMigration []string
+	attributions     []Attribution
+	listings         int
+	appKey           *rsa.PrivateKey
+	installTokens    int
+}
+
+// multipartArchive is an unfinished multipart upload to GitHub-owned storage.
+type multipartArchive struct {
+	name string
+	data []byte
+}
+
+// NewGitHub starts a fake GitHub that the test's GitHub clients trust, with
+// an organization named my-org whose migrations succeed.
+func NewGitHub(t testing.TB) *GitHub {
+	t.Helper()
+	gh := &GitHub{
+		Token:  "ghp_test",
+		Login:  "octocat",
+		Scopes: []string{"admin:org", "repo", "workflow"},
+		Org: Organization{
+			Login:      "my-org",
+			ID:         "O_kgDOAAAAAQ",
+			Name:       "My Org",
+			DatabaseID: 1001,
+		},
+		OrgRole:         "admin",
+		States:          []string{"QUEUED", "IN_PROGRESS", "SUCCEEDED"},
+		MigrationLogURL: "https://github.com/my-org/migration-logs",
+		polls:           make(map[string]int),
+		archives:        make(map[string][]byte),
+		uploads:         make(map[string]*multipartArchive),
+	}
+	gh.Server = httptest.NewTLSServer(http.HandlerFunc(gh.serveHTTP))
+	gh.Host = strings.TrimPrefix(gh.Server.URL, "https://")
+	t.Cleanup(gh.Server.Close)
+	TrustServer(t, gh.Server)
+	return gh
+}
+
+// Env returns the environment that points GitHub clients at the fake.
+func (gh *GitHub) Env() map[string]string {
+	return map[string]string{
+		"GITHUB_API_ENDPOINT":     gh.Host,
+		"GITHUB_UPLOADS_ENDPOINT": gh.Host,
+		"GITHUB_PAT":              gh.Token,
+		"GITHUB_ORG":              gh.Org.Login,
+	}
+}
+
+// AppEnv returns the environment that authenticates as the installation of a
+// GitHub App in Org instead of with GITHUB_PAT. The installation tokens the
+// fake mints are Token, and userToken is set as GH_USER_TOKEN.
+func (gh *GitHub) AppEnv(t testing.TB, userToken string) map[string]string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gh.mu.Lock()
+	gh.appKey = key
+	gh.mu.Unlock()
+
+	return map[string]string{
+		"GITHUB_API_ENDPOINT":    gh.Host,
+		"GITHUB_ORG":             gh.Org.Login,
+		"GITHUB_PAT":             "",
+		"GITHUB_GHEC_PAT":        "",
+		"GITHUB_TOKEN":           "",
+		"GH_APP_ID":              "1234",
+		"GH_APP_PRIVATE_KEY":     string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})),
+		"GH_APP_INSTALLATION_ID": strconv.Itoa(Installation),
+		"GH_USER_TOKEN":          userToken,
+	}
+}
+
+// InstallationTokens returns the number of installation tokens minted.
+func (gh *GitHub) InstallationTokens() int {
+	gh.mu.Lock()
+	defer gh.mu.Unlock()
+	return gh.installTokens
+}
+
+// MigrationSources returns the migration sources created so far.
+func (gh *GitHub) MigrationSources() []MigrationSource {
+	gh.mu.Lock()
+	defer gh.mu.Unlock()
+	return append([]MigrationSource(nil), gh.migrationSources...)
+}
+
+// Migrations returns the migrations started so far.
+func (gh *GitHub) Migrations() []Migration {
+	gh.mu.Lock()
+	defer gh.mu.Unlock()
+	return append([]Migration(nil), gh.migrations...)
+}
+
+// Archive returns the content of the archive uploaded to GitHub-owned storage
+// under uri, such as gei://archive/<guid>.
+func (gh *GitHub) Archive(uri string) ([]byte, bool) {
+	gh.mu.Lock()
+	defer gh.mu.Unlock()
+	data, ok := gh.archives[uri]
+	return data, ok
+}
+
+// AddMigration adds a migration of repositoryName from sourceURL, as if it
+// had been started, and returns its ID.
+func (gh *GitHub) AddMigration(sourceURL, repositoryName string) string {
+	gh.mu.Lock()
+	defer gh.mu.Unlock()
+	id := fmt.Sprintf("RM_%d", len(gh.migrations)+1)
+	gh.migrations = append(gh.migrations, Migration{
+		ID:                  id,
+		SourceRepositoryURL: sourceURL,
+		RepositoryName:      repositoryName,
+	})
+	return id
+}
+
+// AbortedMigrations returns the IDs of the migrations that were aborted.
+func (gh *GitHub) AbortedMigrations() []string {
+	gh.mu.Lock()
+	defer gh.mu.Unlock()
+	return append([]string(nil), gh.abortedMigration...)
+}
+
+// Attributions returns the mannequin attributions received so far.
+func (gh *GitHub) Attributions() []Attribution {
+	gh.mu.Lock()
+	defer gh.mu.Unlock()
+	return append([]Attribution(nil), gh.attributions...)
+}
+
+// RepositoryListings returns the number of queries that listed the
+// repositories of Org.
+func (gh *GitHub) RepositoryListings() int {
+	gh.mu.Lock()
+	defer gh.mu.Unlock()
+	return gh.listings
+}
+
+// AbortedUploads returns the number of multipart uploads that were aborted.
+func (gh *GitHub) AbortedUploads() int {
+	gh.mu.Lock()
+	defer gh.mu.Unlock()
+	return gh.aborted
+}
+
+func (gh *GitHub) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == fmt.Sprintf("/app/installations/%d/access_tokens", Installation) && r.Method == http.MethodPost {
+		gh.mintInstallationToken(w, r)
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer "+gh.Token {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "Bad credentials"})
+		return
+	}
+
+	gh.mu.Lock()
+	defer gh.mu.Unlock()
+
+	prefix := fmt.Sprintf("/organizations/%d/gei/archive", gh.Org.DatabaseID)
+	switch {
+	case r.URL.Path == "/graphql" && r.Method == http.MethodPost:
+		gh.serveGraphQL(w, r)
+	case strings.HasPrefix(r.URL.Path, "/repos/"+gh.Org.Login+"/") && r.Method == http.MethodGet:
+		name := strings.TrimPrefix(r.URL.Path, "/repos/"+gh.Org.Login+"/")
+		for _, repository := range gh.Repositories {
+			if strings.EqualFold(repository, name) {
+				writeJSON(w, http.StatusOK, map[string]string{"name": repository})
+				return
+			}
+		}
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+	case r.URL.Path == "/user" && r.Method == http.MethodGet:
+		if gh.Scopes != nil {
+			w.Header().Set("X-OAuth-Scopes", strings.Join(gh.Scopes, ", "))
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"login": gh.Login})
+	case r.URL.Path == "/user/memberships/orgs/"+gh.Org.Login && r.Method == http.MethodGet:
+		if gh.OrgRole == "" {
+			writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"role": gh.OrgRole, "state": "active"})
+	case r.URL.Path == prefix && r.Method == http.MethodPost:
+		gh.serveSimpleUpload(w, r)
+	case r.URL.Path == prefix+"/blobs/uploads":
+		gh.serveMultipartUpload(w, r)
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+	}
+}
+
+// serveGraphQL answers the queries and mutations of this module, which are
+// told apart by the fields they select.
+func (gh *GitHub) serveGraphQL(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Query     string          `json:"query"`
+		Variables json.RawMessage `json:"variables"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "Problems parsing JSON"})
+		return
+	}
+
+	switch {
+	case strings.Contains(request.Query, "createMigrationSource("):
+		var source MigrationSource
+		_ = json.Unmarshal(request.Variables, &source)
+		source.ID = fmt.Sprintf("MS_%d", len(gh.migrationSources)+1)
+		gh.migrationSources = append(gh.migrationSources, source)
+		writeGraphQLData(w, map[string]interface{}{
+			"createMigrationSource": map[string]interface{}{"migrationSource": source},
+		})
+
+	case strings.Contains(request.Query, "startRepositoryMigration("):
+		var migration Migration
+		_ = json.Unmarshal(request.Variables, &migration)
+		if !gh.hasMigrationSource(migration.SourceID) {
+			writeGraphQLError(w, "NOT_FOUND", fmt.Sprintf("Could not resolve to a node with the global id of '%s'", migration.SourceID))
+			return
+		}
+		migration.ID = fmt.Sprintf("RM_%d", len(gh.migrations)+1)
+		gh.migrations = append(gh.migrations, migration)
+		writeGraphQLData(w, map[string]interface{}{
+			"startRepositoryMigration": map[string]interface{}{
+				"repositoryMigration": map[string]interface{}{
+					"id":         migration.ID,
+					"databaseId": strings.TrimPrefix(migration.ID, "RM_"),
+					"sourceUrl":  migration.SourceRepositoryURL,
+				},
+			},
+		})
+
+	case strings.Contains(request.Query, "node(id:") && strings.Contains(request.Query, "... on Organization"):
+		var variables struct {
+			ID string `json:"id"`
+		}
+		_ = json.Unmarshal(request.Variables, &variables)
+		if variables.ID != gh.Org.ID {
+			writeGraphQLData(w, map[string]interface{}{"node": nil})
+			return
+		}
+		writeGraphQLData(w, map[string]interface{}{"node": map[string]string{"login": gh.Org.Login}})
+
+	case strings.Contains(request.Query, "node(id:"):
+		var variables struct {
+			ID string `json:"id"`
+		}
+		_ = json.Unmarshal(request.Variables, &variables)
+		if gh.OnStatus != nil {
+			gh.OnStatus()
+		}
+		writeGraphQLData(w, map[string]interface{}{"node": gh.migrationState(variables.ID)})
+
+	case strings.Contains(request.Query, "repositoryMigrations("):
+		if gh.OrgRole != "admin" && !gh.isMigrator(gh.Login) {
+			writeGraphQLError(w, "FORBIDDEN", fmt.Sprintf("%s does not have permission to list migrations", gh.Login))
+			return
+		}
+		// Migrations are listed newest first, in their current state.
+		nodes := make([]map[string]interface{}, 0, len(gh.migrations))
+		for i := len(gh.migrations) - 1; i >= 0; i-- {
+			migration := gh.migrations[i]
+			nodes = append(nodes, map[string]interface{}{
+				"id":             migration.ID,
+				"sourceUrl":      migration.SourceRepositoryURL,
+				"repositoryName": migration.RepositoryName,
+				"state":          gh.currentState(migration.ID),
+				"failureReason":  gh.FailureReason,
+			})
+		}
+		writeGraphQLData(w, map[string]interface{}{
+			"organization": map[string]interface{}{
+				"repositoryMigrations": map[string]interface{}{
+					"totalCount": len(nodes),
+					"pageInfo":   map[string]interface{}{"hasNextPage": false},
+					"nodes":      nodes,
+				},
+			},
+		})
+
+	case strings.Contains(request.Query, "abortRepositoryMigration("):
+		var variables struct {
+			MigrationID string `json:"migrationId"`
+		}
+		_ = json.Unmarshal(request.Variables, &variables)
+		if !gh.hasMigration(variables.MigrationID) {
+			writeGraphQLError(w, "NOT_FOUND", fmt.Sprintf("Could not resolve to a node with the global id of '%s'", variables.MigrationID))
+			return
+		}
+		gh.abortedMigration = append(gh.abortedMigration, variables.MigrationID)
+		writeGraphQLData(w, map[string]interface{}{
+			"abortRepositoryMigration": map[string]interface{}{"success": true},
+		})
+
+	case strings.Contains(request.Query, "grantMigratorRole("),
+		strings.Contains(request.Query, "revokeMigratorRole("):
+		var variables struct {
+			OrganizationID string `json:"organizationId"`
+			Actor          string `json:"actor"`
+		}
+		_ = json.Unmarshal(request.Variables, &variables)
+		if variables.OrganizationID != gh.Org.ID {
+			writeGraphQLError(w, "NOT_FOUND", fmt.Sprintf("Could not resolve to a node with the global id of '%s'", variables.OrganizationID))
+			return
+		}
+		migrators := gh.Migrators[:0:0]
+		for _, actor := range gh.Migrators {
+			if actor != variables.Actor {
+				migrators = append(migrators, actor)
+			}
+		}
+		operation := "revokeMigratorRole"
+		if strings.Contains(request.Query, "grantMigratorRole(") {
+			operation = "grantMigratorRole"
+			migrators = append(migrators, variables.Actor)
+		}
+		gh.Migrators = migrators
+		writeGraphQLData(w, map[string]interface{}{
+			operation: map[string]interface{}{"success": true},
+		})
+
+	case strings.Contains(request.Query, "mannequins("):
+		nodes := make([]map[string]interface{}, len(gh.Mannequins))
+		for i, mannequin := range gh.Mannequins {
+			node := map[string]interface{}{"id": mannequin.ID, "login": mannequin.Login}
+			if mannequin.Claimant != "" {
+				node["claimant"] = map[string]string{"id": gh.Users[mannequin.Claimant], "login": mannequin.Claimant}
+			}
+			nodes[i] = node
+		}
+		writeGraphQLData(w, map[string]interface{}{
+			"organization": map[string]interface{}{
+				"mannequins": map[string]interface{}{
+					"pageInfo": map[string]interface{}{"hasNextPage": false},
+					"nodes":    nodes,
+				},
+			},
+		})
+
+	case strings.Contains(request.Query, "user(login:"):
+		var variables struct {
+			Login string `json:"login"`
+		}
+		_ = json.Unmarshal(request.Variables, &variables)
+		if id, ok := gh.Users[variables.Login]; ok {
+			writeGraphQLData(w, map[string]interface{}{"user": map[string]string{"id": id}})
+			return
+		}
+		writeGraphQLData(w, map[string]interface{}{"user": nil})
+
+	case strings.Contains(request.Query, "createAttributionInvitation("),
+		strings.Contains(request.Query, "reattributeMannequinToUser("):
+		var attribution Attribution
+		_ = json.Unmarshal(request.Variables, &attribution)
+		attribution.SkipInvitation = strings.Contains(request.Query, "reattributeMannequinToUser(")
+		gh.attributions = append(gh.attributions, attribution)
+		writeGraphQLData(w, map[string]interface{}{})
+
+	case strings.Contains(request.Query, "repositories("):
+		gh.listings++
+		nodes := make([]map[string]string, len(gh.Repositories))
+		for i, name := range gh.Repositories {
+			nodes[i] = map[string]string{"name": name}
+		}
+		writeGraphQLData(w, map[string]interface{}{
+			"organization": map[string]interface{}{
+				"repositories": map[string]interface{}{
+					"pageInfo": map[string]interface{}{"hasNextPage": false},
+					"nodes":    nodes,
+				},
+			},
+		})
+
+	case strings.Contains(request.Query, "organization(login:"):
+		writeGraphQLData(w, map[string]interface{}{
+			"organization": map[string]interface{}{
+				"login":      gh.Org.Login,
+				"id":         gh.Org.ID,
+				"name":       gh.Org.Name,
+				"databaseId": gh.Org.DatabaseID,
+			},
+		})
+
+	default:
+		writeGraphQLError(w, "UNSUPPORTED", "the fake GitHub does not support this query")
+	}
+}
+
+func (gh *GitHub) hasMigrationSource(id string) bool {
+	for _, source := range gh.migrationSources {
+		if source.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (gh *GitHub) isMigrator(actor string) bool {
+	for _, migrator := range gh.Migrators {
+		if migrator == actor {
+			return true
+		}
+	}
+	return false
+}
+
+func (gh *GitHub) hasMigration(id string) bool {
+	for _, migration := range gh.migrations {
+		if migration.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// currentState returns the state of the migration with the given ID without
+// advancing it.
+func (gh *GitHub) currentState(id string) string {
+	poll := gh.polls[id]
+	if poll >= len(gh.States) {
+		poll = len(gh.States) - 1
+	}
+	return gh.States[poll]
+}
+
+// migrationState returns the node of the migration with the given ID, or nil
+// when there is none.
+func (gh *GitHub) migrationState(id string) interface{} {
+	for _, migration := range gh.migrations {
+		if migration.ID != id {
+			continue
+		}
+		poll := gh.polls[id]
+		gh.polls[id]++
+		if poll >= len(gh.States) {
+			poll = len(gh.States) - 1
+		}
+		return map[string]interface{}{
+			"id":              migration.ID,
+			"sourceUrl":       migration.SourceRepositoryURL,
+			"databaseId":      strings.TrimPrefix(migration.ID, "RM_"),
+			"state":           gh.States[poll],
+			"failureReason":   gh.FailureReason,
+			"repositoryName":  migration.RepositoryName,
+			"warningsCount":   gh.WarningsCount,
+			"migrationLogUrl": gh.MigrationLogURL,
+		}
+	}
+	return nil
+}
+
+// serveSimpleUpload stores an archive uploaded in a single request.
+func (gh *GitHub) serveSimpleUpload(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
+		return
+	}
+	if gh.OnUpload != nil {
+		gh.OnUpload(r)
+	}
+	guid := fmt.Sprintf("guid-%d", len(gh.archives)+1)
+	uri := "gei://archive/" + guid
+	gh.archives[uri] = data
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"guid": guid,
+		"uri":  uri,
+		"name": r.URL.Query().Get("name"),
+		"size": len(data),
+	})
+}
+
+// serveMultipartUpload implements the multipart protocol: a POST starts the
+// upload, each PATCH appends a part and returns the location of the next, a
+// PUT to the location of the last part completes it and a DELETE aborts it.
+func (gh *GitHub) serveMultipartUpload(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	location := func(guid, uploadID string, part int) string {
+		return fmt.Sprintf("%s?part_number=%d&guid=%s&upload_id=%s", r.URL.Path, part, guid, uploadID)
+	}
+
+	if r.Method == http.MethodPost {
+		var start struct {
+			Name string `json:"name"`
+			Size int64  `json:"size"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&start); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
+			return
+		}
+		guid := fmt.Sprintf("guid-%d", len(gh.archives)+len(gh.uploads)+1)
+		uploadID := "upload-" + guid
+		gh.uploads[uploadID] = &multipartArchive{name: start.Name}
+		w.Header().Set("Location", location(guid, uploadID, 1))
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	uploadID := query.Get("upload_id")
+	upload, ok := gh.uploads[uploadID]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "upload not found"})
+		return
+	}
+	guid := query.Get("guid")
+
+	switch r.Method {
+	case http.MethodPatch:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
+			return
+		}
+		if gh.OnUpload != nil {
+			gh.OnUpload(r)
+		}
+		upload.data = append(upload.data, data...)
+		part, _ := strconv.Atoi(query.Get("part_number"))
+		w.Header().Set("Location", location(guid, uploadID, part+1))
+		w.WriteHeader(http.StatusAccepted)
+	case http.MethodPut:
+		delete(gh.uploads, uploadID)
+		uri := "gei://archive/" + guid
+		gh.archives[uri] = upload.data
+		writeJSON(w, http.StatusCreated, map[string]interface{}{
+			"guid": guid,
+			"uri":  uri,
+			"name": upload.name,
+			"size": len(upload.data),
+		})
+	case http.MethodDelete:
+		delete(gh.uploads, uploadID)
+		gh.aborted++
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func writeGraphQLData(w http.ResponseWriter, data interface{}) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": data})
+}
+
+func writeGraphQLError(w http.ResponseWriter, errorType, message string) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"errors": []map[string]string{{"type": errorType, "message": message}},
+	})
+}
+
+// mintInstallationToken answers a request for an installation token signed
+// with the JWT of the app of AppEnv.
+func (gh *GitHub) mintInstallationToken(w http.ResponseWriter, r *http.Request) {
+	gh.mu.Lock()
+	defer gh.mu.Unlock()
+
+	parts := strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), ".")
+	if gh.appKey == nil || len(parts) != 3 {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "A JSON web token could not be decoded"})
+		return
+	}
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if rsa.VerifyPKCS1v15(&gh.appKey.PublicKey, crypto.SHA256, digest[:], signature) != nil {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "A JSON web token could not be decoded"})
+		return
+	}
+
+	gh.installTokens++
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"token":      gh.Token,
+		"expires_at": time.Now().Add(time.Hour),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,165 @@
+package testutil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// GitLab is a fake of the GitLab REST API that implements the user, token
+// and project requests used to verify a GitLab token, and the group and
+// project requests of an inventory.
+type GitLab struct {
+	Server *httptest.Server
+
+	// Token is the token every request must carry as PRIVATE-TOKEN.
+	Token    string
+	Username string
+	IsAdmin  bool
+	// Scopes are the scopes of Token.
+	Scopes []string
+	// Projects are the full paths of the projects the user can see.
+	Projects []string
+
+	// RepositorySize is the repository size in the statistics of every
+	// project, and MergeRequests the number of merge requests of each. With
+	// HideStatistics requests for statistics are forbidden, and with
+	// OmitTotals list responses have no X-Total header, as GitLab does for
+	// more than 10,000 items.
+	RepositorySize int64
+	MergeRequests  int
+	HideStatistics bool
+	OmitTotals     bool
+}
+
+// NewGitLab starts a fake GitLab whose user can export every project.
+func NewGitLab(t testing.TB) *GitLab {
+	t.Helper()
+	gl := &GitLab{
+		Token:    "glpat-test",
+		Username: "migrator",
+		Scopes:   []string{"api", "read_repository"},
+	}
+	gl.Server = httptest.NewServer(http.HandlerFunc(gl.serveHTTP))
+	t.Cleanup(gl.Server.Close)
+	return gl
+}
+
+// APIEndpoint returns the URL of the REST API, as GITLAB_API_ENDPOINT expects.
+func (gl *GitLab) APIEndpoint() string {
+	return gl.Server.URL + "/api/v4"
+}
+
+// Env returns the environment that points GitLab clients at the fake.
+func (gl *GitLab) Env() map[string]string {
+	return map[string]string{
+		"GITLAB_HOST":         gl.Server.URL,
+		"GITLAB_API_ENDPOINT": gl.APIEndpoint(),
+		"GITLAB_USERNAME":     gl.Username,
+		"GITLAB_PAT":          gl.Token,
+	}
+}
+
+func (gl *GitLab) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("PRIVATE-TOKEN") != gl.Token {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "401 Unauthorized"})
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.EscapedPath(), "/api/v4")
+	switch {
+	case path == "/user":
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"id":       1,
+			"username": gl.Username,
+			"is_admin": gl.IsAdmin,
+		})
+	case path == "/personal_access_tokens/self":
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"id":      1,
+			"name":    "migration",
+			"active":  true,
+			"revoked": false,
+			"scopes":  gl.Scopes,
+		})
+	case strings.HasPrefix(path, "/groups/") && strings.HasSuffix(path, "/projects"):
+		group, _ := url.PathUnescape(strings.TrimSuffix(strings.TrimPrefix(path, "/groups/"), "/projects"))
+		var projects []map[string]interface{}
+		for i, p := range gl.Projects {
+			if strings.HasPrefix(p, group+"/") {
+				projects = append(projects, gl.project(i, false))
+			}
+		}
+		gl.writeList(w, projects, len(projects))
+	case strings.HasPrefix(path, "/projects/"):
+		project, _ := url.PathUnescape(strings.TrimPrefix(path, "/projects/"))
+		// Projects are addressed by full path, or by ID followed by a resource.
+		resource := ""
+		if id, rest, ok := strings.Cut(project, "/"); ok && isNumber(id) {
+			project, resource = id, rest
+		}
+		for i, p := range gl.Projects {
+			if p != project && strconv.Itoa(i+1) != project {
+				continue
+			}
+			switch resource {
+			case "":
+				statistics := r.URL.Query().Get("statistics") == "true"
+				if statistics && gl.HideStatistics {
+					writeJSON(w, http.StatusForbidden, map[string]string{"message": "403 Forbidden"})
+					return
+				}
+				writeJSON(w, http.StatusOK, gl.project(i, statistics))
+			case "merge_requests":
+				items := make([]map[string]int, 0, 1)
+				if gl.MergeRequests > 0 {
+					items = append(items, map[string]int{"iid": 1})
+				}
+				gl.writeList(w, items, gl.MergeRequests)
+			default:
+				writeJSON(w, http.StatusNotFound, map[string]string{"message": "404 Not Found"})
+			}
+			return
+		}
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "404 Project Not Found"})
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "404 Not Found"})
+	}
+}
+
+// project returns the i-th project, with its statistics when requested.
+func (gl *GitLab) project(i int, statistics bool) map[string]interface{} {
+	full := gl.Projects[i]
+	namespace, name := full[:strings.LastIndex(full, "/")], full[strings.LastIndex(full, "/")+1:]
+	project := map[string]interface{}{
+		"id":                  i + 1,
+		"path":                name,
+		"path_with_namespace": full,
+		"web_url":             gl.Server.URL + "/" + full,
+		"namespace":           map[string]string{"full_path": namespace},
+	}
+	if statistics {
+		project["statistics"] = map[string]int64{"repository_size": gl.RepositorySize}
+	}
+	return project
+}
+
+// writeList writes one page holding items, with the pagination headers of a
+// list of total items.
+func (gl *GitLab) writeList(w http.ResponseWriter, items interface{}, total int) {
+	w.Header().Set("X-Page", "1")
+	w.Header().Set("X-Next-Page", "")
+	if !gl.OmitTotals {
+		w.Header().Set("X-Total", strconv.Itoa(total))
+		w.Header().Set("X-Total-Pages", "1")
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
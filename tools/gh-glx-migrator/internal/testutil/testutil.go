@@ -0,0 +1,47 @@
+// Package testutil provides httptest fakes of GitHub, GitLab and archive
+// storage, so commands can be tested end to end without network access.
+//
+// The fakes only implement the requests this module sends. Commands reach
+// them through the same settings an operator would use to point the module at
+// GitHub Enterprise, a GitLab instance, MinIO or Azurite, so tests set those
+// settings with SetEnv.
+package testutil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+// TrustServer makes http.DefaultTransport, which the GitHub clients are built
+// on, trust the certificate of the TLS server until the test ends. All
+// httptest TLS servers share one certificate.
+func TrustServer(t testing.TB, server *httptest.Server) {
+	t.Helper()
+	transport := http.DefaultTransport
+	http.DefaultTransport = server.Client().Transport
+	t.Cleanup(func() { http.DefaultTransport = transport })
+}
+
+// SetEnv sets each environment variable of env until the test ends.
+func SetEnv(t testing.TB, env map[string]string) {
+	t.Helper()
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+}
+
+// Chdir changes the working directory to dir until the test ends, so files a
+// command writes by default land in dir.
+func Chdir(t testing.TB, dir string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+}
@@ -0,0 +1,176 @@
+package usermap
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/ps-resources/gh-glx-migrator/internal/github"
+	"github.com/ps-resources/gh-glx-migrator/internal/gitlab"
+)
+
+// Confidence describes how reliable a proposed GitLab to GitHub user match is.
+type Confidence string
+
+const (
+	// ConfidenceHigh is a match on an email address the identity provider or
+	// the organization's verified domains vouch for.
+	ConfidenceHigh Confidence = "high"
+	// ConfidenceMedium is a match on the username known to the identity provider.
+	ConfidenceMedium Confidence = "medium"
+	// ConfidenceLow is a match on identical GitLab and GitHub logins only.
+	ConfidenceLow Confidence = "low"
+)
+
+// Match is a proposed mapping of a GitLab user to a GitHub organization member.
+type Match struct {
+	GitLabUser string
+	GitHubUser string
+	Confidence Confidence
+	MatchedOn  string
+}
+
+// Unmatched is a GitLab user that could not be mapped, with the reason why.
+type Unmatched struct {
+	User   gitlab.User
+	Reason string
+}
+
+// candidate is a GitHub login that matched a GitLab user on a given attribute.
+type candidate struct {
+	login      string
+	confidence Confidence
+	matchedOn  string
+}
+
+var confidenceRank = map[Confidence]int{
+	ConfidenceHigh:   3,
+	ConfidenceMedium: 2,
+	ConfidenceLow:    1,
+}
+
+// Map proposes a GitHub login for every GitLab user. Each user is matched on
+// the most reliable attribute available; users with no match, or with several
+// equally reliable matches, are returned as unmatched.
+func Map(users []gitlab.User, members []github.OrgMember, identities []github.ExternalIdentity) ([]Match, []Unmatched) {
+	index := newIndex(members, identities)
+
+	var matches []Match
+	var unmatched []Unmatched
+	for _, user := range users {
+		best := index.bestCandidates(user)
+		switch {
+		case len(best) == 0:
+			unmatched = append(unmatched, Unmatched{User: user, Reason: "no match"})
+		case len(best) > 1:
+			logins := make([]string, 0, len(best))
+			for _, c := range best {
+				logins = append(logins, c.login)
+			}
+			unmatched = append(unmatched, Unmatched{User: user, Reason: "ambiguous: " + strings.Join(logins, " ")})
+		default:
+			matches = append(matches, Match{
+				GitLabUser: user.Username,
+				GitHubUser: best[0].login,
+				Confidence: best[0].confidence,
+				MatchedOn:  best[0].matchedOn,
+			})
+		}
+	}
+
+	return matches, unmatched
+}
+
+// index looks up GitHub logins by normalised email address and username.
+type index struct {
+	samlEmails     map[string][]string
+	verifiedEmails map[string][]string
+	idpUsernames   map[string][]string
+	logins         map[string]string
+}
+
+func newIndex(members []github.OrgMember, identities []github.ExternalIdentity) *index {
+	idx := &index{
+		samlEmails:     make(map[string][]string),
+		verifiedEmails: make(map[string][]string),
+		idpUsernames:   make(map[string][]string),
+		logins:         make(map[string]string),
+	}
+
+	for _, member := range members {
+		idx.logins[normalise(member.Login)] = member.Login
+		for _, email := range member.VerifiedEmails {
+			addUnique(idx.verifiedEmails, email, member.Login)
+		}
+	}
+
+	for _, identity := range identities {
+		for _, email := range append(append([]string{identity.SAMLNameID}, identity.SAMLEmails...), identity.SCIMEmails...) {
+			if strings.Contains(email, "@") {
+				addUnique(idx.samlEmails, email, identity.Login)
+			}
+		}
+		for _, username := range []string{identity.SAMLUsername, identity.SCIMUsername, identity.SAMLNameID} {
+			if username != "" && !strings.Contains(username, "@") {
+				addUnique(idx.idpUsernames, username, identity.Login)
+			}
+		}
+	}
+
+	return idx
+}
+
+// bestCandidates returns the distinct GitHub logins that match the user with
+// the highest confidence found.
+func (idx *index) bestCandidates(user gitlab.User) []candidate {
+	var found []candidate
+	for _, email := range user.Emails() {
+		for _, login := range idx.samlEmails[normalise(email)] {
+			found = append(found, candidate{login, ConfidenceHigh, "saml-email"})
+		}
+		for _, login := range idx.verifiedEmails[normalise(email)] {
+			found = append(found, candidate{login, ConfidenceHigh, "verified-email"})
+		}
+	}
+	for _, login := range idx.idpUsernames[normalise(user.Username)] {
+		found = append(found, candidate{login, ConfidenceMedium, "saml-username"})
+	}
+	if login, ok := idx.logins[normalise(user.Username)]; ok {
+		found = append(found, candidate{login, ConfidenceLow, "login"})
+	}
+
+	if len(found) == 0 {
+		return nil
+	}
+
+	// Keep the first candidate per login at the best confidence level.
+	sort.SliceStable(found, func(i, j int) bool {
+		return confidenceRank[found[i].confidence] > confidenceRank[found[j].confidence]
+	})
+	var best []candidate
+	seen := make(map[string]bool)
+	for _, c := range found {
+		if c.confidence != found[0].confidence {
+			break
+		}
+		if seen[c.login] {
+			continue
+		}
+		seen[c.login] = true
+		best = append(best, c)
+	}
+	return best
+}
+
+func addUnique(m map[string][]string, key, login string) {
+	key = normalise(key)
+	for _, existing := range m[key] {
+		if existing == login {
+			return
+		}
+	}
+	m[key] = append(m[key], login)
+}
+
+func normalise(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
@@ -0,0 +1,62 @@
+package usermap
+
+import (
+	"testing"
+
+	"github.com/ps-resources/gh-glx-migrator/internal/github"
+	"github.com/ps-resources/gh-glx-migrator/internal/gitlab"
+)
+
+func TestMap(t *testing.T) {
+	members := []github.OrgMember{
+		{Login: "alice-gh", VerifiedEmails: []string{"alice@example.com"}},
+		{Login: "bob-gh"},
+		{Login: "carol"},
+		{Login: "dave-1"},
+		{Login: "dave-2"},
+	}
+	identities := []github.ExternalIdentity{
+		{Login: "bob-gh", SAMLNameID: "Bob@Example.com"},
+		{Login: "erin-gh", SCIMUsername: "erin"},
+		{Login: "dave-1", SAMLEmails: []string{"dave@example.com"}},
+		{Login: "dave-2", SCIMEmails: []string{"dave@example.com"}},
+	}
+	users := []gitlab.User{
+		{Username: "alice", CommitEmail: "alice@example.com"},
+		{Username: "bob", Email: "bob@example.com"},
+		{Username: "carol"},
+		{Username: "erin"},
+		{Username: "dave", PublicEmail: "dave@example.com"},
+		{Username: "frank", Email: "frank@example.com"},
+	}
+
+	matches, unmatched := Map(users, members, identities)
+
+	want := map[string]Match{
+		"alice": {GitLabUser: "alice", GitHubUser: "alice-gh", Confidence: ConfidenceHigh, MatchedOn: "verified-email"},
+		"bob":   {GitLabUser: "bob", GitHubUser: "bob-gh", Confidence: ConfidenceHigh, MatchedOn: "saml-email"},
+		"carol": {GitLabUser: "carol", GitHubUser: "carol", Confidence: ConfidenceLow, MatchedOn: "login"},
+		"erin":  {GitLabUser: "erin", GitHubUser: "erin-gh", Confidence: ConfidenceMedium, MatchedOn: "saml-username"},
+	}
+	if len(matches) != len(want) {
+		t.Fatalf("Map() returned %d matches, want %d: %+v", len(matches), len(want), matches)
+	}
+	for _, m := range matches {
+		if m != want[m.GitLabUser] {
+			t.Errorf("Map() match for %s = %+v, want %+v", m.GitLabUser, m, want[m.GitLabUser])
+		}
+	}
+
+	wantUnmatched := map[string]string{
+		"dave":  "ambiguous: dave-1 dave-2",
+		"frank": "no match",
+	}
+	if len(unmatched) != len(wantUnmatched) {
+		t.Fatalf("Map() returned %d unmatched users, want %d: %+v", len(unmatched), len(wantUnmatched), unmatched)
+	}
+	for _, u := range unmatched {
+		if u.Reason != wantUnmatched[u.User.Username] {
+			t.Errorf("Map() unmatched reason for %s = %q, want %q", u.User.Username, u.Reason, wantUnmatched[u.User.Username])
+		}
+	}
+}
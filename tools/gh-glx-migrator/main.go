@@ -1,24 +1,95 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/ps-resources/gh-glx-migrator/cmd"
+	"github.com/ps-resources/gh-glx-migrator/internal/config"
+	"github.com/ps-resources/gh-glx-migrator/internal/failure"
+	"github.com/ps-resources/gh-glx-migrator/internal/telemetry"
 	"github.com/ps-resources/gh-glx-migrator/pkg/logger"
 
 	"github.com/spf13/cobra"
+	"go.uber.org/zap"
 )
 
+// shutdowns stop the telemetry started for the command.
+var shutdowns []func(ctx context.Context) error
+
 func main() {
 	var rootCmd = &cobra.Command{
 		Use:   "gh-glx",
 		Short: "GitHub GitLab Migration Tool",
+		PersistentPreRunE: func(c *cobra.Command, args []string) error {
+			if err := cmd.ValidateFlags(c); err != nil {
+				return err
+			}
+
+			format, _ := c.Flags().GetString("log-format")
+			level, _ := c.Flags().GetString("log-level")
+			file, _ := c.Flags().GetString("log-file")
+			maxSize, _ := c.Flags().GetInt("log-max-size")
+			maxBackups, _ := c.Flags().GetInt("log-max-backups")
+			if err := logger.Configure(logger.Options{
+				Format:     format,
+				Level:      level,
+				File:       file,
+				MaxSizeMB:  maxSize,
+				MaxBackups: maxBackups,
+			}); err != nil {
+				return err
+			}
+
+			if err := cmd.UseProfile(c); err != nil {
+				return err
+			}
+
+			if metricsAddr, _ := c.Flags().GetString("metrics-addr"); metricsAddr != "" {
+				shutdown, err := telemetry.ServeMetrics(metricsAddr)
+				if err != nil {
+					return err
+				}
+				shutdowns = append(shutdowns, shutdown)
+			}
+
+			if otlpEndpoint, _ := c.Flags().GetString("otlp-endpoint"); otlpEndpoint != "" {
+				insecure, _ := c.Flags().GetBool("otlp-insecure")
+				shutdown, err := telemetry.StartTracing(c.Context(), otlpEndpoint, insecure)
+				if err != nil {
+					return err
+				}
+				shutdowns = append(shutdowns, shutdown)
+			}
+
+			return nil
+		},
 	}
 
+	rootCmd.PersistentFlags().String("log-format", logger.FormatConsole, "Log format (console, json)")
+	rootCmd.PersistentFlags().String("log-level", "info", "Lowest level logged (debug, info, warn, error)")
+	rootCmd.PersistentFlags().String("log-file", "", "Also write logs to this file, without colour")
+	rootCmd.PersistentFlags().Int("log-max-size", 100, "Size in MiB at which the log file is rotated")
+	rootCmd.PersistentFlags().Int("log-max-backups", 5, "Number of rotated log files to keep")
+	rootCmd.PersistentFlags().String("metrics-addr", "", "Serve Prometheus metrics on this address (e.g. :9090) while the command runs")
+	rootCmd.PersistentFlags().String("otlp-endpoint", "", "Export traces over OTLP/HTTP to this collector (e.g. localhost:4318)")
+	rootCmd.PersistentFlags().Bool("otlp-insecure", false, "Export traces without TLS, for a local collector")
+	rootCmd.PersistentFlags().String("config", config.DefaultPath(), "Configuration file with named profiles")
+	rootCmd.PersistentFlags().String("profile", os.Getenv("GH_GLX_PROFILE"), "Profile of the configuration file to use (default current-profile of the file)")
+	rootCmd.PersistentFlags().Bool("non-interactive", false, "Never prompt; fail instead when a choice such as --storage is needed. Implied when stdin is not a terminal")
+
+	rootCmd.SetFlagErrorFunc(func(c *cobra.Command, err error) error {
+		return failure.Validation(err)
+	})
+
 	// Add commands
 	rootCmd.AddCommand(
 		cmd.HelpCmd(),
+		cmd.ConfigCmd(),
 		cmd.VerifyCmd(),
 		cmd.ExportArchiveCmd(),
 		cmd.UploadToS3BucketCmd(),
@@ -29,11 +100,50 @@ func main() {
 		cmd.ExportGHECCmd(),
 		cmd.UploadToAzureCmd(),
 		cmd.ImportArchiveCmd(),
+		cmd.ListMigrationsCmd(),
+		cmd.AbortMigrationCmd(),
+		cmd.GenerateMannequinCSVCmd(),
+		cmd.ReclaimMannequinsCmd(),
+		cmd.MapUsersCmd(),
+		cmd.GrantMigratorRoleCmd(),
+		cmd.RevokeMigratorRoleCmd(),
+		cmd.InventoryCmd(),
+		cmd.PreflightCmd(),
+		cmd.MapNamesCmd(),
 	)
 
-	if err := rootCmd.Execute(); err != nil {
+	// The first SIGINT or SIGTERM cancels the command context, so in-flight
+	// uploads are aborted and the migration report is still written. Signal
+	// handling is then reset, and a second signal kills the process.
+	ctx, stop := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		logger.Logger.Warn("Received signal, shutting down", zap.String("signal", sig.String()))
+		signal.Stop(signals)
+		stop()
+	}()
+
+	err := rootCmd.ExecuteContext(ctx)
+	if err != nil && ctx.Err() != nil {
+		err = failure.Canceled(err)
+	}
+	stop()
+
+	// Flush pending spans and stop the metrics server.
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	for _, shutdown := range shutdowns {
+		if err := shutdown(shutdownCtx); err != nil {
+			logger.Logger.Error("failed to shut down telemetry", zap.Error(err))
+		}
+	}
+	cancel()
+
+	logger.SyncLogger()
+	if err != nil {
 		fmt.Println("Error:", err)
-		os.Exit(1)
+		os.Exit(failure.ExitCode(err))
 	}
 }
 
@@ -1,39 +1,100 @@
 package logger
 
 import (
+	"fmt"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/mattn/go-isatty"
 	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
 	"go.uber.org/zap/zapcore"
 )
 
-var Logger *zap.Logger
-var currentLogLevel zapcore.Level
+// Logger receives the entries of every package of the module. It discards
+// them until InitLogger or Configure is called, so the module can be used as
+// a library without configuring logging.
+var Logger = zap.NewNop()
 
-func InitLogger() {
-	config := zapcore.EncoderConfig{
-		TimeKey:        "time",
-		LevelKey:       "level",
-		MessageKey:     "msg",
-		CallerKey:      "caller",
-		NameKey:        "logger",
-		StacktraceKey:  "stacktrace",
-		LineEnding:     zapcore.DefaultLineEnding,
-		EncodeLevel:    customLevelEncoder,
-		EncodeTime:     customTimeEncoder,
-		EncodeDuration: zapcore.StringDurationEncoder,
-		EncodeCaller:   customCallerEncoder,
-		EncodeName:     zapcore.FullNameEncoder,
-	}
+// Output formats accepted by Options.Format.
+const (
+	FormatConsole = "console"
+	FormatJSON    = "json"
+)
+
+// Options configures the logger built by Configure.
+type Options struct {
+	// Format is FormatConsole or FormatJSON.
+	Format string
+	// Level is the lowest level written: debug, info, warn or error.
+	Level string
+	// File, when set, receives every entry in addition to stdout. It is
+	// rotated once it grows past MaxSizeMB, keeping MaxBackups old files.
+	File       string
+	MaxSizeMB  int
+	MaxBackups int
+}
 
-	core := zapcore.NewCore(
-		zapcore.NewConsoleEncoder(config),
+// InitLogger writes console output to stdout at Debug level, so commands can
+// log before the logging flags are parsed.
+func InitLogger() {
+	Logger = newLogger(zapcore.NewCore(
+		newConsoleEncoder(zapcore.DebugLevel, isTerminal(os.Stdout)),
 		zapcore.AddSync(os.Stdout),
 		zapcore.DebugLevel,
-	)
+	))
+}
 
-	Logger = zap.New(core, zap.AddCallerSkip(1), zap.Hooks(levelHook))
+// Configure replaces Logger with one built from opts. Colour is only used for
+// console output to a terminal; log files never contain colour codes.
+func Configure(opts Options) error {
+	level := zapcore.InfoLevel
+	if opts.Level != "" {
+		if err := level.UnmarshalText([]byte(strings.ToLower(opts.Level))); err != nil {
+			return fmt.Errorf("unknown log level: %s. Available levels: debug, info, warn, error", opts.Level)
+		}
+	}
+
+	format := strings.ToLower(opts.Format)
+	if format == "" {
+		format = FormatConsole
+	}
+	if format != FormatConsole && format != FormatJSON {
+		return fmt.Errorf("unknown log format: %s. Available formats: console, json", opts.Format)
+	}
+
+	encoder := func(color bool) zapcore.Encoder {
+		if format == FormatJSON {
+			return newJSONEncoder()
+		}
+		return newConsoleEncoder(level, color)
+	}
+
+	cores := []zapcore.Core{
+		zapcore.NewCore(encoder(isTerminal(os.Stdout)), zapcore.AddSync(os.Stdout), level),
+	}
+
+	if opts.File != "" {
+		file, err := newRotatingFile(opts.File, opts.MaxSizeMB, opts.MaxBackups)
+		if err != nil {
+			return err
+		}
+		cores = append(cores, zapcore.NewCore(encoder(false), file, level))
+	}
+
+	Logger = newLogger(zapcore.NewTee(cores...))
+	return nil
+}
+
+// newLogger wraps core so that secrets are masked in every entry.
+func newLogger(core zapcore.Core) *zap.Logger {
+	addSecretsFromEnv()
+	return zap.New(newRedactCore(core), zap.AddCaller())
+}
+
+func isTerminal(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
 }
 
 const (
@@ -43,30 +104,98 @@ const (
 	colorYellow = "\033[33m"
 	colorBlue   = "\033[34m"
 	colorCyan   = "\033[36m"
+	colorDim    = "\033[2m"
 )
 
-func levelHook(entry zapcore.Entry) error {
-	currentLogLevel = entry.Level
-	return nil
-}
-
-func customTimeEncoder(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
-	var timeColor string
-	switch currentLogLevel {
+func levelColor(level zapcore.Level) string {
+	switch level {
 	case zapcore.ErrorLevel:
-		timeColor = colorRed
+		return colorRed
 	case zapcore.WarnLevel:
-		timeColor = colorYellow
-	case zapcore.InfoLevel:
-		timeColor = colorBlue
+		return colorYellow
 	case zapcore.DebugLevel:
-		timeColor = colorGreen
+		return colorGreen
 	default:
-		timeColor = colorBlue
+		return colorBlue
+	}
+}
+
+// consoleEncoder prefixes each entry with its timestamp. The timestamp is
+// written here rather than by a zapcore.TimeEncoder because its colour depends
+// on the level of the entry, which a TimeEncoder does not receive.
+type consoleEncoder struct {
+	zapcore.Encoder
+	color bool
+}
+
+func newConsoleEncoder(level zapcore.Level, color bool) zapcore.Encoder {
+	config := zapcore.EncoderConfig{
+		LevelKey:       "level",
+		MessageKey:     "msg",
+		NameKey:        "logger",
+		StacktraceKey:  "stacktrace",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    plainLevelEncoder,
+		EncodeDuration: zapcore.StringDurationEncoder,
+		EncodeCaller:   plainCallerEncoder,
+		EncodeName:     zapcore.FullNameEncoder,
+	}
+	if color {
+		config.EncodeLevel = customLevelEncoder
+		config.EncodeCaller = customCallerEncoder
 	}
+	// The caller is only useful when debugging.
+	if level <= zapcore.DebugLevel {
+		config.CallerKey = "caller"
+	}
+
+	return consoleEncoder{Encoder: zapcore.NewConsoleEncoder(config), color: color}
+}
+
+func (e consoleEncoder) Clone() zapcore.Encoder {
+	return consoleEncoder{Encoder: e.Encoder.Clone(), color: e.color}
+}
 
-	timeStr := timeColor + "[" + t.Format("2006-01-02 15:04:05") + "]" + colorReset
-	enc.AppendString(timeStr)
+func (e consoleEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	line, err := e.Encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return nil, err
+	}
+	defer line.Free()
+
+	out := bufferPool.Get()
+	out.AppendString(formatTime(entry.Time, entry.Level, e.color))
+	out.AppendByte('\t')
+	_, _ = out.Write(line.Bytes())
+	return out, nil
+}
+
+var bufferPool = buffer.NewPool()
+
+func newJSONEncoder() zapcore.Encoder {
+	return zapcore.NewJSONEncoder(zapcore.EncoderConfig{
+		TimeKey:        "time",
+		LevelKey:       "level",
+		MessageKey:     "msg",
+		CallerKey:      "caller",
+		NameKey:        "logger",
+		StacktraceKey:  "stacktrace",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    zapcore.LowercaseLevelEncoder,
+		EncodeTime:     zapcore.RFC3339NanoTimeEncoder,
+		EncodeDuration: zapcore.StringDurationEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
+		EncodeName:     zapcore.FullNameEncoder,
+	})
+}
+
+// formatTime renders the timestamp of an entry, coloured by its level.
+func formatTime(t time.Time, level zapcore.Level, color bool) string {
+	timeStr := "[" + t.Format("2006-01-02 15:04:05") + "]"
+	if !color {
+		return timeStr
+	}
+	return levelColor(level) + timeStr + colorReset
 }
 
 func SyncLogger() {
@@ -76,14 +205,8 @@ func SyncLogger() {
 func customLevelEncoder(level zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
 	var levelStr string
 	switch level {
-	case zapcore.InfoLevel:
-		levelStr = colorBlue + "[" + level.CapitalString() + "]" + colorReset
-	case zapcore.WarnLevel:
-		levelStr = colorYellow + "[" + level.CapitalString() + "]" + colorReset
-	case zapcore.ErrorLevel:
-		levelStr = colorRed + "[" + level.CapitalString() + "]" + colorReset
-	case zapcore.DebugLevel:
-		levelStr = colorGreen + "[" + level.CapitalString() + "]" + colorReset
+	case zapcore.InfoLevel, zapcore.WarnLevel, zapcore.ErrorLevel, zapcore.DebugLevel:
+		levelStr = levelColor(level) + "[" + level.CapitalString() + "]" + colorReset
 	default:
 		levelStr = "[" + level.CapitalString() + "]"
 	}
@@ -91,13 +214,16 @@ func customLevelEncoder(level zapcore.Level, enc zapcore.PrimitiveArrayEncoder)
 	enc.AppendString(levelStr)
 }
 
+func plainLevelEncoder(level zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	enc.AppendString("[" + level.CapitalString() + "]")
+}
+
 func customCallerEncoder(caller zapcore.EntryCaller, enc zapcore.PrimitiveArrayEncoder) {
-	if Logger.Core().Enabled(zapcore.DebugLevel) {
-		const colorDim = "\033[2m"
-		const colorReset = "\033[0m"
-		callerStr := colorDim + padRight(caller.TrimmedPath(), 30) + colorReset
-		enc.AppendString(callerStr)
-	}
+	enc.AppendString(colorDim + padRight(caller.TrimmedPath(), 30) + colorReset)
+}
+
+func plainCallerEncoder(caller zapcore.EntryCaller, enc zapcore.PrimitiveArrayEncoder) {
+	enc.AppendString(padRight(caller.TrimmedPath(), 30))
 }
 
 func padRight(str string, length int) string {
@@ -2,6 +2,9 @@ package logger
 
 import (
 	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -83,15 +86,136 @@ func TestCustomLevelEncoder(t *testing.T) {
 	}
 }
 
-func TestCustomTimeEncoder(t *testing.T) {
-	enc := &testEncoder{}
+func TestFormatTime(t *testing.T) {
 	testTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
-	expected := colorBlue + "[2024-01-01 12:00:00]" + colorReset
 
-	customTimeEncoder(testTime, enc)
+	tests := []struct {
+		name     string
+		level    zapcore.Level
+		color    bool
+		expected string
+	}{
+		{"Info Level", zapcore.InfoLevel, true, colorBlue + "[2024-01-01 12:00:00]" + colorReset},
+		{"Error Level", zapcore.ErrorLevel, true, colorRed + "[2024-01-01 12:00:00]" + colorReset},
+		{"Without Colour", zapcore.ErrorLevel, false, "[2024-01-01 12:00:00]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatTime(testTime, tt.level, tt.color); got != tt.expected {
+				t.Errorf("formatTime() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConfigureJSONFile(t *testing.T) {
+	defer InitLogger()
+
+	path := filepath.Join(t.TempDir(), "gh-glx.log")
+	if err := Configure(Options{Format: FormatJSON, Level: "warn", File: path}); err != nil {
+		t.Fatalf("Configure() error = %v", err)
+	}
+
+	Logger.Info("hidden message")
+	Logger.Warn("test warning message", zap.String("repository", "api"))
+	SyncLogger()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read log file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("log file has %d entries, want 1: %q", len(lines), data)
+	}
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("log entry is not JSON: %v", err)
+	}
+	if entry["level"] != "warn" || entry["msg"] != "test warning message" || entry["repository"] != "api" {
+		t.Errorf("unexpected log entry: %v", entry)
+	}
+	if strings.Contains(string(data), "\033[") {
+		t.Errorf("log file contains colour codes: %q", data)
+	}
+}
+
+func TestConfigureInvalid(t *testing.T) {
+	if err := Configure(Options{Level: "verbose"}); err == nil {
+		t.Error("Configure() accepted an unknown level")
+	}
+	if err := Configure(Options{Format: "xml"}); err == nil {
+		t.Error("Configure() accepted an unknown format")
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gh-glx.log")
+	file, err := newRotatingFile(path, 0, 2)
+	if err != nil {
+		t.Fatalf("newRotatingFile() error = %v", err)
+	}
+	file.maxSize = 10
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := file.Write([]byte(line)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	expected := map[string]string{
+		path:        "fourth\n",
+		path + ".1": "third\n",
+		path + ".2": "second\n",
+	}
+	for name, want := range expected {
+		got, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", name, err)
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("%s.3 exists, want at most 2 backups", path)
+	}
+}
+
+func TestRotatingFileKeepsLoggingWhenRotationFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gh-glx.log")
+	file, err := newRotatingFile(path, 0, 1)
+	if err != nil {
+		t.Fatalf("newRotatingFile() error = %v", err)
+	}
+	file.maxSize = 10
+	// The log file cannot be renamed onto a directory.
+	if err := os.Mkdir(path+".1", 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := file.Write([]byte("first\n")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	n, err := file.Write([]byte("second\n"))
+	if err == nil {
+		t.Error("Write() returned no error, want the failed rotation")
+	}
+	if n != len("second\n") {
+		t.Errorf("Write() = %d, want the entry written despite the failed rotation", n)
+	}
+	if _, err := file.Write([]byte("third\n")); err == nil {
+		t.Error("Write() returned no error, want the rotation retried and failing")
+	}
 
-	if enc.String() != expected {
-		t.Errorf("customTimeEncoder() = %v, want %v", enc.String(), expected)
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "first\nsecond\nthird\n"; string(got) != want {
+		t.Errorf("%s = %q, want %q", path, got, want)
 	}
 }
 
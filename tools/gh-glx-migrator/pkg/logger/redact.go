@@ -0,0 +1,153 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Mask replaces every secret removed from log output.
+const Mask = "***"
+
+// secretEnvVars are the environment variables whose values are masked
+// wherever they appear in a log entry.
+var secretEnvVars = []string{
+	"GITHUB_PAT",
+	"GITHUB_TOKEN",
+	"GITHUB_GHEC_PAT",
+	"GH_APP_PRIVATE_KEY",
+	"GH_USER_TOKEN",
+	"GITLAB_PAT",
+	"GITLAB_API_PRIVATE_TOKEN",
+	"AWS_SECRET_ACCESS_KEY",
+	"AWS_SESSION_TOKEN",
+	"AZURE_STORAGE_ACCESS_KEY",
+}
+
+// redactPatterns match secrets by shape. The first and second groups of a
+// pattern, when present, are kept around the mask so the output still shows
+// what was removed.
+var redactPatterns = []*regexp.Regexp{
+	// GitHub classic, fine-grained, OAuth, app and refresh tokens.
+	regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`),
+	// GitLab personal, project and group access tokens.
+	regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}`),
+	// AWS access key IDs.
+	regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+	// Signatures and credentials of S3 presigned URLs.
+	regexp.MustCompile(`(?i)(X-Amz-(?:Signature|Credential|Security-Token)=)[^&\s"']+`),
+	// Signatures of Azure SAS URLs.
+	regexp.MustCompile(`([?&]sig=)[^&\s"']+`),
+	// Passwords in URLs, such as https://oauth2:<token>@gitlab.com.
+	regexp.MustCompile(`(://[^/\s:@]+:)[^/\s@]+(@)`),
+	// Authorization headers.
+	regexp.MustCompile(`(?i)((?:bearer|token)\s+)[A-Za-z0-9._~+/-]{8,}=*`),
+	// NAME=value assignments of tokens, passwords and keys, as in a docker argv.
+	regexp.MustCompile(`\b([A-Z][A-Z0-9_]*(?:TOKEN|SECRET|PASSWORD|PAT|ACCESS_KEY)=)[^\s&"']+`),
+}
+
+var (
+	secretsMu sync.RWMutex
+	secrets   []string
+)
+
+// AddSecret masks the given values wherever they appear in log output. Values
+// shorter than six characters are ignored to avoid masking common words.
+func AddSecret(values ...string) {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+
+	for _, value := range values {
+		if len(value) < 6 {
+			continue
+		}
+		known := false
+		for _, secret := range secrets {
+			if secret == value {
+				known = true
+				break
+			}
+		}
+		if !known {
+			secrets = append(secrets, value)
+		}
+	}
+	// Replace longer secrets first so one containing another is fully masked.
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+}
+
+func addSecretsFromEnv() {
+	for _, name := range secretEnvVars {
+		AddSecret(os.Getenv(name))
+	}
+}
+
+// Redact masks the known secrets and secret-shaped strings in s.
+func Redact(s string) string {
+	secretsMu.RLock()
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, Mask)
+	}
+	secretsMu.RUnlock()
+
+	for _, pattern := range redactPatterns {
+		if pattern.NumSubexp() > 0 {
+			s = pattern.ReplaceAllString(s, "${1}"+Mask+"${2}")
+		} else {
+			s = pattern.ReplaceAllString(s, Mask)
+		}
+	}
+	return s
+}
+
+// redactCore redacts the message and the string, error and stringer fields of
+// every entry before handing it to the wrapped core.
+type redactCore struct {
+	zapcore.Core
+}
+
+func newRedactCore(core zapcore.Core) zapcore.Core {
+	return &redactCore{Core: core}
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(redactFields(fields))}
+}
+
+func (c *redactCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *redactCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Message = Redact(entry.Message)
+	return c.Core.Write(entry, redactFields(fields))
+}
+
+func redactFields(fields []zapcore.Field) []zapcore.Field {
+	redacted := make([]zapcore.Field, len(fields))
+	for i, field := range fields {
+		switch field.Type {
+		case zapcore.StringType:
+			field.String = Redact(field.String)
+		case zapcore.ErrorType:
+			if err, ok := field.Interface.(error); ok && err != nil {
+				field = zap.String(field.Key, Redact(err.Error()))
+			}
+		case zapcore.StringerType:
+			if stringer, ok := field.Interface.(fmt.Stringer); ok && stringer != nil {
+				field = zap.String(field.Key, Redact(stringer.String()))
+			}
+		}
+		redacted[i] = field
+	}
+	return redacted
+}
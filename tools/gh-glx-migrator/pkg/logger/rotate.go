@@ -0,0 +1,108 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// rotatingFile is a log file that is renamed to <path>.1 once it grows past
+// maxSize bytes, shifting older backups up to <path>.<maxBackups>.
+type rotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func newRotatingFile(path string, maxSizeMB, maxBackups int) (*rotatingFile, error) {
+	r := &rotatingFile{
+		path:       path,
+		maxSize:    int64(maxSizeMB) * 1024 * 1024,
+		maxBackups: maxBackups,
+	}
+	if err := r.open(path); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open(path string) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+// Write rotates the file first when p would grow it past maxSize. When the
+// rotation fails, p is still written to the file that was not rotated, and
+// the rotation error is returned with the number of bytes written.
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var rotateErr error
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		rotateErr = r.rotate()
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, errors.Join(rotateErr, err)
+}
+
+func (r *rotatingFile) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Sync()
+}
+
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return r.reopen(r.path, fmt.Errorf("failed to close log file: %w", err))
+	}
+
+	if r.maxBackups < 1 {
+		if err := os.Remove(r.path); err != nil && !os.IsNotExist(err) {
+			return r.reopen(r.path, fmt.Errorf("failed to remove log file: %w", err))
+		}
+		return r.open(r.path)
+	}
+
+	for i := r.maxBackups - 1; i >= 1; i-- {
+		from := fmt.Sprintf("%s.%d", r.path, i)
+		if _, err := os.Stat(from); err == nil {
+			if err := os.Rename(from, fmt.Sprintf("%s.%d", r.path, i+1)); err != nil {
+				return r.reopen(r.path, fmt.Errorf("failed to rotate log file: %w", err))
+			}
+		}
+	}
+	if err := os.Rename(r.path, r.path+".1"); err != nil {
+		return r.reopen(r.path, fmt.Errorf("failed to rotate log file: %w", err))
+	}
+
+	if err := r.open(r.path); err != nil {
+		return r.reopen(r.path+".1", err)
+	}
+	return nil
+}
+
+// reopen goes on logging to path, where the file that failed to rotate is,
+// so no entries are lost, and returns cause.
+func (r *rotatingFile) reopen(path string, cause error) error {
+	if err := r.open(path); err != nil {
+		return errors.Join(cause, err)
+	}
+	return cause
+}
@@ -0,0 +1,42 @@
+package migrator
+
+import "github.com/ps-resources/gh-glx-migrator/internal/failure"
+
+// Kind is the class of an error returned by a Migrator, the same as the
+// exit codes of gh-glx. Kind.ExitCode returns that exit code.
+type Kind = failure.Kind
+
+// Kinds of the errors returned by a Migrator.
+const (
+	// KindUnknown is any error that was not classified.
+	KindUnknown = failure.KindUnknown
+	// KindValidation is a missing or invalid option.
+	KindValidation = failure.KindValidation
+	// KindAuth is a missing, invalid or insufficient credential.
+	KindAuth = failure.KindAuth
+	// KindStorage is a failed upload to or cleanup of the Store.
+	KindStorage = failure.KindStorage
+	// KindMigrationFailed is a migration that could not be started or that
+	// GitHub reports as failed.
+	KindMigrationFailed = failure.KindMigrationFailed
+	// KindTimeout is a wait for a migration that ran out of time, or an
+	// expired context deadline.
+	KindTimeout = failure.KindTimeout
+	// KindWarnings is a migration that succeeded with warnings, returned
+	// with FailOnWarnings.
+	KindWarnings = failure.KindWarnings
+	// KindCanceled is a migration interrupted by cancelling its context.
+	KindCanceled = failure.KindCanceled
+)
+
+// KindOf returns the Kind of an error returned by a Migrator, KindUnknown for
+// nil and unclassified errors.
+func KindOf(err error) Kind {
+	return failure.KindOf(err)
+}
+
+// IsWarnings reports whether err is the error of a migration that succeeded
+// with warnings, which is returned with FailOnWarnings.
+func IsWarnings(err error) bool {
+	return KindOf(err) == KindWarnings
+}
@@ -0,0 +1,196 @@
+package migrator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	ghlog "github.com/ps-resources/gh-glx-migrator/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// EventType is the kind of a lifecycle Event.
+type EventType string
+
+// Lifecycle events of exports and migrations.
+const (
+	EventExportStarted      EventType = "export.started"
+	EventExportCompleted    EventType = "export.completed"
+	EventExportFailed       EventType = "export.failed"
+	EventUploadCompleted    EventType = "upload.completed"
+	EventMigrationQueued    EventType = "migration.queued"
+	EventMigrationSucceeded EventType = "migration.succeeded"
+	EventMigrationFailed    EventType = "migration.failed"
+	// EventMigrationWarnings follows EventMigrationSucceeded when the
+	// migration log lists warnings.
+	EventMigrationWarnings EventType = "migration.warnings"
+)
+
+// EventTypes lists every EventType.
+var EventTypes = []EventType{
+	EventExportStarted, EventExportCompleted, EventExportFailed,
+	EventUploadCompleted,
+	EventMigrationQueued, EventMigrationSucceeded, EventMigrationFailed, EventMigrationWarnings,
+}
+
+// ParseEventType returns the EventType named name, e.g. migration.failed.
+func ParseEventType(name string) (EventType, error) {
+	for _, eventType := range EventTypes {
+		if string(eventType) == name {
+			return eventType, nil
+		}
+	}
+	names := make([]string, len(EventTypes))
+	for i, eventType := range EventTypes {
+		names[i] = string(eventType)
+	}
+	return "", fmt.Errorf("unknown event: %s. Available events: %s", name, strings.Join(names, ", "))
+}
+
+// Event is a step in the lifecycle of an export or a migration. Fields that
+// do not apply to the type of the event are empty.
+type Event struct {
+	Type EventType `json:"type"`
+	Time time.Time `json:"time"`
+	// SourceURL is the GitLab project, or the namespace/project or CSV file
+	// of an export.
+	SourceURL string `json:"source_url"`
+	Org       string `json:"org,omitempty"`
+	// Repository is the name of the target repository.
+	Repository      string `json:"repository,omitempty"`
+	MigrationID     string `json:"migration_id,omitempty"`
+	Storage         string `json:"storage,omitempty"`
+	ArchiveSize     int64  `json:"archive_size,omitempty"`
+	State           string `json:"state,omitempty"`
+	Warnings        int    `json:"warnings,omitempty"`
+	MigrationLogURL string `json:"migration_log_url,omitempty"`
+	// Error is the redacted reason of a failure.
+	Error string `json:"error,omitempty"`
+}
+
+// target returns org/repository, or the repository alone when the
+// organization is not known.
+func (e Event) target() string {
+	if e.Org == "" {
+		return e.Repository
+	}
+	return e.Org + "/" + e.Repository
+}
+
+// Summary is a one-line description of the event for chat messages.
+func (e Event) Summary() string {
+	switch e.Type {
+	case EventExportStarted:
+		return fmt.Sprintf("Export of %s started", e.SourceURL)
+	case EventExportCompleted:
+		return fmt.Sprintf("Export of %s completed", e.SourceURL)
+	case EventExportFailed:
+		return fmt.Sprintf("Export of %s failed: %s", e.SourceURL, e.Error)
+	case EventUploadCompleted:
+		return fmt.Sprintf("Archive of %s uploaded to %s storage (%d bytes)", e.SourceURL, e.Storage, e.ArchiveSize)
+	case EventMigrationQueued:
+		return fmt.Sprintf("Migration of %s to %s queued", e.SourceURL, e.target())
+	case EventMigrationSucceeded:
+		return fmt.Sprintf("Migration of %s to %s succeeded", e.SourceURL, e.target())
+	case EventMigrationFailed:
+		return fmt.Sprintf("Migration of %s to %s failed: %s", e.SourceURL, e.target(), e.Error)
+	case EventMigrationWarnings:
+		return fmt.Sprintf("Migration of %s to %s completed with %d warnings", e.SourceURL, e.target(), e.Warnings)
+	}
+	return fmt.Sprintf("%s: %s", e.Type, e.SourceURL)
+}
+
+// Sink receives the events published on a Bus.
+type Sink interface {
+	Send(ctx context.Context, event Event) error
+}
+
+// SinkFunc is a Sink that calls a function.
+type SinkFunc func(ctx context.Context, event Event) error
+
+func (f SinkFunc) Send(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+// SendTimeout bounds the delivery of an event to one sink.
+var SendTimeout = 30 * time.Second
+
+// Bus delivers lifecycle events to the sinks subscribed to them. A nil Bus
+// discards every event.
+type Bus struct {
+	// Logger receives the failures of sinks. When it is not set, events
+	// published by a Migrator are logged to the Logger of the Migrator, and
+	// others to logger.Logger.
+	Logger *zap.Logger
+
+	mu            sync.Mutex
+	subscriptions []subscription
+}
+
+type subscription struct {
+	sink Sink
+	// types are the events the sink receives, every event when empty.
+	types map[EventType]bool
+}
+
+// Subscribe sends the events of the given types, or every event when no type
+// is given, to sink.
+func (b *Bus) Subscribe(sink Sink, types ...EventType) {
+	s := subscription{sink: sink}
+	if len(types) > 0 {
+		s.types = make(map[EventType]bool, len(types))
+		for _, eventType := range types {
+			s.types[eventType] = true
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscriptions = append(b.subscriptions, s)
+}
+
+// Publish sends event to its subscribers, one after the other. Events are
+// delivered even when ctx is cancelled, so an interrupted migration is still
+// reported; a sink that fails is logged and does not fail the migration.
+func (b *Bus) Publish(ctx context.Context, event Event) {
+	b.publish(ctx, event, nil)
+}
+
+// publish is Publish logging to log when the Bus has no Logger.
+func (b *Bus) publish(ctx context.Context, event Event, log *zap.Logger) {
+	if b == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	if b.Logger != nil {
+		log = b.Logger
+	}
+	if log == nil {
+		log = ghlog.Logger
+	}
+
+	b.mu.Lock()
+	subscriptions := append([]subscription(nil), b.subscriptions...)
+	b.mu.Unlock()
+
+	for _, s := range subscriptions {
+		if s.types != nil && !s.types[event.Type] {
+			continue
+		}
+		sendCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), SendTimeout)
+		err := s.sink.Send(sendCtx, event)
+		cancel()
+		if err != nil {
+			log.Warn("failed to send notification",
+				zap.String("event", string(event.Type)),
+				zap.String("sink", fmt.Sprintf("%T", s.sink)),
+				zap.Error(err))
+		}
+	}
+}
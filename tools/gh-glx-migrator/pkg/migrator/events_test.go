@@ -0,0 +1,254 @@
+package migrator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// recorder is a Sink that records the events it receives.
+type recorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (r *recorder) Send(_ context.Context, event Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
+
+// types returns the types of the recorded events, in order.
+func (r *recorder) types() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	types := make([]string, len(r.events))
+	for i, event := range r.events {
+		types[i] = string(event.Type)
+	}
+	return types
+}
+
+// request is a request received by a webhook server.
+type request struct {
+	header http.Header
+	body   []byte
+}
+
+// newWebhookServer starts a server that records the requests it receives and
+// responds with status.
+func newWebhookServer(t *testing.T, status int) (*httptest.Server, func() []request) {
+	t.Helper()
+	var mu sync.Mutex
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, request{header: r.Header.Clone(), body: body})
+		mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []request {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]request(nil), requests...)
+	}
+}
+
+func TestBusSubscribe(t *testing.T) {
+	all, failures := &recorder{}, &recorder{}
+	bus := &Bus{}
+	bus.Subscribe(all)
+	bus.Subscribe(failures, EventMigrationFailed)
+	bus.Subscribe(SinkFunc(func(context.Context, Event) error { return errors.New("unreachable") }))
+
+	// Events are delivered even after the migration was interrupted.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	bus.Publish(ctx, Event{Type: EventMigrationQueued})
+	bus.Publish(ctx, Event{Type: EventMigrationFailed})
+
+	if got := strings.Join(all.types(), ","); got != "migration.queued,migration.failed" {
+		t.Errorf("unfiltered sink received %s", got)
+	}
+	if got := strings.Join(failures.types(), ","); got != "migration.failed" {
+		t.Errorf("filtered sink received %s, want migration.failed", got)
+	}
+	if all.events[0].Time.IsZero() {
+		t.Error("published event has no time")
+	}
+
+	var nilBus *Bus
+	nilBus.Publish(ctx, Event{Type: EventMigrationQueued})
+}
+
+func TestBusLogsToMigratorLogger(t *testing.T) {
+	m, gh, archivePath := newTestMigrator(t, &GitHubStore{})
+	core, logs := observer.New(zapcore.WarnLevel)
+	m.Logger = zap.New(core)
+	m.Events = &Bus{}
+	m.Events.Subscribe(SinkFunc(func(context.Context, Event) error { return errors.New("unreachable") }), EventMigrationSucceeded)
+
+	if _, err := m.ImportArchive(context.Background(), ImportArchiveOptions{
+		Org:         gh.Org.Login,
+		SourceURL:   "https://gitlab.example.com/group/api",
+		ArchivePath: archivePath,
+	}); err != nil {
+		t.Fatalf("ImportArchive() error = %v", err)
+	}
+	if failed := logs.FilterMessage("failed to send notification").Len(); failed != 1 {
+		t.Errorf("logged %d sink failures to the Logger of the Migrator, want 1", failed)
+	}
+
+	// A Logger of the Bus takes precedence.
+	busCore, busLogs := observer.New(zapcore.WarnLevel)
+	m.Events.Logger = zap.New(busCore)
+	m.publish(context.Background(), Event{Type: EventMigrationSucceeded})
+	if busLogs.Len() != 1 || logs.FilterMessage("failed to send notification").Len() != 1 {
+		t.Errorf("logged %d sink failures to the Logger of the Bus, want 1", busLogs.Len())
+	}
+}
+
+func TestParseEventType(t *testing.T) {
+	if eventType, err := ParseEventType("migration.warnings"); err != nil || eventType != EventMigrationWarnings {
+		t.Errorf("ParseEventType(migration.warnings) = %q, %v", eventType, err)
+	}
+	if _, err := ParseEventType("migration.started"); err == nil {
+		t.Error("ParseEventType(migration.started) returned no error for an unknown event")
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	server, requests := newWebhookServer(t, http.StatusNoContent)
+	sink := &WebhookSink{URL: server.URL, Secret: "s3cret"}
+	event := Event{Type: EventMigrationSucceeded, SourceURL: "https://gitlab.example.com/group/api", Org: "my-org", Repository: "api", MigrationID: "RM_1"}
+
+	if err := sink.Send(context.Background(), event); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	received := requests()
+	if len(received) != 1 {
+		t.Fatalf("webhook received %d requests, want 1", len(received))
+	}
+	got := received[0]
+	if sig := got.header.Get(SignatureHeader); sig != Sign("s3cret", got.body) || !strings.HasPrefix(sig, "sha256=") {
+		t.Errorf("%s = %q, want the HMAC-SHA256 of the body", SignatureHeader, sig)
+	}
+	if got.header.Get(EventHeader) != "migration.succeeded" {
+		t.Errorf("%s = %q", EventHeader, got.header.Get(EventHeader))
+	}
+	var decoded Event
+	if err := json.Unmarshal(got.body, &decoded); err != nil {
+		t.Fatalf("body is not an event: %v", err)
+	}
+	if decoded.Type != event.Type || decoded.MigrationID != "RM_1" || decoded.Repository != "api" {
+		t.Errorf("posted event = %+v", decoded)
+	}
+
+	unsigned := &WebhookSink{URL: server.URL}
+	if err := unsigned.Send(context.Background(), event); err != nil {
+		t.Fatalf("Send() without secret error = %v", err)
+	}
+	if sig := requests()[1].header.Get(SignatureHeader); sig != "" {
+		t.Errorf("%s = %q without a secret, want none", SignatureHeader, sig)
+	}
+}
+
+func TestWebhookSinkErrorOmitsURL(t *testing.T) {
+	server, _ := newWebhookServer(t, http.StatusForbidden)
+	sink := &SlackSink{URL: server.URL + "/services/T000/B000/token"}
+
+	err := sink.Send(context.Background(), Event{Type: EventMigrationQueued})
+	if err == nil {
+		t.Fatal("Send() returned no error for a 403 response")
+	}
+	if strings.Contains(err.Error(), "token") {
+		t.Errorf("Send() error = %q, want the webhook URL left out", err)
+	}
+}
+
+func TestChatSinks(t *testing.T) {
+	server, requests := newWebhookServer(t, http.StatusOK)
+	event := Event{
+		Type:            EventMigrationWarnings,
+		SourceURL:       "https://gitlab.example.com/group/api",
+		Org:             "my-org",
+		Repository:      "api",
+		Warnings:        3,
+		MigrationLogURL: "https://github.com/my-org/api/issues/1",
+	}
+
+	if err := (&SlackSink{URL: server.URL}).Send(context.Background(), event); err != nil {
+		t.Fatalf("SlackSink.Send() error = %v", err)
+	}
+	if err := (&TeamsSink{URL: server.URL}).Send(context.Background(), event); err != nil {
+		t.Fatalf("TeamsSink.Send() error = %v", err)
+	}
+	received := requests()
+
+	var slack struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(received[0].body, &slack); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(slack.Text, "my-org/api completed with 3 warnings") || !strings.Contains(slack.Text, event.MigrationLogURL) {
+		t.Errorf("Slack text = %q", slack.Text)
+	}
+
+	var teams struct {
+		Type       string `json:"@type"`
+		Summary    string `json:"summary"`
+		ThemeColor string `json:"themeColor"`
+		Sections   []struct {
+			Facts []teamsFact `json:"facts"`
+		} `json:"sections"`
+	}
+	if err := json.Unmarshal(received[1].body, &teams); err != nil {
+		t.Fatal(err)
+	}
+	if teams.Type != "MessageCard" || teams.Summary != event.Summary() || teams.ThemeColor != "BF8700" {
+		t.Errorf("Teams card = %+v", teams)
+	}
+	if len(teams.Sections) != 1 || len(teams.Sections[0].Facts) != 4 {
+		t.Errorf("Teams facts = %+v, want source, repository, warnings and log", teams.Sections)
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	sink := &FileSink{Path: path}
+	for _, eventType := range []EventType{EventExportStarted, EventExportCompleted} {
+		if err := sink.Send(context.Background(), Event{Type: eventType, SourceURL: "group/api"}); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("event file has %d lines, want 2", len(lines))
+	}
+	var last Event
+	if err := json.Unmarshal([]byte(lines[1]), &last); err != nil || last.Type != EventExportCompleted {
+		t.Errorf("last line = %s (%v)", lines[1], err)
+	}
+}
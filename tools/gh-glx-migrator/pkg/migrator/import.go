@@ -0,0 +1,288 @@
+package migrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ps-resources/gh-glx-migrator/internal/failure"
+	"github.com/ps-resources/gh-glx-migrator/internal/github"
+	"github.com/ps-resources/gh-glx-migrator/internal/naming"
+	"github.com/ps-resources/gh-glx-migrator/internal/telemetry"
+
+	"go.uber.org/zap"
+)
+
+// ImportArchiveOptions are the inputs of Migrator.ImportArchive. Org,
+// SourceURL and ArchivePath are required.
+type ImportArchiveOptions struct {
+	// Org is the GitHub organization to import to.
+	Org string
+	// SourceURL is the URL of the GitLab project the archive was exported
+	// from.
+	SourceURL string
+	// ArchivePath is the path of the archive exported with gl-exporter.
+	ArchivePath string
+	// BlobName is the name of the uploaded archive, the base name of
+	// ArchivePath by default.
+	BlobName string
+	// RepositoryName is the name of the target repository. By default it is
+	// derived from SourceURL with NameStrategy.
+	RepositoryName string
+	// Visibility is public, private or internal, private by default.
+	Visibility string
+	// NameStrategy is last-segment, flatten-path or prefix-group,
+	// last-segment by default.
+	NameStrategy string
+	// OnCollision is what happens when the target repository already
+	// exists: fail, suffix or skip, fail by default.
+	OnCollision string
+	// URLDuration is how long GitHub can download the uploaded archive, 20
+	// minutes by default.
+	URLDuration time.Duration
+	// UploadTimeout bounds the upload, 30 minutes by default.
+	UploadTimeout time.Duration
+	// MigrationTimeout bounds the wait for the migration, 90 minutes by
+	// default.
+	MigrationTimeout time.Duration
+	// FailOnWarnings makes a migration that succeeded with warnings return
+	// an error of kind KindWarnings, see IsWarnings.
+	FailOnWarnings bool
+}
+
+func (o ImportArchiveOptions) withDefaults() ImportArchiveOptions {
+	if o.BlobName == "" {
+		o.BlobName = filepath.Base(o.ArchivePath)
+	}
+	if o.Visibility == "" {
+		o.Visibility = "private"
+	}
+	if o.NameStrategy == "" {
+		o.NameStrategy = string(naming.DefaultPolicy.Strategy)
+	}
+	if o.OnCollision == "" {
+		o.OnCollision = string(naming.DefaultPolicy.Collision)
+	}
+	if o.URLDuration == 0 {
+		o.URLDuration = 20 * time.Minute
+	}
+	if o.UploadTimeout == 0 {
+		o.UploadTimeout = 30 * time.Minute
+	}
+	if o.MigrationTimeout == 0 {
+		o.MigrationTimeout = 90 * time.Minute
+	}
+	return o
+}
+
+func (o ImportArchiveOptions) validate() error {
+	var missing []string
+	if o.Org == "" {
+		missing = append(missing, "Org")
+	}
+	if o.SourceURL == "" {
+		missing = append(missing, "SourceURL")
+	}
+	if o.ArchivePath == "" {
+		missing = append(missing, "ArchivePath")
+	}
+	if len(missing) > 0 {
+		return failure.Validation(fmt.Errorf("missing import options: %s", strings.Join(missing, ", ")))
+	}
+	return nil
+}
+
+// ResolveRepositoryName returns the name of the repository opts imports to,
+// and whether the import is skipped because that repository already exists.
+// Only the suffix collision mode lists the repositories of the organization;
+// otherwise the name is looked up on its own. An explicit RepositoryName is
+// never given a suffix, so it fails when the repository exists, unless
+// OnCollision is skip.
+func (m *Migrator) ResolveRepositoryName(ctx context.Context, opts ImportArchiveOptions) (string, bool, error) {
+	opts = opts.withDefaults()
+	if m.GitHub.Credential == nil {
+		return "", false, failure.Validation(errors.New("no GitHub credential"))
+	}
+	return m.resolveName(ctx, opts.Org, opts.SourceURL, opts.RepositoryName, opts.NameStrategy, opts.OnCollision)
+}
+
+// ImportArchive uploads an archive to the Store, migrates it to a new
+// repository and deletes the upload once GitHub is done with it.
+//
+// The Result is returned even when the import fails, with the state and
+// phases reached, so it can be reported.
+func (m *Migrator) ImportArchive(ctx context.Context, opts ImportArchiveOptions) (result Result, err error) {
+	opts = opts.withDefaults()
+	log := m.logger()
+
+	result = Result{
+		SourceURL:  opts.SourceURL,
+		Repository: opts.RepositoryName,
+		StartedAt:  time.Now(),
+	}
+	if m.Store != nil {
+		result.Storage = m.Store.Name()
+	}
+	if info, err := os.Stat(opts.ArchivePath); err == nil {
+		result.ArchiveSize = info.Size()
+	}
+	defer func() {
+		result.finish(err)
+		if err != nil && failure.KindOf(err) != failure.KindWarnings {
+			event := result.event(EventMigrationFailed, opts.Org)
+			event.Error = result.FailureReason
+			m.publish(ctx, event)
+		}
+	}()
+
+	if err := opts.validate(); err != nil {
+		return result, err
+	}
+	if m.Store == nil {
+		return result, failure.Validation(errors.New("no store to upload the archive to"))
+	}
+
+	// Resolve the target name before uploading anything, so a collision fails
+	// the import early instead of when GitHub creates the repository.
+	name, skipped, err := m.ResolveRepositoryName(ctx, opts)
+	if err != nil {
+		return result, err
+	}
+	result.Repository = name
+	if skipped {
+		result.State = StateSkipped
+		log.Warn("Skipping import, target repository already exists",
+			zap.String("source", opts.SourceURL),
+			zap.String("repository", name))
+		return result, nil
+	}
+
+	ctx, span := telemetry.StartRepository(ctx, opts.SourceURL, name)
+	defer span.End()
+
+	target := m.GitHub.target()
+	org, err := target.Organization(ctx, opts.Org)
+	if err != nil {
+		return result, fmt.Errorf("failed to fetch organization information: %w", err)
+	}
+	orgID := org.Organization.ID
+	log.Info("orgId: " + orgID)
+
+	archive := Archive{
+		Path:                   opts.ArchivePath,
+		BlobName:               opts.BlobName,
+		URLDuration:            opts.URLDuration,
+		OrganizationDatabaseID: strconv.Itoa(org.Organization.DatabaseID),
+		target:                 target,
+		logger:                 log,
+	}
+
+	// The upload is bounded on its own; the wait for the migration has a
+	// longer timeout of its own.
+	uploadCtx, cancelUpload := context.WithTimeout(ctx, opts.UploadTimeout)
+	defer cancelUpload()
+	archiveURL, err := m.upload(uploadCtx, &result, archive)
+	if err != nil {
+		return result, err
+	}
+	m.publish(ctx, result.event(EventUploadCompleted, opts.Org))
+
+	// The uploaded archive is deleted when the import is interrupted before
+	// the migration starts, since no later step will. Once it started,
+	// GitHub owns the archive until the migration finishes.
+	defer func() {
+		if result.MigrationID != "" || ctx.Err() == nil {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Minute)
+		defer cancel()
+		if err := m.Store.Delete(ctx, archive); err != nil {
+			log.Warn("failed to delete the archive of the interrupted import", zap.String("blob", archive.BlobName), zap.Error(err))
+			return
+		}
+		log.Info("Deleted the archive of the interrupted import", zap.String("blob", archive.BlobName))
+	}()
+
+	migrationSource, err := target.CreateMigrationSource(ctx, github.MigrationSourceInput{
+		Name:    "GitLab Archive Migration",
+		OwnerID: orgID,
+		Type:    "GL_EXPORTER_ARCHIVE",
+		URL:     m.GitLab.host(),
+	})
+	if err != nil {
+		return result, fmt.Errorf("failed to create migration source: %w", err)
+	}
+	migrationSourceID := migrationSource.CreateMigrationSource.MigrationSource.ID
+	log.Info("Migration source ID: " + migrationSourceID)
+
+	// A migration that succeeded with warnings is still cleaned up before
+	// its error is returned.
+	warningsErr := m.migrate(ctx, &result, opts.Org, github.MigrationInput{
+		SourceID:             migrationSourceID,
+		OwnerID:              orgID,
+		SourceRepositoryURL:  opts.SourceURL,
+		RepositoryName:       name,
+		ContinueOnError:      true,
+		SkipReleases:         false,
+		GitArchiveURL:        archiveURL,
+		MetadataArchiveURL:   archiveURL,
+		AccessToken:          "not-used",
+		TargetRepoVisibility: opts.Visibility,
+		LockSource:           false,
+	}, opts.MigrationTimeout, opts.FailOnWarnings)
+	if warningsErr != nil && failure.KindOf(warningsErr) != failure.KindWarnings {
+		return result, warningsErr
+	}
+
+	// GitHub deletes archives in its own storage.
+	if _, ok := m.Store.(*GitHubStore); !ok {
+		_, endCleanup := result.startPhase(ctx, telemetry.PhaseCleanup)
+		err := m.Store.Delete(ctx, archive)
+		endCleanup(err)
+		if err != nil {
+			log.Error("failed to delete the archive", zap.Error(err))
+			return result, failure.Storage(err)
+		}
+	}
+
+	return result, warningsErr
+}
+
+// upload uploads the archive to the Store as the upload phase of the
+// migration and records the size and throughput of the upload.
+func (m *Migrator) upload(ctx context.Context, result *Result, archive Archive) (string, error) {
+	ctx, end := result.startPhase(ctx, telemetry.PhaseUpload)
+	start := time.Now()
+
+	url, err := m.Store.Upload(ctx, archive)
+	end(err)
+	if err != nil {
+		m.logger().Error("failed to upload the archive", zap.String("storage", result.Storage), zap.Error(err))
+		return "", failure.Storage(err)
+	}
+
+	if info, err := os.Stat(archive.Path); err == nil {
+		telemetry.RecordUpload(result.Storage, info.Size(), time.Since(start))
+	}
+	return url, nil
+}
+
+// startPhase starts a traced phase of the migration whose duration is also
+// recorded in the result.
+func (r *Result) startPhase(ctx context.Context, phase string) (context.Context, func(err error)) {
+	ctx, end := telemetry.StartPhase(ctx, phase)
+	start := time.Now()
+	return ctx, func(err error) {
+		end(err)
+		p := Phase{Name: phase, Duration: time.Since(start)}
+		if err != nil {
+			p.Error = err.Error()
+		}
+		r.Phases = append(r.Phases, p)
+	}
+}
@@ -0,0 +1,252 @@
+package migrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ps-resources/gh-glx-migrator/internal/failure"
+	"github.com/ps-resources/gh-glx-migrator/internal/github"
+	"github.com/ps-resources/gh-glx-migrator/internal/naming"
+	"github.com/ps-resources/gh-glx-migrator/internal/plan"
+	"github.com/ps-resources/gh-glx-migrator/internal/telemetry"
+	ghlog "github.com/ps-resources/gh-glx-migrator/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// MigrateOptions are the inputs of Migrator.Migrate. MigrationSourceID,
+// OwnerID, SourceURL and ArchiveURL are required.
+type MigrateOptions struct {
+	// MigrationSourceID is the ID of a GL_EXPORTER_ARCHIVE migration source.
+	MigrationSourceID string
+	// OwnerID is the node ID of the GitHub organization to migrate to.
+	OwnerID string
+	// SourceURL is the URL of the GitLab project the archive was exported
+	// from.
+	SourceURL string
+	// ArchiveURL is the URL GitHub downloads the uploaded archive from.
+	ArchiveURL string
+	// RepositoryName is the name of the target repository. By default it is
+	// derived from SourceURL with NameStrategy.
+	RepositoryName string
+	// Visibility is public, private or internal, private by default.
+	Visibility string
+	// NameStrategy and OnCollision are as in ImportArchiveOptions.
+	NameStrategy string
+	OnCollision  string
+	// MigrationTimeout bounds the wait for the migration, 60 minutes by
+	// default.
+	MigrationTimeout time.Duration
+	// FailOnWarnings makes a migration that succeeded with warnings return
+	// an error of kind KindWarnings, see IsWarnings.
+	FailOnWarnings bool
+}
+
+func (o MigrateOptions) withDefaults() MigrateOptions {
+	if o.Visibility == "" {
+		o.Visibility = "private"
+	}
+	if o.NameStrategy == "" {
+		o.NameStrategy = string(naming.DefaultPolicy.Strategy)
+	}
+	if o.OnCollision == "" {
+		o.OnCollision = string(naming.DefaultPolicy.Collision)
+	}
+	if o.MigrationTimeout == 0 {
+		o.MigrationTimeout = 60 * time.Minute
+	}
+	return o
+}
+
+func (o MigrateOptions) validate() error {
+	var missing []string
+	if o.MigrationSourceID == "" {
+		missing = append(missing, "MigrationSourceID")
+	}
+	if o.OwnerID == "" {
+		missing = append(missing, "OwnerID")
+	}
+	if o.SourceURL == "" {
+		missing = append(missing, "SourceURL")
+	}
+	if o.ArchiveURL == "" {
+		missing = append(missing, "ArchiveURL")
+	}
+	if len(missing) > 0 {
+		return failure.Validation(fmt.Errorf("missing migrate options: %s", strings.Join(missing, ", ")))
+	}
+	return nil
+}
+
+// ResolveMigrationRepositoryName returns the login of the organization opts
+// migrates to, the name of the repository it creates there and whether the
+// migration is skipped because that repository already exists. Names are
+// resolved as by ResolveRepositoryName.
+func (m *Migrator) ResolveMigrationRepositoryName(ctx context.Context, opts MigrateOptions) (org, name string, skipped bool, err error) {
+	opts = opts.withDefaults()
+	if m.GitHub.Credential == nil {
+		return "", "", false, failure.Validation(errors.New("no GitHub credential"))
+	}
+	org, err = m.GitHub.target().OrganizationLogin(ctx, opts.OwnerID)
+	if err != nil {
+		return "", "", false, err
+	}
+	name, skipped, err = m.resolveName(ctx, org, opts.SourceURL, opts.RepositoryName, opts.NameStrategy, opts.OnCollision)
+	return org, name, skipped, err
+}
+
+// resolveName returns the name of the repository the project at sourceURL
+// migrates to in org, and whether its migration is skipped.
+func (m *Migrator) resolveName(ctx context.Context, org, sourceURL, repositoryName, strategy, collision string) (string, bool, error) {
+	policy, err := naming.NewPolicy(strings.ToLower(strategy), strings.ToLower(collision))
+	if err != nil {
+		return "", false, failure.Validation(err)
+	}
+
+	mappings, err := policy.ResolveIn(ctx, []naming.Source{{
+		Path:   plan.ProjectPath(sourceURL),
+		Target: repositoryName,
+	}}, github.OrgRepositories{Target: m.GitHub.target(), Org: org})
+	if err != nil {
+		return "", false, err
+	}
+	mapping := mappings[0]
+	if mapping.Conflict != "" {
+		return "", false, fmt.Errorf("cannot migrate %s: %s", sourceURL, mapping.Conflict)
+	}
+	if repositoryName == "" {
+		m.logger().Info("Derived the repository name from the source", zap.String("repository", mapping.Target))
+	}
+	return mapping.Target, mapping.Skipped, nil
+}
+
+// Migrate migrates an archive that is already uploaded to a new repository
+// and waits for the migration to finish.
+//
+// Like ImportArchive, the Result is returned even when the migration fails.
+func (m *Migrator) Migrate(ctx context.Context, opts MigrateOptions) (result Result, err error) {
+	opts = opts.withDefaults()
+	log := m.logger()
+
+	var org string
+	result = Result{
+		SourceURL:  opts.SourceURL,
+		Repository: opts.RepositoryName,
+		StartedAt:  time.Now(),
+	}
+	defer func() {
+		result.finish(err)
+		if err != nil && failure.KindOf(err) != failure.KindWarnings {
+			event := result.event(EventMigrationFailed, org)
+			event.Error = result.FailureReason
+			m.publish(ctx, event)
+		}
+	}()
+
+	if err := opts.validate(); err != nil {
+		return result, err
+	}
+
+	org, name, skipped, err := m.ResolveMigrationRepositoryName(ctx, opts)
+	if err != nil {
+		return result, err
+	}
+	result.Repository = name
+	if skipped {
+		result.State = StateSkipped
+		log.Warn("Skipping migration, target repository already exists",
+			zap.String("source", opts.SourceURL),
+			zap.String("repository", name))
+		return result, nil
+	}
+
+	ctx, span := telemetry.StartRepository(ctx, opts.SourceURL, name)
+	defer span.End()
+
+	return result, m.migrate(ctx, &result, org, github.MigrationInput{
+		SourceID:             opts.MigrationSourceID,
+		OwnerID:              opts.OwnerID,
+		SourceRepositoryURL:  opts.SourceURL,
+		RepositoryName:       name,
+		ContinueOnError:      true,
+		SkipReleases:         false,
+		GitArchiveURL:        opts.ArchiveURL,
+		MetadataArchiveURL:   opts.ArchiveURL,
+		AccessToken:          "not-used",
+		TargetRepoVisibility: opts.Visibility,
+		LockSource:           false,
+	}, opts.MigrationTimeout, opts.FailOnWarnings)
+}
+
+// migrate starts the migration of input to org as the import phase of result
+// and waits up to timeout for it to finish. The githubPat of input is the
+// MigrationToken of the Migrator. Once the migration is started,
+// result.MigrationID is set. A migration that succeeds with warnings returns
+// an error of kind KindWarnings when failOnWarnings is set.
+func (m *Migrator) migrate(ctx context.Context, result *Result, org string, input github.MigrationInput, timeout time.Duration, failOnWarnings bool) error {
+	log := m.logger()
+	target := m.GitHub.target()
+
+	input.GithubPat = m.GitHub.MigrationToken
+	if input.GithubPat == "" {
+		token, err := m.GitHub.Credential.Token(ctx)
+		if err != nil {
+			return failure.Auth(err)
+		}
+		input.GithubPat = token
+	}
+
+	_, endImport := result.startPhase(ctx, telemetry.PhaseImport)
+	response, err := target.StartMigration(ctx, input)
+	if err != nil {
+		endImport(err)
+		log.Error("Starting Migration failed",
+			zap.String("repository", input.RepositoryName),
+			zap.Error(err))
+		return failure.MigrationFailed(fmt.Errorf("failed to start migration of %s: %w", input.RepositoryName, err))
+	}
+
+	result.MigrationID = response.StartRepositoryMigration.RepositoryMigration.ID
+	log.Info("Migration started",
+		zap.String("migration_id", result.MigrationID),
+		zap.String("repository", input.RepositoryName))
+	m.publish(ctx, result.event(EventMigrationQueued, org))
+
+	status, err := target.VerifyMigrationStatus(ctx, result.MigrationID, timeout)
+	endImport(err)
+	if status != nil {
+		result.State = status.Node.State
+		// The failure reason GitHub reports can quote the archive URL and its
+		// credentials.
+		result.FailureReason = ghlog.Redact(status.Node.FailureReason)
+		result.Warnings = status.Node.WarningsCount
+		result.MigrationLogURL = status.Node.MigrationLogURL
+	}
+	if err != nil {
+		log.Error("Migration verification failed",
+			zap.String("migration_id", result.MigrationID),
+			zap.Error(err))
+		return err
+	}
+
+	m.publish(ctx, result.event(EventMigrationSucceeded, org))
+	log.Info("Migration completed successfully",
+		zap.String("repository", status.Node.RepositoryName),
+		zap.String("state", status.Node.State))
+
+	if status.Node.WarningsCount == 0 {
+		return nil
+	}
+	m.publish(ctx, result.event(EventMigrationWarnings, org))
+	log.Warn("Migration completed with warnings",
+		zap.String("repository", status.Node.RepositoryName),
+		zap.Int("warnings", status.Node.WarningsCount),
+		zap.String("migration_log_url", status.Node.MigrationLogURL))
+	if failOnWarnings {
+		return failure.Warnings(fmt.Errorf("migration of %s succeeded with %d warnings, see %s", status.Node.RepositoryName, status.Node.WarningsCount, status.Node.MigrationLogURL))
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package migrator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ps-resources/gh-glx-migrator/internal/github"
+	"github.com/ps-resources/gh-glx-migrator/internal/testutil"
+)
+
+// migrateOptions returns the options of a migration of an archive already
+// uploaded to the organization of gh.
+func migrateOptions(t *testing.T, m *Migrator, gh *testutil.GitHub) MigrateOptions {
+	t.Helper()
+	source, err := m.GitHub.target().CreateMigrationSource(context.Background(), github.MigrationSourceInput{
+		Name:    "GitLab Archive Migration",
+		OwnerID: gh.Org.ID,
+		Type:    "GL_EXPORTER_ARCHIVE",
+		URL:     "https://gitlab.example.com",
+	})
+	if err != nil {
+		t.Fatalf("CreateMigrationSource() error = %v", err)
+	}
+	return MigrateOptions{
+		MigrationSourceID: source.CreateMigrationSource.MigrationSource.ID,
+		OwnerID:           gh.Org.ID,
+		SourceURL:         "https://gitlab.example.com/group/api",
+		ArchiveURL:        "https://storage.example.com/archive.tar.gz",
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	m, gh, _ := newTestMigrator(t, nil)
+	events := &recorder{}
+	m.Events = &Bus{}
+	m.Events.Subscribe(events)
+
+	result, err := m.Migrate(context.Background(), migrateOptions(t, m, gh))
+	if err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	migrations := gh.Migrations()
+	if len(migrations) != 1 {
+		t.Fatalf("started %d migrations, want 1", len(migrations))
+	}
+	migration := migrations[0]
+	if migration.RepositoryName != "api" || migration.GitArchiveURL != "https://storage.example.com/archive.tar.gz" || migration.GithubPat != gh.Token {
+		t.Errorf("started migration %+v", migration)
+	}
+	if result.State != github.MigrationStateSucceeded || result.Repository != "api" || result.MigrationID != migration.ID || len(result.Phases) != 1 {
+		t.Errorf("result = %+v", result)
+	}
+	if got := strings.Join(events.types(), ","); got != "migration.queued,migration.succeeded" {
+		t.Fatalf("published events %s", got)
+	}
+	if last := events.events[1]; last.Org != gh.Org.Login || last.Repository != "api" || last.MigrationID != migration.ID {
+		t.Errorf("migration.succeeded event = %+v", last)
+	}
+}
+
+func TestMigrateFailures(t *testing.T) {
+	m, gh, _ := newTestMigrator(t, nil)
+	events := &recorder{}
+	m.Events = &Bus{}
+	m.Events.Subscribe(events, EventMigrationFailed)
+	opts := migrateOptions(t, m, gh)
+
+	gh.Repositories = []string{"api"}
+	skip := opts
+	skip.OnCollision = "skip"
+	if result, err := m.Migrate(context.Background(), skip); err != nil || result.State != StateSkipped {
+		t.Errorf("Migrate() to an existing repository = %+v, %v, want it skipped", result, err)
+	}
+	if migrations := gh.Migrations(); len(migrations) != 0 {
+		t.Fatalf("started %d migrations, want none for an existing repository", len(migrations))
+	}
+
+	gh.States = []string{github.MigrationStateFailed}
+	gh.FailureReason = "failed to download https://storage.example.com/archive.tar.gz?sv=2024&sig=c2VjcmV0"
+	suffix := opts
+	suffix.OnCollision = "suffix"
+	result, err := m.Migrate(context.Background(), suffix)
+	if kind := KindOf(err); kind != KindMigrationFailed {
+		t.Errorf("Migrate() of a failing migration error = %v (%s), want a failed migration", err, kind)
+	}
+	if result.Repository != "api-2" || result.State != github.MigrationStateFailed {
+		t.Errorf("result of a failing migration = %+v", result)
+	}
+	if strings.Contains(result.FailureReason, "c2VjcmV0") {
+		t.Errorf("result failure reason %q is not redacted", result.FailureReason)
+	}
+
+	if got := strings.Join(events.types(), ","); got != "migration.failed" {
+		t.Fatalf("published events %s, want the failed migration", got)
+	}
+	if failed := events.events[0]; failed.Error != result.FailureReason || failed.Org != gh.Org.Login {
+		t.Errorf("migration.failed event = %+v, want the redacted failure reason %q", failed, result.FailureReason)
+	}
+}
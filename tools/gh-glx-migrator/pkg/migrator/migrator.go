@@ -0,0 +1,187 @@
+// Package migrator runs GitLab to GitHub migrations from Go code.
+//
+// The gh-glx commands are thin wrappers around this package: they build a
+// Migrator from flags, environment variables and the configuration file. A
+// program that embeds migrations builds the Migrator itself, so neither the
+// gh-glx settings nor the configuration file are read:
+//
+//	m := migrator.Migrator{
+//		GitHub: migrator.GitHub{Credential: migrator.StaticToken(pat)},
+//		GitLab: migrator.GitLab{Host: "https://gitlab.example.com"},
+//		Store:  &migrator.S3Store{Bucket: "archives", Client: s3Client},
+//	}
+//	result, err := m.ImportArchive(ctx, migrator.ImportArchiveOptions{
+//		Org:         "my-org",
+//		SourceURL:   "https://gitlab.example.com/group/project",
+//		ArchivePath: "migration_archive.tar.gz",
+//	})
+//
+// The one exception is an S3Store without a Client, which falls back to the
+// AWS settings of the environment and the active gh-glx profile, such as
+// AWS_REGION, and the default credential chain of the AWS SDK.
+//
+// The Migrator and its stores log to Logger. The packages they call log
+// through logger.Logger of github.com/ps-resources/gh-glx-migrator/pkg/logger,
+// which discards entries until it is configured.
+package migrator
+
+import (
+	"context"
+	"time"
+
+	"github.com/ps-resources/gh-glx-migrator/internal/clients"
+	"github.com/ps-resources/gh-glx-migrator/internal/failure"
+	"github.com/ps-resources/gh-glx-migrator/internal/github"
+	"github.com/ps-resources/gh-glx-migrator/internal/report"
+	ghlog "github.com/ps-resources/gh-glx-migrator/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// States of a Result besides the migration states GitHub reports, such as
+// SUCCEEDED and FAILED.
+const (
+	// StateError is the state of an import that failed before GitHub
+	// reported a state, for example because the upload failed.
+	StateError = report.StateError
+	// StateSkipped is the state of an import that was skipped because the
+	// target repository already exists.
+	StateSkipped = report.StateSkipped
+)
+
+// Credential supplies the token requests to GitHub are authenticated with.
+// Token is called for every request, so credentials that expire can be
+// refreshed.
+type Credential interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// StaticToken returns a Credential for a personal access token.
+func StaticToken(token string) Credential {
+	return clients.PersonalAccessToken(token)
+}
+
+// GitHub is the GitHub instance repositories are migrated to.
+type GitHub struct {
+	// APIEndpoint is the host of the API, api.github.com by default.
+	APIEndpoint string
+	// UploadsEndpoint is the host of GitHub-owned storage uploads,
+	// uploads.github.com by default.
+	UploadsEndpoint string
+	// Credential authenticates every request.
+	Credential Credential
+	// MigrationToken is passed to GitHub Enterprise Importer as the
+	// githubPat of migrations. It defaults to the current token of
+	// Credential.
+	MigrationToken string
+}
+
+// target returns the internal client of g.
+func (g GitHub) target() *github.Target {
+	var client clients.GitHubClient
+	if g.Credential != nil {
+		client = clients.NewGitHubClientWithCredential(g.Credential)
+	}
+	return &github.Target{
+		APIHost:     g.APIEndpoint,
+		UploadsHost: g.UploadsEndpoint,
+		Client:      client,
+	}
+}
+
+// GitLab is the GitLab instance archives were exported from.
+type GitLab struct {
+	// Host is the URL of the instance, https://gitlab.com by default. It is
+	// the URL of the migration source created on GitHub.
+	Host string
+}
+
+func (g GitLab) host() string {
+	if g.Host != "" {
+		return g.Host
+	}
+	return "https://gitlab.com"
+}
+
+// Migrator migrates GitLab repositories to GitHub.
+type Migrator struct {
+	GitHub GitHub
+	GitLab GitLab
+	// Store is where archives are uploaded for GitHub to download.
+	Store Store
+	// Logger receives the progress of migrations. It defaults to
+	// logger.Logger.
+	Logger *zap.Logger
+	// Events receives the lifecycle events of migrations. A nil Bus discards
+	// them.
+	Events *Bus
+}
+
+func (m *Migrator) logger() *zap.Logger {
+	if m.Logger != nil {
+		return m.Logger
+	}
+	return ghlog.Logger
+}
+
+// publish publishes event on the Events of m, logging the failures of sinks
+// to the Logger of m unless the Bus has its own.
+func (m *Migrator) publish(ctx context.Context, event Event) {
+	m.Events.publish(ctx, event, m.logger())
+}
+
+// Phase is the time spent in one phase of a migration.
+type Phase struct {
+	Name     string
+	Duration time.Duration
+	// Error is the error the phase failed with, if any.
+	Error string
+}
+
+// Result is the outcome of the migration of one repository.
+type Result struct {
+	SourceURL string
+	// Repository is the name of the target repository.
+	Repository  string
+	MigrationID string
+	ArchiveSize int64
+	// Storage is the Name of the Store the archive was uploaded to.
+	Storage string
+	// State is the final state GitHub reported, StateError or
+	// StateSkipped.
+	State           string
+	FailureReason   string
+	Warnings        int
+	MigrationLogURL string
+	StartedAt       time.Time
+	FinishedAt      time.Time
+	Phases          []Phase
+}
+
+// finish records the end of the migration of r, which failed with err if it
+// is not nil.
+func (r *Result) finish(err error) {
+	r.FinishedAt = time.Now()
+	if err != nil && r.FailureReason == "" && failure.KindOf(err) != failure.KindWarnings {
+		r.FailureReason = ghlog.Redact(err.Error())
+	}
+	if r.State == "" {
+		r.State = StateError
+	}
+}
+
+// event returns an event of the given type about the import of r into org.
+func (r *Result) event(eventType EventType, org string) Event {
+	return Event{
+		Type:            eventType,
+		SourceURL:       r.SourceURL,
+		Org:             org,
+		Repository:      r.Repository,
+		MigrationID:     r.MigrationID,
+		Storage:         r.Storage,
+		ArchiveSize:     r.ArchiveSize,
+		State:           r.State,
+		Warnings:        r.Warnings,
+		MigrationLogURL: r.MigrationLogURL,
+	}
+}
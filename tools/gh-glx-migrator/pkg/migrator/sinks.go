@@ -0,0 +1,204 @@
+package migrator
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"sync"
+)
+
+// Headers of the requests of a WebhookSink.
+const (
+	// EventHeader holds the EventType of the request body.
+	EventHeader = "X-Glx-Event"
+	// SignatureHeader holds sha256= and the hex-encoded HMAC-SHA256 of the
+	// request body, keyed with the secret of the WebhookSink.
+	SignatureHeader = "X-Glx-Signature-256"
+)
+
+// WebhookSink posts every event as JSON to a URL.
+type WebhookSink struct {
+	URL string
+	// Secret, when set, signs the body of every request in SignatureHeader,
+	// so the receiver can check that it comes from the migrator.
+	Secret string
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+func (s *WebhookSink) Send(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	headers := map[string]string{EventHeader: string(event.Type)}
+	if s.Secret != "" {
+		headers[SignatureHeader] = Sign(s.Secret, body)
+	}
+	return postJSON(ctx, s.Client, s.URL, body, headers)
+}
+
+// Sign returns the SignatureHeader of a request body signed with secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// SlackSink posts every event as a message to a Slack incoming webhook, or a
+// compatible one such as Mattermost's.
+type SlackSink struct {
+	URL string
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+func (s *SlackSink) Send(ctx context.Context, event Event) error {
+	text := event.Summary()
+	if event.MigrationLogURL != "" && (event.Type == EventMigrationWarnings || event.Type == EventMigrationFailed) {
+		text += fmt.Sprintf(" (<%s|migration log>)", event.MigrationLogURL)
+	}
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return fmt.Errorf("failed to marshal Slack message: %w", err)
+	}
+	return postJSON(ctx, s.Client, s.URL, body, nil)
+}
+
+// TeamsSink posts every event as a message card to a Microsoft Teams incoming
+// webhook.
+type TeamsSink struct {
+	URL string
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// teamsFact is a name and value of a message card section.
+type teamsFact struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func (s *TeamsSink) Send(ctx context.Context, event Event) error {
+	var facts []teamsFact
+	for _, fact := range []teamsFact{
+		{"Source", event.SourceURL},
+		{"Repository", event.target()},
+		{"Migration ID", event.MigrationID},
+		{"State", event.State},
+		{"Storage", event.Storage},
+		{"Warnings", warningsFact(event.Warnings)},
+		{"Migration log", event.MigrationLogURL},
+		{"Error", event.Error},
+	} {
+		if fact.Value != "" {
+			facts = append(facts, fact)
+		}
+	}
+
+	summary := event.Summary()
+	body, err := json.Marshal(map[string]interface{}{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"summary":    summary,
+		"title":      summary,
+		"themeColor": themeColor(event.Type),
+		"sections": []map[string]interface{}{{
+			"activityTitle": string(event.Type),
+			"facts":         facts,
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal Teams message: %w", err)
+	}
+	return postJSON(ctx, s.Client, s.URL, body, nil)
+}
+
+func warningsFact(warnings int) string {
+	if warnings == 0 {
+		return ""
+	}
+	return fmt.Sprint(warnings)
+}
+
+// themeColor is the accent colour of the message card of an event.
+func themeColor(eventType EventType) string {
+	switch eventType {
+	case EventExportFailed, EventMigrationFailed:
+		return "D1242F"
+	case EventMigrationWarnings:
+		return "BF8700"
+	case EventExportCompleted, EventMigrationSucceeded:
+		return "1A7F37"
+	}
+	return "0969DA"
+}
+
+// FileSink appends every event as a line of JSON to a file.
+type FileSink struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+func (s *FileSink) Send(_ context.Context, event Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open event file: %w", err)
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write event file: %w", err)
+	}
+	return file.Close()
+}
+
+// postJSON posts body to url. The URL is left out of errors, as incoming
+// webhook URLs embed a token.
+func postJSON(ctx context.Context, client *http.Client, url string, body []byte, headers map[string]string) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gh-glx-migrator")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *neturl.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("failed to send webhook request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package migrator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	awsUtils "github.com/ps-resources/gh-glx-migrator/internal/aws"
+	"github.com/ps-resources/gh-glx-migrator/internal/azure"
+	"github.com/ps-resources/gh-glx-migrator/internal/clients"
+	"github.com/ps-resources/gh-glx-migrator/internal/github"
+	ghlog "github.com/ps-resources/gh-glx-migrator/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// Store is storage that archives are uploaded to for GitHub to download.
+type Store interface {
+	// Name identifies the store in results, e.g. aws.
+	Name() string
+	// Upload uploads the archive and returns the URL GitHub downloads it
+	// from.
+	Upload(ctx context.Context, archive Archive) (string, error)
+	// Delete deletes the uploaded archive once GitHub no longer needs it.
+	Delete(ctx context.Context, archive Archive) error
+}
+
+// Archive is a migration archive to upload to a Store.
+type Archive struct {
+	// Path is the path of the local archive file.
+	Path string
+	// BlobName is the name of the uploaded archive.
+	BlobName string
+	// URLDuration is how long the URL returned by Upload is valid.
+	URLDuration time.Duration
+	// OrganizationDatabaseID is the database ID of the target organization.
+	OrganizationDatabaseID string
+
+	// target is the GitHub the archive is migrated to.
+	target *github.Target
+	// logger is the logger of the Migrator.
+	logger *zap.Logger
+}
+
+func (a Archive) log() *zap.Logger {
+	if a.logger != nil {
+		return a.logger
+	}
+	return ghlog.Logger
+}
+
+// S3Store uploads archives to an S3 bucket and hands GitHub presigned URLs.
+type S3Store struct {
+	Bucket string
+	// Client defaults to a client configured with the AWS settings of the
+	// environment and the active gh-glx profile, such as AWS_REGION and
+	// AWS_ENDPOINT_URL_S3, and the default credential chain of the AWS SDK.
+	Client *s3.Client
+}
+
+// s3Client is a clients.S3Client for a configured S3 client.
+type s3Client struct {
+	client *s3.Client
+}
+
+func (c s3Client) GetS3Client(context.Context) (*s3.Client, error) {
+	return c.client, nil
+}
+
+func (s *S3Store) Name() string { return "aws" }
+
+func (s *S3Store) manager(ctx context.Context) (*awsUtils.S3Manager, error) {
+	var client clients.S3Client = s3Client{client: s.Client}
+	if s.Client == nil {
+		client = clients.NewAwsClient()
+	}
+	manager, err := awsUtils.NewS3Manager(ctx, client, s.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize AWS S3 manager: %w", err)
+	}
+	return manager, nil
+}
+
+func (s *S3Store) Upload(ctx context.Context, archive Archive) (string, error) {
+	manager, err := s.manager(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.Open(archive.Path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			archive.log().Error("failed to close file", zap.Error(err))
+		}
+	}()
+
+	archive.log().Info("Uploading to S3 bucket",
+		zap.String("bucket", s.Bucket),
+		zap.String("key", archive.BlobName),
+		zap.String("file-path", archive.Path))
+
+	if err := manager.Upload(ctx, archive.BlobName, file); err != nil {
+		return "", fmt.Errorf("failed to upload to S3 bucket: %w", err)
+	}
+
+	presignedUrl, err := manager.GeneratePresignedURL(ctx, archive.BlobName, archive.URLDuration)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate presigned URL: %w", err)
+	}
+
+	// The presigned URL is a credential, and Logger may not redact it.
+	archive.log().Info("Generated pre-signed URL S3 Successfully",
+		zap.String("key", archive.BlobName),
+		zap.Time("expiration", time.Now().Add(archive.URLDuration)))
+
+	return presignedUrl, nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, archive Archive) error {
+	manager, err := s.manager(ctx)
+	if err != nil {
+		return err
+	}
+	if err := manager.DeleteObject(ctx, archive.BlobName); err != nil {
+		return fmt.Errorf("failed to delete file from S3: %w", err)
+	}
+	archive.log().Info("Deleted file from S3 bucket", zap.String("bucket", s.Bucket), zap.String("key", archive.BlobName))
+	return nil
+}
+
+// AzureStore uploads archives to an Azure Blob Storage container and hands
+// GitHub SAS URLs.
+type AzureStore struct {
+	Account   string
+	AccessKey string
+	Container string
+	// BlobEndpoint replaces https://<account>.blob.core.windows.net/, e.g.
+	// with the URL of an Azurite emulator.
+	BlobEndpoint string
+}
+
+func (s *AzureStore) Name() string { return "azure" }
+
+func (s *AzureStore) options(archive Archive) *azure.AzureOptions {
+	return &azure.AzureOptions{
+		StorageAccount:   s.Account,
+		StorageAccessKey: s.AccessKey,
+		ContainerName:    s.Container,
+		BlobName:         archive.BlobName,
+		ArchiveFilePath:  archive.Path,
+		BlobEndpoint:     s.BlobEndpoint,
+	}
+}
+
+func (s *AzureStore) Upload(ctx context.Context, archive Archive) (string, error) {
+	archive.log().Info("Uploading file to Azure Blob Storage",
+		zap.String("file", archive.Path),
+		zap.String("container", s.Container))
+
+	presignedUrl, err := azure.UploadToAzureBlob(ctx, s.options(archive), archive.URLDuration)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload to Azure Blob Storage: %w", err)
+	}
+
+	// The SAS URL is a credential, and Logger may not redact it.
+	archive.log().Info("File uploaded successfully",
+		zap.String("blob", archive.BlobName),
+		zap.Time("expiration", time.Now().Add(archive.URLDuration)))
+
+	return presignedUrl, nil
+}
+
+func (s *AzureStore) Delete(ctx context.Context, archive Archive) error {
+	if err := azure.DeleteBlob(ctx, s.options(archive)); err != nil {
+		return fmt.Errorf("failed to delete file from Azure Blob Storage: %w", err)
+	}
+	archive.log().Info("Deleted file from Azure Blob Storage", zap.String("container", s.Container), zap.String("blob", archive.BlobName))
+	return nil
+}
+
+// GitHubStore uploads archives to the GitHub-owned storage of the target
+// organization, with the credential of the Migrator.
+type GitHubStore struct {
+	// PartSize and MultipartThreshold default to 100 MiB and 5 GB.
+	PartSize           int64
+	MultipartThreshold int64
+}
+
+func (s *GitHubStore) Name() string { return "github" }
+
+func (s *GitHubStore) Upload(ctx context.Context, archive Archive) (string, error) {
+	if archive.target == nil {
+		return "", fmt.Errorf("GitHub-owned storage is only available to the archives of a Migrator")
+	}
+	uri, err := archive.target.UploadArchive(ctx, github.UploadArchiveInput{
+		ArchiveFilePath:    archive.Path,
+		OrganizationId:     archive.OrganizationDatabaseID,
+		PartSize:           s.PartSize,
+		MultipartThreshold: s.MultipartThreshold,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload to GitHub storage: %w", err)
+	}
+	archive.log().Info("Uploaded archive to GitHub storage successfully")
+	archive.log().Info("Archive URI", zap.String("uri", uri))
+	return uri, nil
+}
+
+// Delete does nothing: GitHub deletes archives in its storage itself.
+func (s *GitHubStore) Delete(context.Context, Archive) error {
+	return nil
+}